@@ -0,0 +1,144 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ByteRange labels the bytes [Start, End) of a serialized message with the
+// field they encode.
+type ByteRange struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Field string `json:"field"`
+}
+
+// AnnotateMessage splits the serialized message data into the byte ranges of
+// the array header and of every field, named as ExplainMessage names them,
+// e.g. MessageType, Request and Options. Every range covers only the encoding
+// of its element, so separators, whitespace and the end of the array are left
+// out.
+func AnnotateMessage(serializerName string, data []byte) ([]ByteRange, error) {
+	raw, err := DeserializeRaw(serializerName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []ByteRange
+	switch serializerName {
+	case JSONSerializer:
+		ranges, err = jsonFieldRanges(data)
+	case CBORSerializer:
+		ranges, err = cborFieldRanges(data)
+	case MsgPackSerializer:
+		ranges, err = msgPackFieldRanges(data)
+	default:
+		return nil, fmt.Errorf("unknown serializer %q", serializerName)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	names := make([]string, len(raw))
+	for i := range names {
+		names[i] = "field " + strconv.Itoa(i)
+	}
+
+	for _, field := range ExplainMessage(raw) {
+		if field.Position < len(names) && field.Name != "unknown" {
+			names[field.Position] = field.Name
+		}
+	}
+
+	ranges[0].Field = fmt.Sprintf("array of %d fields", len(raw))
+	for i := range ranges[1:] {
+		ranges[i+1].Field = names[i]
+	}
+
+	return ranges, nil
+}
+
+// jsonFieldRanges returns the range of the opening bracket of the top-level
+// JSON array data, followed by the range of every element.
+func jsonFieldRanges(data []byte) ([]ByteRange, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	end := int(decoder.InputOffset())
+	ranges := []ByteRange{{Start: end - 1, End: end}}
+	for decoder.More() {
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return nil, err
+		}
+
+		// The element is preceded by whitespace and a comma after the first.
+		start := end + bytes.IndexFunc(data[end:], func(r rune) bool {
+			return !strings.ContainsRune(" \t\r\n,", r)
+		})
+		end = int(decoder.InputOffset())
+		ranges = append(ranges, ByteRange{Start: start, End: end})
+	}
+
+	return ranges, nil
+}
+
+// cborFieldRanges is jsonFieldRanges for CBOR, starting with the range of the
+// array head.
+func cborFieldRanges(data []byte) ([]ByteRange, error) {
+	_, length, next, indefinite, err := cborHead(data, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := []ByteRange{{Start: 0, End: next}}
+	scan, path := &payloadScan{size: len(data)}, newScanPath()
+	for i := uint64(0); indefinite || i < length; i++ {
+		if indefinite && next < len(data) && data[next] == cborBreak {
+			break
+		}
+
+		start := next
+		if next, err = scan.walkCBOR(data, next, path); err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, ByteRange{Start: start, End: next})
+	}
+
+	return ranges, nil
+}
+
+// msgPackFieldRanges is jsonFieldRanges for MessagePack, starting with the
+// range of the array header.
+func msgPackFieldRanges(data []byte) ([]ByteRange, error) {
+	// A bytes.Reader isn't buffered by the decoder, so its position is the
+	// offset of the decoder.
+	reader := bytes.NewReader(data)
+	decoder := msgpack.NewDecoder(reader)
+	length, err := decoder.DecodeArrayLen()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := func() int { return len(data) - reader.Len() }
+	ranges := []ByteRange{{Start: 0, End: offset()}}
+	for i := 0; i < length; i++ {
+		start := offset()
+		if err = decoder.Skip(); err != nil {
+			return nil, err
+		}
+
+		ranges = append(ranges, ByteRange{Start: start, End: offset()})
+	}
+
+	return ranges, nil
+}
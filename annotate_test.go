@@ -0,0 +1,48 @@
+package wampprotocli
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+)
+
+func TestAnnotateMessage(t *testing.T) {
+	for _, tc := range []struct {
+		serializer string
+		data       string
+		ranges     [][2]int
+	}{
+		{JSONSerializer, hex.EncodeToString([]byte(`[48, 1,{} ,"a.b"]`)),
+			[][2]int{{0, 1}, {1, 3}, {5, 6}, {7, 9}, {11, 16}}},
+		{CBORSerializer, "84183001a063612e62", [][2]int{{0, 1}, {1, 3}, {3, 4}, {4, 5}, {5, 9}}},
+		// An array of indefinite length, ending with a break byte.
+		{CBORSerializer, "9f183001a063612e62ff", [][2]int{{0, 1}, {1, 3}, {3, 4}, {4, 5}, {5, 9}}},
+		{MsgPackSerializer, "94300180a3612e62", [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 8}}},
+	} {
+		data, err := hex.DecodeString(tc.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		annotations, err := AnnotateMessage(tc.serializer, data)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.serializer, tc.data, err)
+		}
+
+		ranges := make([][2]int, 0, len(annotations))
+		fields := make([]string, 0, len(annotations))
+		for _, annotation := range annotations {
+			ranges = append(ranges, [2]int{annotation.Start, annotation.End})
+			fields = append(fields, annotation.Field)
+		}
+
+		if !reflect.DeepEqual(ranges, tc.ranges) {
+			t.Errorf("%s %s: expected the ranges %v, got %v", tc.serializer, tc.data, tc.ranges, ranges)
+		}
+
+		expected := []string{"array of 4 fields", "MessageType", "Request", "Options", "Procedure"}
+		if !reflect.DeepEqual(fields, expected) {
+			t.Errorf("%s %s: expected the fields %v, got %v", tc.serializer, tc.data, expected, fields)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+type SerializeRequest struct {
+	Serializer string `json:"serializer"`
+	Message    []any  `json:"message"`
+	Encoding   string `json:"encoding"`
+	// NoValidate skips spec validation, so that deliberately malformed
+	// messages can be produced for negative tests.
+	NoValidate bool `json:"no_validate"`
+	// Strict turns validation warnings into errors.
+	Strict bool `json:"strict"`
+	// Lenient turns invalid UTF-8 from an error into a warning.
+	Lenient bool `json:"lenient"`
+	// AllowReserved accepts REGISTER and PUBLISH messages for procedures
+	// and topics reserved under wamp., e.g. to test that routers reject them.
+	AllowReserved bool `json:"allow_reserved"`
+	// MaxMessageSize rejects larger serialized messages, regardless of
+	// NoValidate. 0 means unlimited.
+	MaxMessageSize int `json:"max_message_size"`
+	// SortKeys orders map keys so that the output is reproducible.
+	SortKeys bool `json:"sort_keys"`
+	// NonFinite is the policy for NaN and infinite floats when serializing
+	// to JSON, one of NonFinitePolicies.
+	NonFinite string `json:"nonfinite"`
+	// BigInt is the policy for integers beyond 2^53 when serializing to
+	// JSON, one of BigIntPolicies.
+	BigInt string `json:"bigint"`
+}
+
+type SerializeResponse struct {
+	Data     string    `json:"data"`
+	Warnings []Warning `json:"warnings,omitempty"`
+}
+
+type ParseRequest struct {
+	Serializer string `json:"serializer"`
+	Data       string `json:"data"`
+	// NoValidate, Strict, Lenient, AllowReserved and MaxMessageSize are as
+	// for SerializeRequest.
+	NoValidate     bool `json:"no_validate"`
+	Strict         bool `json:"strict"`
+	Lenient        bool `json:"lenient"`
+	AllowReserved  bool `json:"allow_reserved"`
+	MaxMessageSize int  `json:"max_message_size"`
+	// NonFinite is the policy for NaN and infinite floats in the parsed
+	// message, which JSON cannot represent. They are kept unless it is set,
+	// see ParseJSON.
+	NonFinite string `json:"nonfinite"`
+	// BigInt is the policy for integers beyond 2^53 in the parsed message.
+	BigInt string `json:"bigint"`
+	// WireTypes reports the wire type of every number in the message.
+	WireTypes bool `json:"wire_types"`
+	// AllowUnknownTypes decodes messages whose type is unknown, e.g. the
+	// extension messages of a router, into their raw positional elements
+	// with a warning instead of failing.
+	AllowUnknownTypes bool `json:"allow_unknown_types"`
+	// PPTPrivateKey and PPTPeerPublicKey are the hex encoded keys that
+	// payloads encrypted end-to-end with cryptobox are decrypted with.
+	// Sealed payloads only require the private key.
+	PPTPrivateKey    string `json:"ppt_private_key"`
+	PPTPeerPublicKey string `json:"ppt_peer_public_key"`
+}
+
+type ParseResponse struct {
+	Type    int    `json:"type"`
+	Name    string `json:"name"`
+	Message []any  `json:"message"`
+	// Fields are the fields of Message by the keys NamedFields returns.
+	Fields  map[string]any `json:"fields"`
+	Numbers []WireNumber   `json:"numbers,omitempty"`
+	// Payload is the payload unwrapped from a message in payload passthru
+	// mode whose payload is serialized separately.
+	Payload  *PPTPayload `json:"ppt_payload,omitempty"`
+	Warnings []Warning   `json:"warnings,omitempty"`
+}
+
+type SignRequest struct {
+	Challenge  string `json:"challenge"`
+	PrivateKey string `json:"private_key"`
+}
+
+type SignResponse struct {
+	Signature string `json:"signature"`
+}
+
+type VerifyRequest struct {
+	Signature string `json:"signature"`
+	PublicKey string `json:"public_key"`
+	// Challenge is the hex encoded challenge the signature must sign, if set.
+	Challenge string `json:"challenge,omitempty"`
+}
+
+type VerifyResponse struct {
+	Valid bool `json:"valid"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+	// Violations are the spec violations the error consists of, if it is a
+	// validation error.
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// DecodeRequest decodes a JSON request body. Numbers are decoded as
+// json.Number so that integers survive until NormalizeJSON is applied.
+func DecodeRequest(r io.Reader, request any) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	return decoder.Decode(request)
+}
+
+// CallJSON runs fn on a JSON encoded request and returns the JSON encoded
+// response. Failures are reported as {"error": "..."} so that callers across
+// language boundaries only ever have to deal with a single string.
+func CallJSON[Req any, Resp any](fn func(*Req) (*Resp, error), request []byte) []byte {
+	var req Req
+	if err := DecodeRequest(bytes.NewReader(request), &req); err != nil {
+		return errorJSON(fmt.Sprintf("invalid request: %s", err), nil)
+	}
+
+	resp, err := fn(&req)
+	if err != nil {
+		return errorJSON(err.Error(), Violations(err))
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return errorJSON(err.Error(), nil)
+	}
+
+	return data
+}
+
+func errorJSON(message string, violations []Violation) []byte {
+	data, _ := json.Marshal(errorResponse{Error: message, Violations: violations})
+	return data
+}
+
+// Serialize encodes a positional WAMP message with the requested serializer,
+// validating it against the spec first.
+func Serialize(request *SerializeRequest) (*SerializeResponse, error) {
+	raw, _ := NormalizeJSON(request.Message).([]any)
+
+	var warnings []Warning
+	if request.Serializer == JSONSerializer {
+		var err error
+		if raw, warnings, err = applyJSONPolicies(raw, request.NonFinite, request.BigInt); err != nil {
+			return nil, err
+		}
+	}
+
+	if !request.NoValidate {
+		options := ValidationOptions{
+			Strict:        request.Strict,
+			Lenient:       request.Lenient,
+			AllowReserved: request.AllowReserved,
+		}
+		validationWarnings, err := ValidateRaw(raw, options)
+		if err != nil {
+			return nil, err
+		}
+
+		warnings = append(warnings, validationWarnings...)
+	}
+
+	message, err := MessageFromRaw(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	serialize := SerializeMessage
+	if request.SortKeys {
+		serialize = SerializeMessageSorted
+	}
+
+	data, err := serialize(request.Serializer, message)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = CheckMessageSize(len(data), request.MaxMessageSize); err != nil {
+		return nil, err
+	}
+
+	encoded, err := EncodeBytes(data, request.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SerializeResponse{Data: encoded, Warnings: warnings}, nil
+}
+
+// Parse decodes serialized bytes into their positional WAMP representation
+// and validates the result against the spec.
+func Parse(request *ParseRequest) (*ParseResponse, error) {
+	data, err := DecodeBytes(request.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseBytes(request, data)
+}
+
+// ParseBytes is Parse for the serialized bytes data instead of request.Data,
+// e.g. for messages read from a stream, which needn't be encoded first.
+func ParseBytes(request *ParseRequest, data []byte) (*ParseResponse, error) {
+	if err := CheckMessageSize(len(data), request.MaxMessageSize); err != nil {
+		return nil, err
+	}
+
+	options := ValidationOptions{
+		Strict:        request.Strict,
+		Lenient:       request.Lenient,
+		AllowReserved: request.AllowReserved,
+	}
+	var warnings []Warning
+	var err error
+	if !request.NoValidate {
+		if warnings, err = ValidatePayload(request.Serializer, data, options); err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err := DeserializeRaw(request.Serializer, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.AllowUnknownTypes {
+		response, unknownErr := parseUnknownMessage(request, raw, options)
+		if unknownErr != nil {
+			return nil, unknownErr
+		} else if response != nil {
+			response.Warnings = append(warnings, response.Warnings...)
+			return response, nil
+		}
+	}
+
+	if !request.NoValidate {
+		var messageWarnings []Warning
+		if messageWarnings, err = ValidateRaw(raw, options); err != nil {
+			return nil, err
+		}
+
+		warnings = append(warnings, messageWarnings...)
+	}
+
+	message, err := MessageFromRaw(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	// The fields are named after the policies are applied.
+	response := newParseResponse(message)
+	if request.WireTypes {
+		if response.Numbers, err = WireNumbers(request.Serializer, data); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := CryptoboxKeysFromHex(request.PPTPrivateKey, request.PPTPeerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	defer keys.Wipe()
+
+	// Malformed payloads of unvalidated messages are left wrapped.
+	response.Payload, err = UnpackPPT(request.Serializer, response.Message, keys)
+	if err != nil && !request.NoValidate {
+		return nil, err
+	}
+
+	var policyWarnings []Warning
+	if response.Message, policyWarnings, err = applyParsePolicies(response.Message, request); err != nil {
+		return nil, err
+	}
+
+	response.Fields = NamedFields(response.Message)
+	response.Warnings = append(warnings, policyWarnings...)
+	return response, nil
+}
+
+// parseUnknownMessage describes the positional elements raw if they are a
+// message of an unknown type, and returns nil otherwise. Under
+// options.Strict the unknown type is an error.
+func parseUnknownMessage(request *ParseRequest, raw []any, options ValidationOptions) (*ParseResponse, error) {
+	if len(raw) == 0 || !isIntegral(raw[0]) {
+		return nil, nil
+	}
+
+	if schema, _ := messageSchema(rawMessageType(raw)); schema != nil {
+		return nil, nil
+	}
+
+	warnings, err := finishValidation([]Warning{{
+		Category: CategoryStructure,
+		Message:  fmt.Sprintf("unknown message type %v", raw[0]),
+		Spec:     SpecMessageType,
+	}}, nil, options)
+	if err != nil {
+		return nil, err
+	}
+
+	message, policyWarnings, err := applyParsePolicies(raw, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParseResponse{
+		Type:     rawMessageType(raw),
+		Name:     MessageName(rawMessageType(raw)),
+		Message:  message,
+		Fields:   NamedFields(message),
+		Warnings: append(warnings, policyWarnings...),
+	}, nil
+}
+
+// ParseJSON is Parse for callers returning the parsed message as JSON, such
+// as the HTTP and library APIs. NaN and infinite floats fail as by
+// NonFiniteError unless the request selects another policy.
+func ParseJSON(request *ParseRequest) (*ParseResponse, error) {
+	if request.NonFinite == "" {
+		withPolicy := *request
+		withPolicy.NonFinite = NonFiniteError
+		request = &withPolicy
+	}
+
+	return Parse(request)
+}
+
+// applyParsePolicies applies the policies of request to the parsed message
+// raw. NaN and infinite floats are kept unless a policy for them is set, as
+// only JSON cannot represent them.
+func applyParsePolicies(raw []any, request *ParseRequest) ([]any, []Warning, error) {
+	if request.NonFinite != "" {
+		return applyJSONPolicies(raw, request.NonFinite, request.BigInt)
+	}
+
+	replaced, warnings, err := ApplyBigIntPolicy(raw, request.BigInt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, _ := replaced.([]any)
+	return result, warnings, nil
+}
+
+// applyJSONPolicies applies the policies for values JSON cannot represent
+// faithfully to raw.
+func applyJSONPolicies(raw []any, nonFinite, bigInt string) ([]any, []Warning, error) {
+	replaced, warnings, err := ReplaceNonFinite(raw, nonFinite)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replaced, bigIntWarnings, err := ApplyBigIntPolicy(replaced, bigInt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, _ := replaced.([]any)
+	return result, append(warnings, bigIntWarnings...), nil
+}
+
+// NewParseResponse describes a decoded message.
+func NewParseResponse(message messages.Message) *ParseResponse {
+	response := newParseResponse(message)
+	response.Fields = NamedFields(response.Message)
+	return response
+}
+
+// newParseResponse is NewParseResponse without the named fields.
+func newParseResponse(message messages.Message) *ParseResponse {
+	return &ParseResponse{
+		Type:    message.Type(),
+		Name:    MessageName(message.Type()),
+		Message: MarshalMessage(message),
+	}
+}
+
+// SignCryptoSign signs a hex encoded cryptosign challenge with a hex encoded private key.
+func SignCryptoSign(request *SignRequest) (*SignResponse, error) {
+	challenge, err := hex.DecodeString(request.Challenge)
+	if err != nil {
+		return nil, fmt.Errorf("challenge must be hex encoded: %w", err)
+	}
+
+	seed, err := DecodeSecretHex(request.PrivateKey, "private key")
+	if err != nil {
+		return nil, err
+	}
+
+	keyPair, err := KeyPairFromSeed(seed)
+	clear(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	defer keyPair.Wipe()
+	signature, err := SignChallenge(challenge, keyPair)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignResponse{Signature: hex.EncodeToString(signature)}, nil
+}
+
+// VerifyCryptoSign checks a cryptosign signature against a hex encoded public key.
+func VerifyCryptoSign(request *VerifyRequest) (*VerifyResponse, error) {
+	signature, err := hex.DecodeString(request.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("signature must be hex encoded: %w", err)
+	}
+
+	publicKey, err := hex.DecodeString(request.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("public key must be hex encoded: %w", err)
+	}
+
+	if request.Challenge == "" {
+		valid, err := VerifySignature(signature, publicKey)
+		if err != nil {
+			return nil, err
+		}
+
+		return &VerifyResponse{Valid: valid}, nil
+	}
+
+	challenge, err := hex.DecodeString(request.Challenge)
+	if err != nil {
+		return nil, fmt.Errorf("challenge must be hex encoded: %w", err)
+	}
+
+	valid, err := VerifyChallengeSignature(signature, challenge, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VerifyResponse{Valid: valid}, nil
+}
@@ -0,0 +1,129 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+// TestParseNonFinite parses a CBOR CALL whose argument is NaN, which only
+// needs a policy when the message is returned as JSON.
+func TestParseNonFinite(t *testing.T) {
+	const call = "85183001a063612e6281f97e00"
+	response, err := Parse(&ParseRequest{Serializer: CBORSerializer, Data: call})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	args, _ := response.Message[4].([]any)
+	if f, ok := args[0].(float64); !ok || !math.IsNaN(f) {
+		t.Errorf("expected NaN to be kept, got %v", response.Message)
+	}
+
+	if _, err = ParseJSON(&ParseRequest{Serializer: CBORSerializer, Data: call}); err == nil {
+		t.Error("ParseJSON: expected an error for NaN")
+	}
+
+	response, err = ParseJSON(&ParseRequest{Serializer: CBORSerializer, Data: call, NonFinite: NonFiniteNull})
+	if err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+
+	if args, _ = response.Message[4].([]any); args[0] != nil {
+		t.Errorf("expected NaN to be replaced with null, got %v", response.Message)
+	}
+}
+
+// TestValidateRawID parses and serializes a CALL whose request ID is a
+// float, which converting it to a message would truncate to 1.
+func TestValidateRawID(t *testing.T) {
+	data := hex.EncodeToString([]byte(`[48,1.5,{},"a.b"]`))
+	if _, err := Parse(&ParseRequest{Serializer: JSONSerializer, Data: data}); !errors.As(err, new(*Violation)) {
+		t.Errorf("Parse: expected a violation for a float request ID, got %v", err)
+	}
+
+	message := []any{int64(48), 1.5, map[string]any{}, "a.b"}
+	_, err := Serialize(&SerializeRequest{Serializer: JSONSerializer, Message: message})
+	if !errors.As(err, new(*Violation)) {
+		t.Errorf("Serialize: expected a violation for a float request ID, got %v", err)
+	}
+}
+
+// benchmarkCall is a CALL with nested arguments, as typical RPC traffic
+// carries.
+func benchmarkCall() []any {
+	item := map[string]any{"id": int64(42), "name": "widget", "price": 9.99, "tags": []any{"a", "b", "c"}}
+	items := make([]any, 16)
+	for i := range items {
+		items[i] = item
+	}
+
+	return []any{int64(48), int64(7), map[string]any{"timeout": int64(1000), "receive_progress": true},
+		"com.example.inventory.update", []any{"warehouse-1", items}, map[string]any{"dry_run": false}}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, serializer := range SerializerNames() {
+		data, err := serializeValue(serializer, benchmarkCall())
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		request := &ParseRequest{Serializer: serializer, Data: hex.EncodeToString(data)}
+		b.Run(serializer, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(request); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkParseStream parses length-prefixed messages read with a
+// MessageReader, as message parse --stream does.
+func BenchmarkParseStream(b *testing.B) {
+	const messages = 100
+	for _, serializer := range SerializerNames() {
+		data, err := serializeValue(serializer, benchmarkCall())
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var stream bytes.Buffer
+		for i := 0; i < messages; i++ {
+			_ = binary.Write(&stream, binary.BigEndian, uint32(len(data)))
+			stream.Write(data)
+		}
+
+		b.Run(serializer, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(stream.Len()))
+			for i := 0; i < b.N; i++ {
+				reader, err := NewMessageReader(bytes.NewReader(stream.Bytes()), LengthFraming, 0)
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				for {
+					message, err := reader.Next()
+					if errors.Is(err, io.EOF) {
+						break
+					} else if err != nil {
+						b.Fatal(err)
+					}
+
+					if _, err = ParseBytes(&ParseRequest{Serializer: serializer}, message); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package wampprotocli
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// CryptoPrimitive describes a cryptographic primitive the tool uses and the
+// parameters it uses it with.
+type CryptoPrimitive struct {
+	Purpose        string `json:"purpose"`
+	Primitive      string `json:"primitive"`
+	Parameters     string `json:"parameters"`
+	Implementation string `json:"implementation"`
+}
+
+// SelfAudit reports the cryptography of the running binary for security
+// reviews.
+type SelfAudit struct {
+	GoVersion string `json:"go_version"`
+	// Modules maps the modules providing cryptography to their versions.
+	Modules    map[string]string `json:"modules"`
+	Primitives []CryptoPrimitive `json:"primitives"`
+	// Practices lists how secrets are handled.
+	Practices []string `json:"practices"`
+}
+
+// AuditSelf reports the cryptographic primitives and parameters in use.
+func AuditSelf() *SelfAudit {
+	audit := &SelfAudit{
+		GoVersion: runtime.Version(),
+		Modules:   map[string]string{},
+		Primitives: []CryptoPrimitive{
+			{
+				Purpose:   "cryptosign signatures",
+				Primitive: "Ed25519 (RFC 8032)",
+				Parameters: fmt.Sprintf("%d byte private key seed, %d byte public key, %d byte signature "+
+					"followed by the challenge", ed25519.SeedSize, ed25519.PublicKeySize, ed25519.SignatureSize),
+				Implementation: "crypto/ed25519",
+			},
+			{
+				Purpose:   "cryptosign verification",
+				Primitive: "Ed25519 verification, constant-time challenge comparison",
+				Parameters: fmt.Sprintf("%d byte signed message, the challenge is compared with crypto/subtle",
+					ed25519.SignatureSize+challengeSize),
+				Implementation: "golang.org/x/crypto/nacl/sign, crypto/subtle",
+			},
+			{
+				Purpose:   "end-to-end payload encryption (" + CryptoboxCipher + ")",
+				Primitive: "NaCl box: X25519 key agreement, XSalsa20-Poly1305",
+				Parameters: fmt.Sprintf("%d byte keys, random %d byte nonce prepended, %d byte tag",
+					cryptoboxKeySize, cryptoboxNonceSize, box.Overhead),
+				Implementation: "golang.org/x/crypto/nacl/box",
+			},
+			{
+				Purpose:   "anonymous payload encryption (" + SealedBoxCipher + ")",
+				Primitive: "NaCl sealed box: ephemeral X25519, XSalsa20-Poly1305",
+				Parameters: fmt.Sprintf("%d byte ephemeral public key prepended, nonce derived with BLAKE2b, "+
+					"%d byte tag", cryptoboxKeySize, box.Overhead),
+				Implementation: "golang.org/x/crypto/nacl/box",
+			},
+			{
+				Purpose:        "wampcra signatures",
+				Primitive:      "HMAC-SHA256, constant-time signature comparison",
+				Parameters:     "key is the secret or the base64 encoded derived key, signature base64 encoded",
+				Implementation: "crypto/hmac, crypto/sha256",
+			},
+			{
+				Purpose:   "wampcra key derivation",
+				Primitive: "PBKDF2-HMAC-SHA256 (RFC 8018)",
+				Parameters: fmt.Sprintf("salt, iterations and key length from the CHALLENGE, %d iterations "+
+					"and %d bytes by default", DefaultCRAIterations, DefaultCRAKeyLen),
+				Implementation: "github.com/xconnio/wampproto-go/auth (golang.org/x/crypto/pbkdf2)",
+			},
+			{
+				Purpose:        "keys, nonces, challenges and global scope IDs",
+				Primitive:      "operating system CSPRNG",
+				Parameters:     fmt.Sprintf("IDs drawn uniformly from [1, %d]", int64(MaxID)),
+				Implementation: "crypto/rand",
+			},
+		},
+		Practices: []string{
+			"Signed challenges are compared with the expected challenge in constant time; Ed25519 " +
+				"verification involves no secrets.",
+			"Decoded private keys are overwritten with zeros once an operation completes.",
+			"Hex encoded keys given as flags or request fields are immutable strings and stay in memory " +
+				"until garbage collected.",
+			"The random invocation policy uses math/rand with a user chosen seed and is not used for secrets.",
+			"Seeding replaces crypto/rand with a deterministic SHA-256 counter stream for fixtures, which makes " +
+				"generated keys predictable.",
+		},
+	}
+
+	if randomSource != rand.Reader {
+		audit.Practices = append(audit.Practices, "Randomness is seeded for this invocation, generated keys "+
+			"must not be used outside tests.")
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, module := range info.Deps {
+			if strings.HasPrefix(module.Path, "golang.org/x/crypto") ||
+				strings.HasPrefix(module.Path, "github.com/xconnio/wampproto-go") {
+				audit.Modules[module.Path] = module.Version
+			}
+		}
+	}
+
+	return audit
+}
+
+// challengeSize is the size of cryptosign challenges.
+const challengeSize = 32
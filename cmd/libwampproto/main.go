@@ -0,0 +1,46 @@
+// Command libwampproto exports the wampproto-cli library with a C ABI.
+//
+// Build it with -buildmode=c-shared to get a shared library and header.
+// Every function takes a NUL terminated JSON request, identical to the body
+// of the corresponding HTTP endpoint, and returns a newly allocated JSON
+// response which must be released with wampproto_free.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+//export wampproto_serialize
+func wampproto_serialize(request *C.char) *C.char {
+	return C.CString(string(wampprotocli.CallJSON(wampprotocli.Serialize, []byte(C.GoString(request)))))
+}
+
+//export wampproto_parse
+func wampproto_parse(request *C.char) *C.char {
+	return C.CString(string(wampprotocli.CallJSON(wampprotocli.ParseJSON, []byte(C.GoString(request)))))
+}
+
+//export wampproto_cryptosign_sign
+func wampproto_cryptosign_sign(request *C.char) *C.char {
+	return C.CString(string(wampprotocli.CallJSON(wampprotocli.SignCryptoSign, []byte(C.GoString(request)))))
+}
+
+//export wampproto_cryptosign_verify
+func wampproto_cryptosign_verify(request *C.char) *C.char {
+	return C.CString(string(wampprotocli.CallJSON(wampprotocli.VerifyCryptoSign, []byte(C.GoString(request)))))
+}
+
+//export wampproto_free
+func wampproto_free(response *C.char) {
+	C.free(unsafe.Pointer(response))
+}
+
+// main is required by -buildmode=c-shared but never runs.
+func main() {}
@@ -0,0 +1,35 @@
+//go:build js && wasm
+
+// Command wampproto-wasm exposes the wampproto-cli library to JavaScript.
+//
+// Once loaded it registers a global "wampproto" object whose functions take
+// the same JSON request documents as the HTTP endpoints and return the JSON
+// response as a string. Failures are returned as {"error": "..."}.
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func main() {
+	js.Global().Set("wampproto", js.ValueOf(map[string]any{
+		"serialize":        export(wampprotocli.Serialize),
+		"parse":            export(wampprotocli.ParseJSON),
+		"signCryptoSign":   export(wampprotocli.SignCryptoSign),
+		"verifyCryptoSign": export(wampprotocli.VerifyCryptoSign),
+	}))
+
+	select {}
+}
+
+func export[Req any, Resp any](fn func(*Req) (*Resp, error)) js.Func {
+	return js.FuncOf(func(_ js.Value, args []js.Value) any {
+		if len(args) != 1 || args[0].Type() != js.TypeString {
+			return `{"error":"expected a single JSON string argument"}`
+		}
+
+		return string(wampprotocli.CallJSON(fn, []byte(args[0].String())))
+	})
+}
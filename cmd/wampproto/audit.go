@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerAuditSelf(c *cli, cmd *kingpin.CmdClause) {
+	output := cmd.Flag("output", "Output format.").Default(textOutput).Enum(textOutput, markdownOutput, jsonOutput)
+	c.handle(cmd, func(e *env) error {
+		return writeSelfAudit(e.stdout, *output, wampprotocli.AuditSelf())
+	})
+}
+
+func writeSelfAudit(w io.Writer, output string, audit *wampprotocli.SelfAudit) error {
+	if output == jsonOutput {
+		return json.NewEncoder(w).Encode(audit)
+	}
+
+	paths := make([]string, 0, len(audit.Modules))
+	for path := range audit.Modules {
+		paths = append(paths, path)
+	}
+
+	slices.Sort(paths)
+	fmt.Fprintf(w, "go: %s\n", audit.GoVersion)
+	for _, path := range paths {
+		fmt.Fprintf(w, "%s: %s\n", path, audit.Modules[path])
+	}
+
+	fmt.Fprintln(w)
+	rows := [][]string{{"purpose", "primitive", "parameters", "implementation"}}
+	for _, primitive := range audit.Primitives {
+		rows = append(rows, []string{primitive.Purpose, primitive.Primitive, primitive.Parameters,
+			primitive.Implementation})
+	}
+
+	if err := writeTable(w, output, rows); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	for _, practice := range audit.Practices {
+		fmt.Fprintf(w, "- %s\n", practice)
+	}
+
+	return nil
+}
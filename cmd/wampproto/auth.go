@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+// keyPair is the output of commands generating keys.
+type keyPair struct {
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
+func registerAuth(c *cli, cmd *kingpin.CmdClause) {
+	cryptoboxCmd := cmd.Command("cryptobox", "Keys for end-to-end payload encryption.")
+
+	keygenCmd := cryptoboxCmd.Command("keygen", "Generate a cryptobox key pair and print it hex encoded as JSON.")
+	c.handle(keygenCmd, func(e *env) error {
+		publicKey, privateKey, err := wampprotocli.GenerateCryptoboxKey()
+		if err != nil {
+			return err
+		}
+
+		defer clear(privateKey)
+		return json.NewEncoder(e.stdout).Encode(keyPair{
+			PrivateKey: hex.EncodeToString(privateKey),
+			PublicKey:  hex.EncodeToString(publicKey),
+		})
+	})
+
+	registerAuthWalkthrough(c, cmd)
+	registerAuthOffline(c, cmd)
+	registerAuthCRA(c, cmd)
+	registerAuthTicket(c, cmd)
+	registerAuthExportPrincipal(c, cmd)
+}
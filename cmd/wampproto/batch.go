@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+const maxBatchLineSize = 64 << 20
+
+func registerBatch(c *cli, cmd *kingpin.CmdClause) {
+	workers := cmd.Flag("workers", "Number of command lines to execute concurrently. "+
+		"Output order always matches input order.").Default("1").Int()
+
+	c.handle(cmd, func(e *env) error {
+		if *workers < 1 {
+			return fmt.Errorf("workers must be at least 1, was %d", *workers)
+		} else if *workers > 1 && e.seeded {
+			return errors.New("--seed can't be combined with --workers, concurrent lines would draw from the " +
+				"seeded stream in no fixed order")
+		}
+
+		// Results are queued in input order; the queue capacity together with
+		// the semaphore bounds how far execution may run ahead of the output.
+		queue := make(chan chan lineResult, *workers)
+		semaphore := make(chan struct{}, *workers)
+
+		var scanErr error
+		go func() {
+			defer close(queue)
+
+			scanner := bufio.NewScanner(e.stdin)
+			scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxBatchLineSize)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+
+				resultCh := make(chan lineResult, 1)
+				queue <- resultCh
+				semaphore <- struct{}{}
+				go func() {
+					defer func() { <-semaphore }()
+
+					output, err := runLine(e, line, *workers > 1)
+					resultCh <- lineResult{output: output, err: err}
+				}()
+			}
+
+			scanErr = scanner.Err()
+		}()
+
+		var total, failed int
+		for resultCh := range queue {
+			result := <-resultCh
+			total++
+			if result.err != nil {
+				failed++
+				fmt.Fprintf(e.stdout, "error: %s\n", singleLine(result.err.Error()))
+				continue
+			}
+
+			fmt.Fprintln(e.stdout, singleLine(result.output))
+		}
+
+		if scanErr != nil {
+			return scanErr
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d commands failed", failed, total)
+		}
+
+		return nil
+	})
+}
+
+type lineResult struct {
+	output string
+	err    error
+}
+
+// runLine executes a single command line and returns everything it wrote to
+// stdout. Lines executed concurrently can't be seeded: the random source is
+// shared by the whole process, so other lines would draw from the seeded,
+// predictable stream, in no fixed order.
+func runLine(e *env, line string, concurrent bool) (string, error) {
+	args, err := splitArgs(line)
+	if err != nil {
+		return "", err
+	}
+
+	if len(args) > 0 && args[0] == "batch" {
+		return "", errors.New("batch commands cannot be nested")
+	}
+
+	if concurrent && slices.ContainsFunc(args, isSeedFlag) {
+		return "", errors.New("--seed can't be given per line with --workers, run the batch with --workers 1 " +
+			"to seed its lines")
+	}
+
+	if e.strict {
+		args = append([]string{"--strict"}, args...)
+	}
+
+	if e.showSecrets {
+		args = append([]string{"--show-secrets"}, args...)
+	}
+
+	var stdout bytes.Buffer
+	if err = execute(e.ctx, args, strings.NewReader(""), &stdout, e.stderr, e.warnings); err != nil {
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
+
+// isSeedFlag reports whether arg sets --seed.
+func isSeedFlag(arg string) bool {
+	return arg == "--seed" || strings.HasPrefix(arg, "--seed=")
+}
+
+// singleLine keeps the one-line-per-command contract of batch mode by
+// escaping embedded newlines.
+func singleLine(output string) string {
+	return strings.ReplaceAll(strings.TrimRight(output, "\n"), "\n", `\n`)
+}
+
+// splitArgs splits a command line into arguments using POSIX shell quoting
+// rules: single quotes are literal, double quotes allow backslash escapes and
+// a backslash outside quotes escapes the next character.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var inArg bool
+
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == ' ' || ch == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case ch == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+
+			current.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case ch == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte(`"\$`+"`", line[i+1]) >= 0 {
+					i++
+				}
+				current.WriteByte(line[i])
+			}
+
+			if i == len(line) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inArg = true
+		case ch == '\\' && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+			inArg = true
+		default:
+			current.WriteByte(ch)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
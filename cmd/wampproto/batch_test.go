@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBatchSeed(t *testing.T) {
+	const lines = "uri realm random\nauth cra generate-challenge --authid alice\n"
+	first := runCommand(t, []string{"--seed", "7", "batch"}, lines)
+	if second := runCommand(t, []string{"--seed", "7", "batch"}, lines); first != second {
+		t.Errorf("expected a seeded batch to be reproducible, got %q and %q", first, second)
+	}
+
+	for _, args := range [][]string{
+		{"--seed", "7", "batch", "--workers", "2"},
+		{"batch", "--workers", "2"},
+	} {
+		input := lines
+		if args[0] == "batch" {
+			input = "--seed 7 uri realm random\n"
+		}
+
+		var stdout bytes.Buffer
+		err := run(context.Background(), args, strings.NewReader(input), &stdout, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error()+stdout.String(), "--seed") {
+			t.Errorf("%v: expected --seed to be rejected, got %v: %s", args, err, stdout.String())
+		}
+	}
+}
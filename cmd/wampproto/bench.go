@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-go/messages"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+const (
+	textOutput     = "text"
+	jsonOutput     = "json"
+	markdownOutput = "markdown"
+)
+
+func registerBench(c *cli, cmd *kingpin.CmdClause) {
+	serializer := cmd.Flag("serializer", "Serializer to benchmark.").
+		Default(wampprotocli.JSONSerializer).Enum(wampprotocli.SerializerNames()...)
+	messageNames := cmd.Flag("message", "Message type to benchmark, may be repeated (default: call for run, "+
+		"every message type for compare).").Enums(wampprotocli.SampleMessageNames()...)
+	iterations := cmd.Flag("iterations", "Number of operations to measure, e.g. 1e6.").Default("100000").Float64()
+	output := cmd.Flag("output", "Output format.").Default(textOutput).Enum(textOutput, markdownOutput, jsonOutput)
+
+	corpus := func(defaults []string) ([]messages.Message, int, error) {
+		count, err := toCount(*iterations)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		names := *messageNames
+		if len(names) == 0 {
+			names = defaults
+		}
+
+		msgs := make([]messages.Message, 0, len(names))
+		for _, name := range names {
+			msg, err := wampprotocli.SampleMessage(name)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			msgs = append(msgs, msg)
+		}
+
+		return msgs, count, nil
+	}
+
+	runCmd := cmd.Command("run", "Benchmark messages with a single serializer.").Default()
+	c.handle(runCmd, func(e *env) error {
+		msgs, count, err := corpus([]string{"call"})
+		if err != nil {
+			return err
+		}
+
+		results := make([]*wampprotocli.BenchmarkResult, 0, len(msgs))
+		for _, msg := range msgs {
+			result, err := wampprotocli.Benchmark(*serializer, msg, count)
+			if err != nil {
+				return err
+			}
+
+			results = append(results, result)
+		}
+
+		return writeBenchmarks(e.stdout, *output, results)
+	})
+
+	compareCmd := cmd.Command("compare", "Benchmark the same messages with every serializer.")
+	c.handle(compareCmd, func(e *env) error {
+		msgs, count, err := corpus(wampprotocli.SampleMessageNames())
+		if err != nil {
+			return err
+		}
+
+		results, err := wampprotocli.CompareSerializers(msgs, count)
+		if err != nil {
+			return err
+		}
+
+		return writeComparison(e.stdout, *output, results)
+	})
+}
+
+// toCount converts a flag value such as 1e6 into an iteration count.
+func toCount(value float64) (int, error) {
+	if value < 1 || value != math.Trunc(value) || value > math.MaxInt32 {
+		return 0, fmt.Errorf("iterations must be a whole number between 1 and %d, was %v", math.MaxInt32, value)
+	}
+
+	return int(value), nil
+}
+
+func writeBenchmarks(w io.Writer, output string, results []*wampprotocli.BenchmarkResult) error {
+	if output == jsonOutput {
+		encoder := json.NewEncoder(w)
+		for _, result := range results {
+			if err := encoder.Encode(result); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		fmt.Fprintf(w, "serializer: %s, message: %s, size: %d bytes, iterations: %d\n\n",
+			result.Serializer, result.Message, result.Size, result.Iterations)
+
+		rows := [][]string{{"operation", "ops/s", "mean", "p50", "p90", "p99", "max", "allocs/op"}}
+		for _, op := range []struct {
+			name  string
+			stats wampprotocli.LatencyStats
+		}{{"serialize", result.Serialize}, {"parse", result.Parse}} {
+			rows = append(rows, []string{op.name, fmt.Sprintf("%.0f", op.stats.OpsPerSecond), op.stats.Mean.String(),
+				op.stats.P50.String(), op.stats.P90.String(), op.stats.P99.String(), op.stats.Max.String(),
+				fmt.Sprintf("%.1f", op.stats.AllocsPerOp)})
+		}
+
+		if err := writeTable(w, output, rows); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeComparison(w io.Writer, output string, results []*wampprotocli.BenchmarkResult) error {
+	if output == jsonOutput {
+		return json.NewEncoder(w).Encode(results)
+	}
+
+	rows := [][]string{{"message", "serializer", "size", "encode ns/op", "decode ns/op", "encode allocs/op",
+		"decode allocs/op"}}
+	for _, result := range results {
+		rows = append(rows, []string{result.Message, result.Serializer, strconv.Itoa(result.Size),
+			strconv.FormatInt(result.Serialize.Mean.Nanoseconds(), 10),
+			strconv.FormatInt(result.Parse.Mean.Nanoseconds(), 10),
+			fmt.Sprintf("%.1f", result.Serialize.AllocsPerOp), fmt.Sprintf("%.1f", result.Parse.AllocsPerOp)})
+	}
+
+	return writeTable(w, output, rows)
+}
+
+// writeTable writes rows, the first of which is the header, as an aligned
+// text table or as a markdown table.
+func writeTable(w io.Writer, output string, rows [][]string) error {
+	if output == markdownOutput {
+		for i, row := range rows {
+			fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+			if i == 0 {
+				fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(row)))
+			}
+		}
+
+		return nil
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(table, strings.Join(row, "\t"))
+	}
+
+	return table.Flush()
+}
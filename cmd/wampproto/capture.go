@@ -0,0 +1,530 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/gorilla/websocket"
+
+	"github.com/xconnio/wampproto-go/messages"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerCapture(c *cli, cmd *kingpin.CmdClause) {
+	decodeCmd := cmd.Command("decode", "Decode the WAMP messages of a pcap capture, reassembling TCP streams "+
+		"and WebSocket or RawSocket frames, and print them as an NDJSON transcript.")
+	pcapFile := decodeCmd.Arg("file", "Capture in pcap format.").Required().String()
+	port := decodeCmd.Flag("port", "TCP port of the router.").Required().Uint16()
+	decodeLimit := decodeCmd.Flag("max-message-size", "Report messages larger than this many bytes as an error "+
+		"record and ignore the rest of their stream instead of holding them in memory (0 is 16 MiB, the largest "+
+		"RawSocket allows).").Default("0").Int()
+	c.handle(decodeCmd, func(e *env) error {
+		f, err := os.Open(*pcapFile)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = f.Close() }()
+
+		encoder := json.NewEncoder(e.stdout)
+		return wampprotocli.DecodePcap(f, *port, *decodeLimit, func(record *wampprotocli.TranscriptRecord) error {
+			// The signature of AUTHENTICATE is the ticket of ticket authentication.
+			if !e.showSecrets && record.Name == messages.MessageNameAuthenticate {
+				if err := wampprotocli.ScrubRecord(record, []string{wampprotocli.RedactSignature}); err != nil {
+					return err
+				}
+			}
+
+			return encoder.Encode(record)
+		})
+	})
+
+	convertCmd := cmd.Command("convert", "Re-serialize every message of a transcript with another serializer.")
+	from := convertCmd.Flag("from", "Only convert messages of this serializer, all by default.").
+		Enum(wampprotocli.SerializerNames()...)
+	to := convertCmd.Flag("to", "Serializer to convert messages to.").Required().
+		Enum(wampprotocli.SerializerNames()...)
+	convertInput := convertCmd.Arg("input", "Transcript to convert.").Required().String()
+	convertOutput := convertCmd.Arg("output", "Transcript to write.").Required().String()
+	convertLimit := addTranscriptLimitFlag(convertCmd)
+	c.handle(convertCmd, func(e *env) error {
+		convert := func(record *wampprotocli.TranscriptRecord) error {
+			if *from != "" && record.Serializer != *from {
+				return nil
+			}
+
+			return wampprotocli.ConvertRecord(record, *to)
+		}
+
+		return rewriteTranscript(*convertInput, *convertOutput, *convertLimit, convert)
+	})
+
+	statsCmd := cmd.Command("stats", "Summarize a transcript: message counts per type, unique procedures and "+
+		"topics, message sizes, error rate and sessions.")
+	statsFile := statsCmd.Arg("file", "Transcript to summarize.").Required().String()
+	statsOutput := statsCmd.Flag("output", "Output format.").Default(textOutput).
+		Enum(textOutput, markdownOutput, jsonOutput)
+	statsLimit := addTranscriptLimitFlag(statsCmd)
+	c.handle(statsCmd, func(e *env) error {
+		summary := wampprotocli.NewTranscriptSummary()
+		err := eachRecord(*statsFile, *statsLimit, func(record *wampprotocli.TranscriptRecord) error {
+			summary.Add(record)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return writeTranscriptStats(e.stdout, *statsOutput, summary.Stats())
+	})
+
+	exportCmd := cmd.Command("export", "Print the messages of a transcript as NDJSON with their fields "+
+		"flattened into named ones, for loading into analysis tools such as Elasticsearch.")
+	exportFile := exportCmd.Arg("file", "Transcript to export.").Required().String()
+	exportLimit := addTranscriptLimitFlag(exportCmd)
+	c.handle(exportCmd, func(e *env) error {
+		encoder := json.NewEncoder(e.stdout)
+		return eachRecord(*exportFile, *exportLimit, func(record *wampprotocli.TranscriptRecord) error {
+			return encoder.Encode(wampprotocli.ExportRecord(record))
+		})
+	})
+
+	scrubCmd := cmd.Command("scrub", "Replace sensitive values of the messages of a transcript with "+
+		"placeholders of the same type, keeping their structure and IDs.")
+	redact := scrubCmd.Flag("redact", fmt.Sprintf("Comma separated fields to redact, of %s.",
+		strings.Join(wampprotocli.RedactFields(), ", "))).Default("args,kwargs,authid,authextra,signature").String()
+	scrubInput := scrubCmd.Arg("input", "Transcript to scrub.").Required().String()
+	scrubOutput := scrubCmd.Arg("output", "Transcript to write.").Required().String()
+	scrubLimit := addTranscriptLimitFlag(scrubCmd)
+	c.handle(scrubCmd, func(e *env) error {
+		fields := strings.Split(*redact, ",")
+		for _, field := range fields {
+			if !slices.Contains(wampprotocli.RedactFields(), field) {
+				return fmt.Errorf("unknown field %q, must be one of %v", field, wampprotocli.RedactFields())
+			}
+		}
+
+		scrub := func(record *wampprotocli.TranscriptRecord) error {
+			return wampprotocli.ScrubRecord(record, fields)
+		}
+
+		return rewriteTranscript(*scrubInput, *scrubOutput, *scrubLimit, scrub)
+	})
+
+	latencyCmd := cmd.Command("latency", "Correlate requests with their responses by request ID and print the "+
+		"router's latency percentiles per request type and URI.")
+	latencyFile := latencyCmd.Arg("file", "Transcript to analyze.").Required().String()
+	latencyOutput := latencyCmd.Flag("output", "Output format.").Default(textOutput).
+		Enum(textOutput, markdownOutput, jsonOutput)
+	latencyLimit := addTranscriptLimitFlag(latencyCmd)
+	c.handle(latencyCmd, func(e *env) error {
+		analysis := wampprotocli.NewLatencyAnalysis()
+		err := eachRecord(*latencyFile, *latencyLimit, func(record *wampprotocli.TranscriptRecord) error {
+			analysis.Add(record)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		latencies := analysis.Latencies()
+		if *latencyOutput == jsonOutput {
+			return json.NewEncoder(e.stdout).Encode(latencies)
+		}
+
+		rows := [][]string{{"request", "uri", "count", "errors", "min", "mean", "p50", "p90", "p99", "max"}}
+		for _, l := range latencies {
+			rows = append(rows, []string{l.Request, l.URI, strconv.Itoa(l.Count), strconv.Itoa(l.Errors),
+				l.Min.String(), l.Mean.String(), l.P50.String(), l.P90.String(), l.P99.String(), l.Max.String()})
+		}
+
+		return writeTable(e.stdout, *latencyOutput, rows)
+	})
+
+	splitCmd := cmd.Command("split", "Split a transcript into one transcript per session, named after the "+
+		"session.")
+	splitFile := splitCmd.Arg("file", "Transcript to split.").Required().String()
+	splitDir := splitCmd.Flag("dir", "Directory to write the transcripts to.").Default(".").String()
+	splitLimit := addTranscriptLimitFlag(splitCmd)
+	c.handle(splitCmd, func(e *env) error {
+		writer := &sessionWriter{dir: *splitDir, files: map[string]*os.File{}, written: map[string]bool{}}
+		err := eachRecord(*splitFile, *splitLimit, writer.write)
+		if err = errors.Join(err, writer.close()); err != nil {
+			return err
+		}
+
+		for _, path := range writer.paths {
+			fmt.Fprintln(e.stdout, path)
+		}
+
+		return nil
+	})
+
+	mergeCmd := cmd.Command("merge", "Merge transcripts into one, ordering their records by time.")
+	mergeOutput := mergeCmd.Arg("output", "Transcript to write.").Required().String()
+	mergeInputs := mergeCmd.Arg("input", "Transcripts to merge, each ordered by time.").Required().Strings()
+	mergeLimit := addTranscriptLimitFlag(mergeCmd)
+	c.handle(mergeCmd, func(e *env) error {
+		readers := make([]*wampprotocli.TranscriptReader, 0, len(*mergeInputs))
+		for _, input := range *mergeInputs {
+			f, err := os.Open(input)
+			if err != nil {
+				return err
+			}
+
+			defer func() { _ = f.Close() }()
+
+			readers = append(readers, wampprotocli.NewTranscriptReader(f, *mergeLimit))
+		}
+
+		out, err := os.Create(*mergeOutput)
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(out)
+		err = wampprotocli.MergeTranscriptReaders(readers, func(record *wampprotocli.TranscriptRecord) error {
+			return encoder.Encode(record)
+		})
+
+		return errors.Join(err, out.Close())
+	})
+
+	reportCmd := cmd.Command("report", "Write an HTML report showing each session of a transcript as a "+
+		"sequence diagram of its handshake, authentication and traffic.")
+	reportFile := reportCmd.Arg("file", "Transcript to report on.").Required().String()
+	reportOut := reportCmd.Flag("out", "HTML file to write, stdout by default.").PlaceHolder("FILE").String()
+	reportLimit := addTranscriptLimitFlag(reportCmd)
+	c.handle(reportCmd, func(e *env) error {
+		// The report shows every record, so unlike the other commands it
+		// holds the whole transcript in memory.
+		records, err := readTranscript(*reportFile, *reportLimit)
+		if err != nil {
+			return err
+		}
+
+		title := "WAMP session report: " + filepath.Base(*reportFile)
+		if *reportOut == "" {
+			return wampprotocli.WriteReport(e.stdout, title, records)
+		}
+
+		f, err := os.Create(*reportOut)
+		if err != nil {
+			return err
+		}
+
+		return errors.Join(wampprotocli.WriteReport(f, title, records), f.Close())
+	})
+
+	replayCmd := cmd.Command("replay", "Resend the client messages of a recorded session to a live router and "+
+		"compare its responses with the recorded ones, printing each comparison as NDJSON.")
+	replayFile := replayCmd.Arg("file", "Transcript to replay.").Required().String()
+	url := replayCmd.Flag("url", "WebSocket URL of the router, e.g. ws://localhost:8080/ws.").Required().String()
+	session := replayCmd.Flag("session", "Session of the transcript to replay, the first one by default.").
+		String()
+	timeout := replayCmd.Flag("timeout", "Time to wait for each response of the router.").Default("5s").
+		Duration()
+	replayLimit := addTranscriptLimitFlag(replayCmd)
+	c.handle(replayCmd, func(e *env) error {
+		records, err := readSession(*replayFile, *session, *replayLimit)
+		if err != nil {
+			return err
+		}
+
+		transport, err := dialWebSocket(e, *url, records[0].Serializer, *timeout)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = transport.conn.Close() }()
+
+		var responses, mismatches int
+		encoder := json.NewEncoder(e.stdout)
+		err = wampprotocli.ReplaySession(transport, records, func(result *wampprotocli.ReplayResult) error {
+			responses++
+			if !result.Match {
+				mismatches++
+			}
+
+			return encoder.Encode(result)
+		})
+		if err != nil {
+			return err
+		} else if mismatches > 0 {
+			return fmt.Errorf("%d of %d router messages differ from the recording", mismatches, responses)
+		}
+
+		return nil
+	})
+}
+
+// addTranscriptLimitFlag adds the flag limiting the size of the messages of
+// the transcript a command reads.
+func addTranscriptLimitFlag(cmd *kingpin.CmdClause) *int {
+	return cmd.Flag("max-message-size", "Fail on messages larger than this many bytes instead of holding them "+
+		"in memory (0 is 16 MiB, the largest RawSocket allows).").Default("0").Int()
+}
+
+// eachRecord calls fn for each record of the transcript at path in order,
+// reading one record at a time.
+func eachRecord(path string, maxMessageSize int, fn func(*wampprotocli.TranscriptRecord) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	return wampprotocli.ReadTranscript(f, maxMessageSize, fn)
+}
+
+// rewriteTranscript writes the records of the transcript at input to output
+// after passing each of them to fn, which may modify it.
+func rewriteTranscript(input, output string, maxMessageSize int,
+	fn func(*wampprotocli.TranscriptRecord) error) error {
+	in, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+	line := 0
+	err = wampprotocli.ReadTranscript(in, maxMessageSize, func(record *wampprotocli.TranscriptRecord) error {
+		line++
+		if err := fn(record); err != nil {
+			return fmt.Errorf("transcript line %d: %w", line, err)
+		}
+
+		return encoder.Encode(record)
+	})
+
+	return errors.Join(err, out.Close())
+}
+
+// readTranscript returns the records of the transcript at path.
+func readTranscript(path string, maxMessageSize int) ([]*wampprotocli.TranscriptRecord, error) {
+	var records []*wampprotocli.TranscriptRecord
+	err := eachRecord(path, maxMessageSize, func(record *wampprotocli.TranscriptRecord) error {
+		records = append(records, record)
+		return nil
+	})
+
+	return records, err
+}
+
+// maxOpenSessionFiles bounds the transcripts a sessionWriter keeps open.
+const maxOpenSessionFiles = 64
+
+// sessionWriter writes records to one transcript per session in dir, named
+// by sessionFileName. The transcripts of the sessions written to least
+// recently are closed and reopened once they are written to again, so that
+// captures of many sessions don't run out of file descriptors.
+type sessionWriter struct {
+	dir   string
+	files map[string]*os.File
+	// open lists the sessions of files by when they were last written to.
+	open []string
+	// paths lists the transcripts in the order their sessions first appeared.
+	paths   []string
+	written map[string]bool
+}
+
+func (w *sessionWriter) write(record *wampprotocli.TranscriptRecord) error {
+	f, ok := w.files[record.Session]
+	if !ok {
+		path := filepath.Join(w.dir, sessionFileName(record.Session))
+		flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		if !w.written[record.Session] {
+			flags |= os.O_TRUNC
+		}
+
+		var err error
+		if f, err = os.OpenFile(path, flags, 0o600); err != nil {
+			return err
+		}
+
+		if !w.written[record.Session] {
+			w.written[record.Session] = true
+			w.paths = append(w.paths, path)
+		}
+
+		w.files[record.Session] = f
+	} else {
+		w.open = slices.DeleteFunc(w.open, func(session string) bool { return session == record.Session })
+	}
+
+	w.open = append(w.open, record.Session)
+	if len(w.open) > maxOpenSessionFiles {
+		oldest := w.open[0]
+		w.open = w.open[1:]
+		if err := w.files[oldest].Close(); err != nil {
+			return err
+		}
+
+		delete(w.files, oldest)
+	}
+
+	return json.NewEncoder(f).Encode(record)
+}
+
+// close closes the transcripts that are still open.
+func (w *sessionWriter) close() error {
+	var errs []error
+	for _, f := range w.files {
+		errs = append(errs, f.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// sessionFileName returns the name of the transcript of session, replacing
+// characters that are unsafe in file names.
+func sessionFileName(session string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+
+		return '_'
+	}, session) + ".wampt"
+}
+
+// readSession returns the records of session in the transcript at path, or
+// of its first session if session is empty. Only the records of session are
+// held in memory.
+func readSession(path, session string, maxMessageSize int) ([]*wampprotocli.TranscriptRecord, error) {
+	var records []*wampprotocli.TranscriptRecord
+	err := eachRecord(path, maxMessageSize, func(record *wampprotocli.TranscriptRecord) error {
+		if session == "" {
+			session = record.Session
+		}
+
+		if record.Session == session {
+			records = append(records, record)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s has no records of session %q", path, session)
+	}
+
+	return records, nil
+}
+
+// writeTranscriptStats writes stats as JSON or as tables of the message
+// counts, the summary and the sessions.
+func writeTranscriptStats(w io.Writer, output string, stats *wampprotocli.TranscriptStats) error {
+	if output == jsonOutput {
+		return json.NewEncoder(w).Encode(stats)
+	}
+
+	names := make([]string, 0, len(stats.Messages))
+	for name := range stats.Messages {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	rows := [][]string{{"message", "count"}}
+	for _, name := range names {
+		rows = append(rows, []string{name, strconv.Itoa(stats.Messages[name])})
+	}
+
+	sizes := stats.Sizes
+	summary := [][]string{
+		{"metric", "value"},
+		{"records", strconv.Itoa(stats.Records)},
+		{"undecodable", strconv.Itoa(stats.Undecodable)},
+		{"sessions", strconv.Itoa(len(stats.Sessions))},
+		{"procedures", strconv.Itoa(len(stats.Procedures))},
+		{"topics", strconv.Itoa(len(stats.Topics))},
+		{"requests", strconv.Itoa(stats.Requests)},
+		{"errors", strconv.Itoa(stats.Errors)},
+		{"error rate", fmt.Sprintf("%.2f%%", 100*stats.ErrorRate)},
+		{"size min/mean/p50/p90/p99/max", fmt.Sprintf("%d/%d/%d/%d/%d/%d bytes", sizes.Min, sizes.Mean, sizes.P50,
+			sizes.P90, sizes.P99, sizes.Max)},
+	}
+
+	sessions := [][]string{{"session", "messages", "start", "duration"}}
+	for _, session := range stats.Sessions {
+		sessions = append(sessions, []string{session.Session, strconv.Itoa(session.Messages),
+			session.Start.Format(time.RFC3339Nano), session.Duration.String()})
+	}
+
+	for i, table := range [][][]string{rows, summary, sessions} {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		if err := writeTable(w, output, table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// webSocketTransport is a WAMP WebSocket connection to a router.
+type webSocketTransport struct {
+	conn        *websocket.Conn
+	messageType int
+	timeout     time.Duration
+}
+
+// dialWebSocket connects to the router at url, negotiating serializer.
+func dialWebSocket(e *env, url, serializer string, timeout time.Duration) (*webSocketTransport, error) {
+	subprotocol := "wamp.2." + serializer
+	dialer := websocket.Dialer{Subprotocols: []string{subprotocol}, HandshakeTimeout: timeout}
+	conn, response, err := dialer.DialContext(e.ctx, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", url, err)
+	}
+
+	_ = response.Body.Close()
+	if conn.Subprotocol() != subprotocol {
+		_ = conn.Close()
+		return nil, fmt.Errorf("router at %s doesn't support %s", url, subprotocol)
+	}
+
+	messageType := websocket.BinaryMessage
+	if serializer == wampprotocli.JSONSerializer {
+		messageType = websocket.TextMessage
+	}
+
+	return &webSocketTransport{conn: conn, messageType: messageType, timeout: timeout}, nil
+}
+
+func (t *webSocketTransport) Send(data []byte) error {
+	return t.conn.WriteMessage(t.messageType, data)
+}
+
+func (t *webSocketTransport) Receive() ([]byte, error) {
+	if err := t.conn.SetReadDeadline(time.Now().Add(t.timeout)); err != nil {
+		return nil, err
+	}
+
+	_, data, err := t.conn.ReadMessage()
+	return data, err
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardSource is the argument, or flag value, that is replaced with the
+// contents of the clipboard.
+const clipboardSource = "clip:"
+
+// clipboardCommands returns the commands that write to and read from the
+// system clipboard, in order of preference.
+func clipboardCommands() (copyCommands, pasteCommands [][]string) {
+	switch runtime.GOOS {
+	case "darwin":
+		return [][]string{{"pbcopy"}}, [][]string{{"pbpaste"}}
+	case "windows":
+		return [][]string{{"clip.exe"}}, [][]string{{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard"}}
+	}
+
+	copyCommands = [][]string{{"xclip", "-selection", "clipboard"}, {"xsel", "--clipboard", "--input"}}
+	pasteCommands = [][]string{{"xclip", "-selection", "clipboard", "-o"}, {"xsel", "--clipboard", "--output"}}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		copyCommands = append([][]string{{"wl-copy"}}, copyCommands...)
+		pasteCommands = append([][]string{{"wl-paste", "--no-newline"}}, pasteCommands...)
+	}
+
+	return copyCommands, pasteCommands
+}
+
+// clipboardCommand returns the first of commands that is installed.
+func clipboardCommand(commands [][]string) ([]string, error) {
+	for _, command := range commands {
+		if _, err := exec.LookPath(command[0]); err == nil {
+			return command, nil
+		}
+	}
+
+	var names []string
+	for _, command := range commands {
+		names = append(names, command[0])
+	}
+
+	return nil, fmt.Errorf("no clipboard tool found, install one of %s", strings.Join(names, ", "))
+}
+
+func writeClipboard(ctx context.Context, text string) error {
+	copyCommands, _ := clipboardCommands()
+	command, err := clipboardCommand(copyCommands)
+	if err != nil {
+		return err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return fmt.Errorf("failed to copy to the clipboard with %s: %w %s", command[0], err,
+			strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+func readClipboard(ctx context.Context) (string, error) {
+	_, pasteCommands := clipboardCommands()
+	command, err := clipboardCommand(pasteCommands)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to paste from the clipboard with %s: %w %s", command[0], err,
+			strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// pasteArgs replaces every argument that is clip:, or a flag whose value is
+// clip:, with the contents of the clipboard, stripped of surrounding
+// whitespace. The clipboard is only read if an argument refers to it.
+func pasteArgs(ctx context.Context, args []string) ([]string, error) {
+	var contents *string
+	pasted := make([]string, 0, len(args))
+	for _, arg := range args {
+		prefix, ok := strings.CutSuffix(arg, clipboardSource)
+		if !ok || (prefix != "" && !(strings.HasPrefix(prefix, "--") && strings.HasSuffix(prefix, "="))) {
+			pasted = append(pasted, arg)
+			continue
+		}
+
+		if contents == nil {
+			text, err := readClipboard(ctx)
+			if err != nil {
+				return nil, err
+			} else if text = strings.TrimSpace(text); text == "" {
+				return nil, errors.New("the clipboard is empty")
+			}
+
+			contents = &text
+		}
+
+		pasted = append(pasted, prefix+*contents)
+	}
+
+	return pasted, nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+// shellBuiltins returns the commands the shell executes itself.
+func shellBuiltins() []string {
+	return []string{"exit", "quit", "set", "unset", "show", "history", "replay"}
+}
+
+// complete is the tab completion of the terminal. It completes the word
+// before the cursor to the longest prefix shared by its candidates, adding a
+// space once the word is unambiguous.
+func (s *shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	prefix := line[:pos]
+	start := strings.LastIndexAny(prefix, " \t") + 1
+	word := prefix[start:]
+
+	var matching []string
+	for _, candidate := range s.completions(strings.Fields(prefix[:start]), word) {
+		if strings.HasPrefix(candidate, word) && !slices.Contains(matching, candidate) {
+			matching = append(matching, candidate)
+		}
+	}
+
+	if len(matching) == 0 {
+		return "", 0, false
+	}
+
+	completed := matching[0]
+	for _, candidate := range matching[1:] {
+		for !strings.HasPrefix(candidate, completed) {
+			completed = completed[:len(completed)-1]
+		}
+	}
+
+	if len(matching) == 1 {
+		completed += " "
+	} else if completed == word {
+		return "", 0, false
+	}
+
+	return prefix[:start] + completed + line[pos:], start + len(completed), true
+}
+
+// completions returns the candidates for word following words: builtins and
+// commands, flags, the values of enum flags and the URIs and realms used
+// before.
+func (s *shell) completions(words []string, word string) []string {
+	if len(words) == 0 {
+		candidates := shellBuiltins()
+		for _, command := range topLevelCommands() {
+			candidates = append(candidates, command.name)
+		}
+
+		return candidates
+	}
+
+	switch words[0] {
+	case "set", "unset":
+		if len(words) == 1 {
+			return s.allFlagNames()
+		} else if words[0] == "set" && len(words) == 2 {
+			return s.flagValues(nil, words[1])
+		}
+
+		return nil
+	case "exit", "quit", "show", "history", "replay":
+		return nil
+	}
+
+	if name, _, ok := strings.Cut(word, "="); ok && strings.HasPrefix(name, "--") {
+		var candidates []string
+		for _, value := range s.flagValues(words, strings.TrimPrefix(name, "--")) {
+			candidates = append(candidates, name+"="+value)
+		}
+
+		return candidates
+	}
+
+	flags := s.commandFlags(words)
+	if strings.HasPrefix(word, "-") {
+		var candidates []string
+		for _, flag := range flags {
+			if !flag.Hidden {
+				candidates = append(candidates, "--"+flag.Name)
+			}
+		}
+
+		return candidates
+	}
+
+	if previous := words[len(words)-1]; strings.HasPrefix(previous, "--") {
+		name := strings.TrimPrefix(previous, "--")
+		index := slices.IndexFunc(flags, func(flag *kingpin.FlagModel) bool { return flag.Name == name })
+		if index >= 0 && !flags[index].IsBoolFlag() {
+			return s.flagValues(words, name)
+		}
+	}
+
+	commands := s.model().Commands
+	for _, word := range words {
+		index := slices.IndexFunc(commands, func(command *kingpin.CmdModel) bool { return command.Name == word })
+		if index < 0 {
+			break
+		}
+
+		commands = commands[index].Commands
+	}
+
+	if len(commands) > 0 {
+		candidates := make([]string, 0, len(commands))
+		for _, command := range commands {
+			candidates = append(candidates, command.Name)
+		}
+
+		return candidates
+	}
+
+	uris, _ := s.usedURIs()
+	return uris
+}
+
+// flagValues returns the values of the flag name of the command words invoke
+// or, if words is nil, of any command. Only enum flags have values, apart
+// from --realm, whose values are the realms used before.
+func (s *shell) flagValues(words []string, name string) []string {
+	c := newCLI(io.Discard, io.Discard, func(int) {})
+	for _, command := range topLevelCommands() {
+		command.register(c, c.app.Command(command.name, command.help))
+	}
+
+	var clauses []*kingpin.CmdClause
+	if words == nil {
+		var walk func(commands []*kingpin.CmdModel, parent *kingpin.CmdClause)
+		walk = func(commands []*kingpin.CmdModel, parent *kingpin.CmdClause) {
+			for _, command := range commands {
+				clause := c.app.GetCommand(command.Name)
+				if parent != nil {
+					clause = parent.GetCommand(command.Name)
+				}
+
+				clauses = append(clauses, clause)
+				walk(command.Commands, clause)
+			}
+		}
+
+		walk(c.app.Model().Commands, nil)
+	} else {
+		for _, word := range words {
+			clause := c.app.GetCommand(word)
+			if len(clauses) > 0 {
+				clause = clauses[len(clauses)-1].GetCommand(word)
+			}
+
+			if clause == nil {
+				break
+			}
+
+			clauses = append(clauses, clause)
+		}
+
+		slices.Reverse(clauses)
+	}
+
+	var values []string
+	if name == "realm" {
+		_, values = s.usedURIs()
+	}
+
+	for _, clause := range clauses {
+		if options, matched, _ := clause.FlagCompletion(name, ""); matched {
+			return append(values, options...)
+		}
+	}
+
+	if options, matched, _ := c.app.FlagCompletion(name, ""); matched {
+		return append(values, options...)
+	}
+
+	return values
+}
+
+// allFlagNames returns the names of the flags of all commands.
+func (s *shell) allFlagNames() []string {
+	var names []string
+	var walk func(flags []*kingpin.FlagModel, commands []*kingpin.CmdModel)
+	walk = func(flags []*kingpin.FlagModel, commands []*kingpin.CmdModel) {
+		for _, flag := range flags {
+			if !flag.Hidden && !slices.Contains(names, flag.Name) {
+				names = append(names, flag.Name)
+			}
+		}
+
+		for _, command := range commands {
+			walk(command.Flags, command.Commands)
+		}
+	}
+
+	model := s.model()
+	walk(model.Flags, model.Commands)
+	slices.Sort(names)
+	return names
+}
+
+// usedURIs returns the URIs and the realms found in the history. Arguments
+// are taken as URIs if they contain a dot and are valid URIs, and as realms
+// if they are the value of --realm.
+func (s *shell) usedURIs() ([]string, []string) {
+	var uris, realms []string
+	for _, line := range s.history {
+		args, err := splitArgs(line)
+		if err != nil {
+			continue
+		}
+
+		for i, arg := range args {
+			switch {
+			case i > 0 && args[i-1] == "--realm":
+				realms = append(realms, arg)
+			case strings.HasPrefix(arg, "--realm="):
+				realms = append(realms, strings.TrimPrefix(arg, "--realm="))
+			case !strings.HasPrefix(arg, "-") && strings.Contains(arg, ".") &&
+				wampprotocli.ValidateURI(arg, false, false) == nil:
+				uris = append(uris, arg)
+			}
+		}
+	}
+
+	return uris, realms
+}
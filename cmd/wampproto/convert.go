@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerConvertMessage(c *cli, cmd *kingpin.CmdClause) {
+	convertCmd := cmd.Command("convert", "Re-serialize a message serialized with --serializer with another "+
+		"serializer, keeping all of its fields.")
+	data := convertCmd.Arg("data", "Hex or base64 encoded serialized message.").Required().String()
+	to := convertCmd.Flag("to", "Serializer to re-serialize the message with.").Required().
+		Enum(wampprotocli.SerializerNames()...)
+	flags := addReadFlags(convertCmd)
+	encoding := addEncodingFlag(convertCmd)
+	c.handle(convertCmd, func(e *env) error {
+		input, err := wampprotocli.DecodeBytes(*data)
+		if err != nil {
+			return err
+		}
+
+		if err = wampprotocli.CheckMessageSize(len(input), *flags.maxMessageSize); err != nil {
+			return err
+		}
+
+		converted, warnings, err := wampprotocli.ConvertMessage(*flags.serializer, *to, input, *flags.nonFinite,
+			*flags.bigInt)
+		if err != nil {
+			return err
+		}
+
+		e.warnings.add(warnings...)
+
+		encoded, err := wampprotocli.EncodeBytes(converted, *encoding)
+		if err != nil {
+			return err
+		}
+
+		if !*flags.noValidate {
+			// The policies only apply to JSON, whose converted message they
+			// were already applied to.
+			request := flags.request(e, encoded, *to == wampprotocli.JSONSerializer)
+			request.Serializer = *to
+			response, err := wampprotocli.Parse(request)
+			if err != nil {
+				return fmt.Errorf("converted message is invalid: %w", err)
+			}
+
+			e.warnings.add(response.Warnings...)
+		}
+
+		fmt.Fprintln(e.stdout, encoded)
+		return nil
+	})
+}
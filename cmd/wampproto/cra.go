@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerAuthCRA(c *cli, cmd *kingpin.CmdClause) {
+	craCmd := cmd.Command("cra", "Challenges and signatures of wampcra, the challenge-response "+
+		"authentication with a shared secret.")
+
+	generateCmd := craCmd.Command("generate-challenge", "Print the JSON challenge string a router sends "+
+		"with a wampcra CHALLENGE, e.g. for message challenge wampcra --challenge.")
+	authID := generateCmd.Flag("authid", "Authid of the authenticating client.").Required().String()
+	authRole := generateCmd.Flag("authrole", "Authrole the client is authenticated as.").
+		Default("anonymous").String()
+	authProvider := generateCmd.Flag("authprovider", "Provider of the principal.").Default("static").String()
+	session := generateCmd.Flag("session", "Session ID, a random one by default.").PlaceHolder("ID").Int64()
+	nonce := generateCmd.Flag("nonce", "Nonce, 16 random bytes base64 encoded by default.").String()
+	timestamp := generateCmd.Flag("timestamp", "Timestamp, the current UTC time in ISO 8601 by default, or "+
+		"the Unix epoch with --seed.").String()
+	c.handle(generateCmd, func(e *env) error {
+		challenge, err := wampprotocli.NewCRAChallenge(*authID, *authRole, *authProvider, *session, *nonce,
+			*timestamp)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(e.stdout, challenge)
+		return err
+	})
+
+	signCmd := craCmd.Command("sign-challenge", "Sign a wampcra challenge with HMAC-SHA256 and print the "+
+		"base64 encoded signature a client sends in AUTHENTICATE.")
+	signChallenge := signCmd.Arg("challenge", "JSON challenge string of the CHALLENGE.").Required().String()
+	signKey := addCRAKeyFlags(signCmd)
+	c.handle(signCmd, func(e *env) error {
+		key, err := signKey.key()
+		if err != nil {
+			return err
+		}
+
+		defer clear(key)
+		_, err = fmt.Fprintln(e.stdout, wampprotocli.SignCRAChallenge(*signChallenge, key))
+		return err
+	})
+
+	verifyCmd := craCmd.Command("verify-signature", "Verify the signature of a wampcra challenge, failing "+
+		"unless it was signed with the secret.")
+	verifyChallenge := verifyCmd.Arg("challenge", "JSON challenge string of the CHALLENGE.").Required().String()
+	signature := verifyCmd.Arg("signature", "Base64 encoded signature of the AUTHENTICATE.").Required().String()
+	verifyKey := addCRAKeyFlags(verifyCmd)
+	c.handle(verifyCmd, func(_ *env) error {
+		key, err := verifyKey.key()
+		if err != nil {
+			return err
+		}
+
+		defer clear(key)
+		valid, err := wampprotocli.VerifyCRASignature(*signature, *verifyChallenge, key)
+		if err != nil {
+			return err
+		} else if !valid {
+			return errors.New("signature does not match")
+		}
+
+		return nil
+	})
+
+	deriveCmd := craCmd.Command("derive-key", "Derive the key of a salted wampcra secret with PBKDF2-SHA256, "+
+		"as the client does when the CHALLENGE carries a salt.")
+	deriveSecret := deriveCmd.Flag("secret", "Secret shared by the client and the router.").Required().String()
+	deriveSalt := deriveCmd.Flag("salt", "Salt from the CHALLENGE.").Required().String()
+	iterations := deriveCmd.Flag("iterations", "PBKDF2 iterations.").
+		Default(strconv.Itoa(wampprotocli.DefaultCRAIterations)).Int()
+	keyLen := deriveCmd.Flag("keylen", "Length of the key in bytes.").
+		Default(strconv.Itoa(wampprotocli.DefaultCRAKeyLen)).Int()
+	encoding := deriveCmd.Flag("output", "Encoding of the key. Signatures are made with the base64 encoded "+
+		"key.").Short('o').Default(wampprotocli.Base64Output).Enum(wampprotocli.HexOutput,
+		wampprotocli.Base64Output)
+	c.handle(deriveCmd, func(e *env) error {
+		key, err := wampprotocli.DeriveCRAKey(*deriveSecret, *deriveSalt, *iterations, *keyLen)
+		if err != nil {
+			return err
+		}
+
+		defer clear(key)
+		if *encoding == wampprotocli.Base64Output {
+			_, err = fmt.Fprintln(e.stdout, string(key))
+			return err
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(string(key))
+		if err != nil {
+			return err
+		}
+
+		defer clear(raw)
+		encoded, err := wampprotocli.EncodeBytes(raw, *encoding)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(e.stdout, encoded)
+		return err
+	})
+}
+
+// craKeyFlags select the key wampcra signs challenges with.
+type craKeyFlags struct {
+	secret     *string
+	derivedKey *string
+	salt       *string
+	iterations *int
+	keyLen     *int
+}
+
+func addCRAKeyFlags(cmd *kingpin.CmdClause) *craKeyFlags {
+	return &craKeyFlags{
+		secret: cmd.Flag("secret", "Secret shared by the client and the router.").String(),
+		derivedKey: cmd.Flag("derived-key", "Key derived from a salted secret, base64 encoded as printed "+
+			"by derive-key, instead of --secret.").String(),
+		salt: cmd.Flag("salt", "Salt from the CHALLENGE, to sign with the PBKDF2 key derived from the "+
+			"secret instead of the secret itself.").String(),
+		iterations: cmd.Flag("iterations", "PBKDF2 iterations of the CHALLENGE, used with --salt.").
+			Default(strconv.Itoa(wampprotocli.DefaultCRAIterations)).Int(),
+		keyLen: cmd.Flag("keylen", "Length of the PBKDF2 key in bytes of the CHALLENGE, used with --salt.").
+			Default(strconv.Itoa(wampprotocli.DefaultCRAKeyLen)).Int(),
+	}
+}
+
+func (f *craKeyFlags) key() ([]byte, error) {
+	if (*f.secret == "") == (*f.derivedKey == "") {
+		return nil, errors.New("either --secret or --derived-key is required")
+	}
+
+	if *f.derivedKey != "" {
+		if *f.salt != "" {
+			return nil, errors.New("--salt can't be combined with --derived-key")
+		}
+
+		return []byte(*f.derivedKey), nil
+	}
+
+	return wampprotocli.CRAKey(*f.secret, *f.salt, *f.iterations, *f.keyLen)
+}
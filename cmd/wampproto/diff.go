@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerDiffMessage(c *cli, cmd *kingpin.CmdClause) {
+	diffCmd := cmd.Command("diff", "Compare two serialized messages by content, ignoring map key order and "+
+		"number encodings, and print the fields that differ.")
+	first := diffCmd.Arg("first", "Hex or base64 encoded message serialized with --serializer.").Required().String()
+	second := diffCmd.Arg("second", "Hex or base64 encoded message serialized with --second-serializer.").
+		Required().String()
+	serializer := addSerializerFlag(diffCmd, "Serializer of the first message.")
+	secondSerializer := diffCmd.Flag("second-serializer", "Serializer of the second message (default: "+
+		"--serializer).").Enum(wampprotocli.SerializerNames()...)
+	c.handle(diffCmd, func(e *env) error {
+		if *secondSerializer == "" {
+			*secondSerializer = *serializer
+		}
+
+		diff, err := wampprotocli.DiffSerialized(*serializer, *first, *secondSerializer, *second)
+		if err != nil {
+			return err
+		} else if len(diff) == 0 {
+			return nil
+		}
+
+		for _, line := range diff {
+			fmt.Fprintln(e.stdout, line)
+		}
+
+		return errors.New("messages differ")
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerDigestMessage(c *cli, cmd *kingpin.CmdClause) {
+	digestCmd := cmd.Command("digest", "Print a checksum or integrity tag of a serialized message, e.g. to "+
+		"detect corrupted or tampered test vectors.")
+	data := digestCmd.Arg("data", "Hex or base64 encoded serialized message.").Required().String()
+	algorithm := digestCmd.Flag("algo", "Digest algorithm.").Default(wampprotocli.SHA256Digest).
+		Enum(wampprotocli.DigestAlgorithms()...)
+	key := digestCmd.Flag("key", "Key of the integrity tag, required by hmac-sha256.").String()
+	verify := digestCmd.Flag("verify", "Hex or base64 encoded digest the message must have, failing "+
+		"otherwise.").PlaceHolder("DIGEST").String()
+	encoding := addEncodingFlag(digestCmd)
+	c.handle(digestCmd, func(e *env) error {
+		message, err := wampprotocli.DecodeBytes(*data)
+		if err != nil {
+			return err
+		}
+
+		var digest []byte
+		if *verify == "" {
+			digest, err = wampprotocli.Digest(message, *algorithm, []byte(*key))
+		} else {
+			expected, decodeErr := wampprotocli.DecodeBytes(*verify)
+			if decodeErr != nil {
+				return fmt.Errorf("invalid --verify: %w", decodeErr)
+			}
+
+			digest, err = wampprotocli.VerifyDigest(message, *algorithm, []byte(*key), expected)
+		}
+
+		if digest != nil {
+			encoded, encodeErr := wampprotocli.EncodeBytes(digest, *encoding)
+			if encodeErr != nil {
+				return encodeErr
+			}
+
+			fmt.Fprintln(e.stdout, encoded)
+		}
+
+		return err
+	})
+}
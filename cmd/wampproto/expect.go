@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+// expectIDFlags are the flags of message expect for IDs, by the key of the
+// field they check.
+//
+//nolint:gochecknoglobals
+var expectIDFlags = []string{"request_id", "session_id", "subscription_id", "registration_id", "publication_id"}
+
+// expectURIFlags are the flags of message expect for URIs and names.
+//
+//nolint:gochecknoglobals
+var expectURIFlags = []string{"procedure", "topic", "error", "reason", "realm"}
+
+func registerExpectMessage(c *cli, cmd *kingpin.CmdClause) {
+	expectCmd := cmd.Command("expect", "Parse a message serialized with --serializer and fail unless its "+
+		"fields have the expected values, e.g. as an assertion step of a test suite.")
+	data := expectCmd.Arg("data", "Hex or base64 encoded serialized message.").Required().String()
+	flags := addReadFlags(expectCmd)
+	messageType := expectCmd.Flag("type", "Expected message type, by name or number, e.g. CALL or 48.").String()
+
+	ids := make(map[string]*string, len(expectIDFlags))
+	for _, key := range expectIDFlags {
+		ids[key] = expectCmd.Flag(strings.ReplaceAll(key, "_", "-"), fmt.Sprintf("Expected %s ID.",
+			strings.ReplaceAll(strings.TrimSuffix(key, "_id"), "_", " "))).PlaceHolder("ID").String()
+	}
+
+	uris := make(map[string]*string, len(expectURIFlags))
+	for _, key := range expectURIFlags {
+		uris[key] = expectCmd.Flag(key, fmt.Sprintf("Expected %s.", key)).String()
+	}
+
+	arguments := addArgumentFlags(expectCmd)
+	options := expectCmd.Flag("option", "Expected option or detail as KEY=VALUE, may be repeated. Values are "+
+		"decoded like --arg and other options are ignored.").PlaceHolder("KEY=VALUE").StringMap()
+	fields := expectCmd.Flag("field", "Expected field as KEY=VALUE, keyed as by message parse --output json, "+
+		"may be repeated. Values are decoded like --arg.").PlaceHolder("KEY=VALUE").StringMap()
+	c.handle(expectCmd, func(e *env) error {
+		// The expected values are decoded from JSON, so the message is
+		// compared as JSON would represent it.
+		response, err := wampprotocli.Parse(flags.request(e, *data, true))
+		if err != nil {
+			return err
+		}
+
+		e.warnings.add(response.Warnings...)
+
+		var mismatches []string
+		if *messageType != "" && !matchesMessageType(*messageType, response.Type) {
+			mismatches = append(mismatches, fmt.Sprintf("type: expected %s, got %s (%d)", *messageType,
+				response.Name, response.Type))
+		}
+
+		expected := map[string]any{}
+		for key, value := range ids {
+			if *value == "" {
+				continue
+			}
+
+			id, err := strconv.ParseInt(*value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid --%s %q: %w", strings.ReplaceAll(key, "_", "-"), *value, err)
+			}
+
+			expected[key] = id
+		}
+
+		for key, value := range uris {
+			if *value != "" {
+				expected[key] = *value
+			}
+		}
+
+		payload := arguments.payload()
+		if len(payload.Args) > 0 {
+			expected["arguments"] = payload.Args
+		}
+
+		if len(payload.Kwargs) > 0 {
+			expected["arguments_kw"] = payload.Kwargs
+		}
+
+		for key, value := range *options {
+			expected["options."+key] = parseValue(value)
+		}
+
+		for key, value := range *fields {
+			expected[key] = parseValue(value)
+		}
+
+		mismatches = append(mismatches, wampprotocli.ExpectFields(response.Message, expected)...)
+		if len(mismatches) == 0 {
+			return nil
+		}
+
+		for _, line := range mismatches {
+			fmt.Fprintln(e.stderr, line)
+		}
+
+		return errors.New("message doesn't have the expected fields")
+	})
+}
+
+// matchesMessageType reports whether expected, a message name or number,
+// names the message type.
+func matchesMessageType(expected string, messageType int) bool {
+	if number, err := strconv.Atoi(expected); err == nil {
+		return number == messageType
+	}
+
+	return strings.EqualFold(expected, wampprotocli.MessageName(messageType))
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerExportCodeMessage(c *cli, cmd *kingpin.CmdClause) {
+	exportCmd := cmd.Command("export-code", "Print the client code producing a message: wampproto-go "+
+		"constructors, or the session calls of Autobahn|Python or Autobahn|JS. The message is given as "+
+		"to message size.")
+	message := exportCmd.Arg("message", "Message as a JSON array or message command.").Required().Strings()
+	serializer := addSerializerFlag(exportCmd, "Serializer the exported code encodes messages with.")
+	noValidate := exportCmd.Flag("no-validate", "Skip spec validation, e.g. to export invalid messages of "+
+		"negative tests.").Bool()
+	language := exportCmd.Flag("lang", "Language to export the message in.").Default(wampprotocli.GoLanguage).
+		Enum(wampprotocli.CodeLanguages()...)
+	c.handle(exportCmd, func(e *env) error {
+		raw, err := resolveMessage(e, *noValidate, *message)
+		if err != nil {
+			return err
+		}
+
+		code, err := wampprotocli.ExportCode(raw, *language, *serializer)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprint(e.stdout, code)
+		return nil
+	})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerIDGen(c *cli, cmd *kingpin.CmdClause) {
+	count := cmd.Flag("count", "Number of IDs to generate.").Short('n').Default("1").Int()
+
+	// nextIDs draws the --count IDs of generator.
+	nextIDs := func(generator wampprotocli.IDGenerator) ([]int64, error) {
+		if *count < 1 {
+			return nil, fmt.Errorf("count must be at least 1, was %d", *count)
+		}
+
+		ids := make([]int64, 0, *count)
+		for i := 0; i < *count; i++ {
+			id, err := generator.NextID()
+			if err != nil {
+				return nil, err
+			}
+
+			ids = append(ids, id)
+		}
+
+		return ids, nil
+	}
+
+	generate := func(e *env, generator wampprotocli.IDGenerator) error {
+		ids, err := nextIDs(generator)
+		if err != nil {
+			return err
+		}
+
+		writeIDs(e, ids)
+		return nil
+	}
+
+	globalCmd := cmd.Command("global", "Generate global scope IDs, e.g. session and publication IDs, "+
+		"drawn randomly from [1, 2^53].")
+	c.handle(globalCmd, func(e *env) error {
+		return generate(e, wampprotocli.RandomIDGenerator{})
+	})
+
+	routerCmd := cmd.Command("router", "Generate router scope IDs, e.g. subscription and registration IDs. "+
+		"The spec leaves their choice to the router; they are sequential unless --random is given.")
+	random := routerCmd.Flag("random", "Draw IDs randomly from [1, 2^53] instead.").Bool()
+	c.handle(routerCmd, func(e *env) error {
+		if *random {
+			return generate(e, wampprotocli.RandomIDGenerator{})
+		}
+
+		return generate(e, wampprotocli.NewSequentialIDGenerator(0))
+	})
+
+	sessionCmd := cmd.Command("session", "Generate session scope IDs, i.e. request IDs, counting up from 1.")
+	stateFile := sessionCmd.Flag("state-file", "Continue the sequence stored in this file and store where it "+
+		"ended, so that separate invocations share one session.").PlaceHolder("FILE").String()
+	c.handle(sessionCmd, func(e *env) error {
+		if *stateFile == "" {
+			return generate(e, wampprotocli.NewSequentialIDGenerator(0))
+		}
+
+		unlock, err := lockIDState(e.ctx, *stateFile)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		last, err := readIDState(*stateFile)
+		if err != nil {
+			return err
+		}
+
+		// The state is stored while still locked and before the IDs are
+		// printed, as IDs printed but not stored would be handed out again.
+		generator := wampprotocli.NewSequentialIDGenerator(last)
+		ids, err := nextIDs(generator)
+		if err != nil {
+			return err
+		}
+
+		if err = writeIDState(*stateFile, generator.Last()); err != nil {
+			return err
+		}
+
+		writeIDs(e, ids)
+		return nil
+	})
+}
+
+// writeIDs prints ids, one per line.
+func writeIDs(e *env, ids []int64) {
+	for _, id := range ids {
+		fmt.Fprintln(e.stdout, id)
+	}
+}
+
+// idStateLockTimeout bounds the wait for another invocation to release the
+// ID state.
+const idStateLockTimeout = 10 * time.Second
+
+// lockIDState waits until no other invocation updates the ID state in path
+// and returns the function releasing it. The lock is a file next to path that
+// exists while the state is updated, which works on every platform.
+func lockIDState(ctx context.Context, path string) (func(), error) {
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(idStateLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		} else if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		} else if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another invocation, remove %s if none is running", path,
+				lockPath)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// readIDState returns the last ID stored in path, or 0 if it doesn't exist yet.
+func readIDState(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	last, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || last < 0 || last > wampprotocli.MaxID {
+		return 0, fmt.Errorf("invalid ID state in %s: %q", path, strings.TrimSpace(string(data)))
+	}
+
+	return last, nil
+}
+
+// writeIDState stores last in path, replacing the file atomically so that
+// an interrupted invocation never leaves a truncated state behind.
+func writeIDState(path string, last int64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = fmt.Fprintln(tmp, last); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
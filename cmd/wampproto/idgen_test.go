@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestIDStateConcurrent runs invocations sharing an ID state file
+// concurrently, which must never hand out an ID twice.
+func TestIDStateConcurrent(t *testing.T) {
+	const invocations, count = 8, 5
+	path := filepath.Join(t.TempDir(), "ids")
+
+	var wg sync.WaitGroup
+	outputs := make([]bytes.Buffer, invocations)
+	errs := make([]error, invocations)
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			args := []string{"idgen", "--count", strconv.Itoa(count), "session", "--state-file", path}
+			errs[i] = run(context.Background(), args, strings.NewReader(""), &outputs[i], &bytes.Buffer{})
+		}(i)
+	}
+
+	wg.Wait()
+	seen := map[string]bool{}
+	for i, output := range outputs {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+
+		for _, id := range strings.Fields(output.String()) {
+			if seen[id] {
+				t.Errorf("ID %s was generated twice", id)
+			}
+
+			seen[id] = true
+		}
+	}
+
+	if len(seen) != invocations*count {
+		t.Errorf("expected %d IDs, got %d", invocations*count, len(seen))
+	}
+
+	state, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	} else if strings.TrimSpace(string(state)) != strconv.Itoa(invocations*count) {
+		t.Errorf("expected the state %d, got %q", invocations*count, state)
+	}
+
+	if _, err = os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("expected the lock to be released, got %v", err)
+	}
+}
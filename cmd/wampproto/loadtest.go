@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+// histogramWidth is the width of the bar of the fullest histogram bucket.
+const histogramWidth = 40
+
+func registerLoadTest(c *cli, cmd *kingpin.CmdClause) {
+	url := cmd.Flag("url", "WebSocket URL of the router, e.g. ws://localhost:8080/ws.").Required().String()
+	realm := cmd.Flag("realm", "Realm to join anonymously.").Default("realm1").String()
+	serializer := cmd.Flag("serializer", "Serializer to encode messages with.").Short('s').
+		Default(wampprotocli.JSONSerializer).Enum(wampprotocli.SerializerNames()...)
+	rate := cmd.Flag("rate", "Requests to send per second.").Default("100").Int()
+	procedures := cmd.Flag("calls", "Procedure to call, may be repeated.").PlaceHolder("URI").Strings()
+	topics := cmd.Flag("publishes", "Topic to publish to with acknowledgement, may be repeated.").
+		PlaceHolder("URI").Strings()
+	payloadSize := cmd.Flag("payload-size", "Size of the string argument of every request, e.g. 512, 1k or 1m.").
+		Default("0").String()
+	duration := cmd.Flag("duration", "Time to send requests for.").Default("10s").Duration()
+	timeout := cmd.Flag("timeout", "Time to wait for the WebSocket handshake and for the "+
+		"outstanding responses at the end.").Default("5s").Duration()
+	output := cmd.Flag("output", "Output format.").Default(textOutput).Enum(textOutput, markdownOutput,
+		jsonOutput)
+	c.handle(cmd, func(e *env) error {
+		size, err := parseByteSize(*payloadSize)
+		if err != nil {
+			return err
+		}
+
+		transport, err := dialWebSocket(e, *url, *serializer, *timeout)
+		if err != nil {
+			return err
+		}
+
+		defer func() { _ = transport.conn.Close() }()
+
+		// Reads last until the outstanding responses are due, as a router
+		// may fall behind for the whole duration.
+		transport.timeout = *duration + *timeout
+
+		result, err := wampprotocli.RunLoadTest(e.ctx, transport, &wampprotocli.LoadTestConfig{
+			Realm:       *realm,
+			Serializer:  *serializer,
+			Rate:        *rate,
+			Duration:    *duration,
+			Procedures:  *procedures,
+			Topics:      *topics,
+			PayloadSize: size,
+			Timeout:     *timeout,
+		})
+		if err != nil {
+			return err
+		}
+
+		return writeLoadTest(e.stdout, *output, result)
+	})
+}
+
+// parseByteSize parses a size in bytes with an optional k or m suffix for
+// kibibytes or mebibytes.
+func parseByteSize(value string) (int, error) {
+	number, multiplier := strings.TrimSuffix(strings.ToLower(value), "b"), 1
+	switch {
+	case strings.HasSuffix(number, "k"):
+		number, multiplier = strings.TrimSuffix(number, "k"), 1<<10
+	case strings.HasSuffix(number, "m"):
+		number, multiplier = strings.TrimSuffix(number, "m"), 1<<20
+	}
+
+	size, err := strconv.Atoi(number)
+	if err != nil || size < 0 || size > math.MaxInt32/multiplier {
+		return 0, fmt.Errorf("invalid size %q, must be e.g. 512, 1k or 1m", value)
+	}
+
+	return size * multiplier, nil
+}
+
+// writeLoadTest writes result as JSON or as tables of the summary, the
+// latencies per request and the latency histogram.
+func writeLoadTest(w io.Writer, output string, result *wampprotocli.LoadTestResult) error {
+	if output == jsonOutput {
+		return json.NewEncoder(w).Encode(result)
+	}
+
+	summary := [][]string{
+		{"metric", "value"},
+		{"duration", result.Duration.String()},
+		{"sent", strconv.Itoa(result.Sent)},
+		{"completed", strconv.Itoa(result.Completed)},
+		{"errors", strconv.Itoa(result.Errors)},
+		{"timeouts", strconv.Itoa(result.Timeouts)},
+		{"throughput", fmt.Sprintf("%.0f/s", result.Throughput)},
+	}
+
+	errorURIs := make([]string, 0, len(result.ErrorURIs))
+	for uri := range result.ErrorURIs {
+		errorURIs = append(errorURIs, uri)
+	}
+
+	slices.Sort(errorURIs)
+	for _, uri := range errorURIs {
+		summary = append(summary, []string{"error " + uri, strconv.Itoa(result.ErrorURIs[uri])})
+	}
+
+	requests := [][]string{{"request", "uri", "count", "errors", "min", "mean", "p50", "p90", "p99", "max"}}
+	for _, r := range result.Requests {
+		requests = append(requests, []string{r.Request, r.URI, strconv.Itoa(r.Count), strconv.Itoa(r.Errors),
+			r.Min.String(), r.Mean.String(), r.P50.String(), r.P90.String(), r.P99.String(), r.Max.String()})
+	}
+
+	fullest := 0
+	for _, bucket := range result.Histogram {
+		fullest = max(fullest, bucket.Count)
+	}
+
+	histogram := [][]string{{"latency", "count", "distribution"}}
+	for _, bucket := range result.Histogram {
+		bound := "> " + result.Histogram[max(len(result.Histogram)-2, 0)].UpperBound.String()
+		if bucket.UpperBound > 0 {
+			bound = "<= " + bucket.UpperBound.String()
+		}
+
+		bar := ""
+		if fullest > 0 {
+			bar = strings.Repeat("#", int(math.Ceil(float64(bucket.Count*histogramWidth)/float64(fullest))))
+		}
+
+		histogram = append(histogram, []string{bound, strconv.Itoa(bucket.Count), bar})
+	}
+
+	for i, table := range [][][]string{summary, requests, histogram} {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		if err := writeTable(w, output, table); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,20 +1,295 @@
 package main
 
 import (
-	"encoding/hex"
-	"log"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/xconnio/wampproto-go/messages"
-	"github.com/xconnio/wampproto-go/serializers"
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
 )
 
+const appDescription = "A tool for testing interoperability between different wampproto implementations."
+
+// seedEnvar names the environment variable that seeds invocations without
+// --seed.
+const seedEnvar = "WAMPPROTO_SEED"
+
+// env is everything a command needs to execute.
+type env struct {
+	ctx    context.Context
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	logger *slog.Logger
+
+	// strict turns validation warnings into errors.
+	strict bool
+	// showSecrets disables the redaction of secrets.
+	showSecrets bool
+	// seeded is whether randomness is seeded with --seed.
+	seeded bool
+	// warnings collects validation warnings, which are summarized on stderr
+	// once the invocation completes.
+	warnings *warningCollector
+}
+
+type cli struct {
+	app      *kingpin.Application
+	handlers map[string]func(*env) error
+
+	logFormat        *string
+	profile          *string
+	strict           *bool
+	warningsAsErrors *bool
+	showSecrets      *bool
+	copyOutput       *bool
+	paste            *bool
+	seed             *string
+}
+
+// handle registers the function that executes cmd.
+func (c *cli) handle(cmd *kingpin.CmdClause, handler func(*env) error) {
+	c.handlers[cmd.FullCommand()] = handler
+}
+
 func main() {
-	hello := messages.NewHello("realm1", "test", nil, nil, []string{"anonymous"})
-	serializer := &serializers.MsgPackSerializer{}
-	data, err := serializer.Serialize(hello)
-	if err != nil {
-		panic(err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		stop()
+		fmt.Fprintf(os.Stderr, "wampproto: error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run parses args and executes the selected command. All I/O goes through
+// the given readers and writers and the process is never terminated, so
+// that the CLI can be embedded and driven in-process. Long-running commands
+// stop when ctx is done.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	return execute(ctx, args, stdin, stdout, stderr, nil)
+}
+
+// execute is run for commands executed on behalf of another command, which
+// pass their warning collector as parent so that warnings are summarized
+// once for the whole invocation.
+func execute(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer,
+	parent *warningCollector) (err error) {
+	if args, err = pasteArgs(ctx, args); err != nil {
+		return err
+	}
+
+	var terminated bool
+	c := newCLI(stdout, stderr, func(int) { terminated = true })
+
+	selected := selectCommand(c.app, args)
+	for _, command := range topLevelCommands() {
+		if selected == "" || selected == command.name {
+			command.register(c, c.app.Command(command.name, command.help))
+		}
+	}
+
+	command, err := c.app.Parse(args)
+	if terminated {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	handler, ok := c.handlers[command]
+	if !ok {
+		return fmt.Errorf("command %q is not implemented", command)
+	}
+
+	if *c.profile != "" {
+		stopProfiling, profileErr := startProfiling(*c.profile)
+		if profileErr != nil {
+			return profileErr
+		}
+
+		defer func() { err = errors.Join(err, stopProfiling()) }()
+	}
+
+	seedValue := *c.seed
+	if seedValue == "" && parent == nil {
+		seedValue = os.Getenv(seedEnvar)
+	}
+
+	var seed int64
+	if seedValue != "" {
+		if seed, err = strconv.ParseInt(seedValue, 10, 64); err != nil {
+			return fmt.Errorf("seed must be an integer, was %q", seedValue)
+		}
+
+		previous := wampprotocli.SetRandomSource(wampprotocli.NewSeededReader(seed))
+		defer wampprotocli.SetRandomSource(previous)
+
+		// Timestamps are fixed at the Unix epoch, so that fixtures holding
+		// them are reproducible too.
+		previousClock := wampprotocli.SetClock(func() time.Time { return time.Unix(0, 0) })
+		defer wampprotocli.SetClock(previousClock)
+	}
+
+	warnings := parent
+	if warnings == nil {
+		warnings = &warningCollector{}
+	}
+
+	if *c.paste {
+		text, pasteErr := readClipboard(ctx)
+		if pasteErr != nil {
+			return pasteErr
+		}
+
+		stdin = strings.NewReader(text)
+	}
+
+	var output bytes.Buffer
+	if *c.copyOutput {
+		stdout = io.MultiWriter(stdout, &output)
+	}
+
+	logger := newLogger(*c.logFormat, stderr, *c.showSecrets)
+	err = handler(&env{
+		ctx:         ctx,
+		stdin:       stdin,
+		stdout:      stdout,
+		stderr:      stderr,
+		logger:      logger,
+		strict:      *c.strict,
+		showSecrets: *c.showSecrets,
+		seeded:      seedValue != "",
+		warnings:    warnings,
+	})
+
+	if err == nil && *c.copyOutput {
+		err = writeClipboard(ctx, strings.TrimSpace(output.String()))
+	}
+
+	if parent == nil {
+		if count := warnings.summarize(logger); count > 0 && *c.warningsAsErrors {
+			err = errors.Join(err, fmt.Errorf("%d validation warning(s) reported", count))
+		}
+	}
+
+	return err
+}
+
+type topLevelCommand struct {
+	name     string
+	help     string
+	register func(*cli, *kingpin.CmdClause)
+}
+
+func topLevelCommands() []topLevelCommand {
+	return []topLevelCommand{
+		{"http-serve", "Expose serialize, parse and sign as JSON endpoints over HTTP.", registerServe},
+		{"batch", "Execute command lines read from stdin in-process, printing one output line each.", registerBatch},
+		{"bench", "Measure serialize and parse throughput and latency.", registerBench},
+		{"validate", "Check values against the WAMP spec.", registerValidate},
+		{"idgen", "Generate WAMP IDs of the global, router or session scope.", registerIDGen},
+		{"uri", "Match URIs the way brokers and dealers do.", registerURI},
+		{"message", "Build and serialize WAMP messages.", registerMessage},
+		{"scenario", "Emit the ordered messages of protocol flows, serialized.", registerScenario},
+		{"auth", "Generate keys for WAMP authentication and encryption.", registerAuth},
+		{"payload", "Encrypt and decrypt message payloads end-to-end.", registerPayload},
+		{"capture", "Decode and analyze recorded WAMP traffic.", registerCapture},
+		{"loadtest", "Send sustained CALL and PUBLISH traffic to a live router and measure its latency.",
+			registerLoadTest},
+		{"shell", "Execute commands interactively with persistent settings and history.", registerShell},
+		{"audit-self", "Report the cryptographic primitives and parameters in use.", registerAuditSelf},
+	}
+}
+
+// newCLI returns the application with its global flags but without commands,
+// writing usage to stdout and errors to stderr and calling terminate instead
+// of exiting.
+func newCLI(stdout, stderr io.Writer, terminate func(int)) *cli {
+	c := &cli{
+		app: kingpin.New("wampproto", appDescription).
+			UsageWriter(stdout).
+			ErrorWriter(stderr).
+			Terminate(terminate),
+		handlers: make(map[string]func(*env) error),
+	}
+
+	c.logFormat = c.app.Flag("log-format", "Format of log records written to stderr.").
+		Default("text").Enum("text", "json")
+	c.profile = c.app.Flag("profile", "Write profiles while the command runs, e.g. cpu=cpu.out,mem=mem.out.").
+		PlaceHolder("KIND=FILE,...").String()
+	c.strict = c.app.Flag("strict", "Treat every validation warning as an error, e.g. for CI conformance gates.").
+		Bool()
+	c.warningsAsErrors = c.app.Flag("warnings-as-errors", "Exit with an error after the command completes if "+
+		"any validation warnings were reported.").Bool()
+	c.showSecrets = c.app.Flag("show-secrets", "Show private keys, tickets and other secrets in logs, shell "+
+		"history and transcripts instead of redacting them.").Bool()
+	c.seed = c.app.Flag("seed", "Make keys, challenges, nonces, random IDs and realms and the random invocation "+
+		"policy deterministic for reproducible fixtures, instead of using the system CSPRNG. Defaults to $"+
+		seedEnvar+". Seeded keys are predictable, never use them outside tests.").PlaceHolder("N").String()
+	c.copyOutput = c.app.Flag("copy", "Also place the output of the command, e.g. a key, signature or "+
+		"serialized message, on the system clipboard.").Bool()
+	c.paste = c.app.Flag("paste", "Read the input of the command from the system clipboard instead of stdin. "+
+		"Arguments and flag values written as "+clipboardSource+" are always replaced with the clipboard.").Bool()
+
+	return c
+}
+
+// selectCommand returns the top-level command invoked by args, so that only
+// its subtree has to be constructed. It returns "" when the command cannot be
+// determined, e.g. for help or typos, in which case everything is built so
+// that kingpin can produce complete usage and error messages.
+func selectCommand(app *kingpin.Application, args []string) string {
+	takesValue := make(map[string]bool)
+	for _, flag := range app.Model().Flags {
+		takesValue["--"+flag.Name] = !flag.IsBoolFlag()
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return ""
+		case strings.HasPrefix(arg, "-"):
+			if takesValue[arg] {
+				i++
+			}
+		default:
+			for _, command := range topLevelCommands() {
+				if command.name == arg {
+					return arg
+				}
+			}
+
+			return ""
+		}
+	}
+
+	return ""
+}
+
+// newLogger returns a logger writing records in format to w, redacting the
+// values of attributes naming a secret unless showSecrets is set.
+func newLogger(format string, w io.Writer, showSecrets bool) *slog.Logger {
+	options := &slog.HandlerOptions{}
+	if !showSecrets {
+		options.ReplaceAttr = wampprotocli.RedactSecrets
+	}
+
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, options))
 	}
 
-	log.Println("HELLO", hex.EncodeToString(data))
+	return slog.New(slog.NewTextHandler(w, options))
 }
@@ -0,0 +1,992 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-go/messages"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+const (
+	progressOption   = "progress"
+	discloseMeOption = "disclose_me"
+	matchOption      = "match"
+)
+
+// encodeFlags are the flags selecting how commands serialize messages.
+type encodeFlags struct {
+	serializer *string
+	encoding   *string
+	noValidate *bool
+	sortKeys   *bool
+	// nonFinite, bigInt, maxMessageSize, lenient and allowReserved are as
+	// for SerializeRequest.
+	nonFinite      *string
+	bigInt         *string
+	maxMessageSize *int
+	lenient        *bool
+	allowReserved  *bool
+}
+
+func addEncodeFlags(cmd *kingpin.CmdClause) *encodeFlags {
+	return &encodeFlags{
+		serializer: addSerializerFlag(cmd, "Serializer to encode messages with."),
+		encoding:   addEncodingFlag(cmd),
+		noValidate: cmd.Flag("no-validate", "Skip spec validation, e.g. to produce invalid messages for "+
+			"negative tests.").Bool(),
+		sortKeys:       cmd.Flag("sort-keys", "Sort map keys so that the output is reproducible.").Bool(),
+		nonFinite:      addNonFiniteFlag(cmd),
+		bigInt:         addBigIntFlag(cmd),
+		maxMessageSize: addMaxMessageSizeFlag(cmd),
+		lenient:        cmd.Flag("lenient", "Report invalid UTF-8 as a warning instead of failing.").Bool(),
+		allowReserved: cmd.Flag("allow-reserved", "Accept procedures and topics reserved for the WAMP protocol "+
+			"in REGISTER and PUBLISH messages, e.g. to test that routers reject them.").Bool(),
+	}
+}
+
+// serializeFlags are the flags of commands that serialize a single message,
+// which may also explain and assert on it.
+type serializeFlags struct {
+	*encodeFlags
+	explain *bool
+	// expect, expectFile and expectSemantic turn the command into an
+	// assertion on the serialized message.
+	expect         *string
+	expectFile     *string
+	expectSemantic *bool
+	// verify deserializes the serialized message again and compares it with
+	// the message that was serialized.
+	verify *bool
+}
+
+// addSerializeFlags adds the flags of a command serializing a message. Only
+// commands serializing a single message get them, so that the assertions
+// can't be given to commands ignoring them.
+func addSerializeFlags(cmd *kingpin.CmdClause) *serializeFlags {
+	return &serializeFlags{
+		encodeFlags: addEncodeFlags(cmd),
+		explain: cmd.Flag("explain", "Follow the serialized message with a breakdown of its fields: their "+
+			"position, spec name and type, value and the spec section defining them.").Bool(),
+		expect: cmd.Flag("expect", "Hex or base64 encoded bytes the serialized message must equal, failing with a "+
+			"diff otherwise.").PlaceHolder("BYTES").String(),
+		expectFile: cmd.Flag("expect-file", "File holding the bytes the serialized message must equal, raw or hex "+
+			"or base64 encoded.").PlaceHolder("FILE").ExistingFile(),
+		expectSemantic: cmd.Flag("expect-semantic", "Compare the expected message by content instead of byte by "+
+			"byte, ignoring map key order and number encodings.").Bool(),
+		verify: cmd.Flag("verify-round-trip", "Deserialize the serialized message again and fail with a diff unless "+
+			"every field survived the round trip, e.g. numbers losing precision.").Bool(),
+	}
+}
+
+// readFlags are the flags of message commands reading a serialized message.
+type readFlags struct {
+	serializer     *string
+	noValidate     *bool
+	maxMessageSize *int
+	allowReserved  *bool
+	// nonFinite and bigInt are the policies for the values of the message
+	// that JSON can't represent, applied where it is written as or compared
+	// with JSON.
+	nonFinite *string
+	bigInt    *string
+}
+
+func addReadFlags(cmd *kingpin.CmdClause) *readFlags {
+	return &readFlags{
+		serializer: addSerializerFlag(cmd, "Serializer the message is encoded with."),
+		noValidate: cmd.Flag("no-validate", "Skip spec validation, e.g. to read invalid messages of "+
+			"negative tests.").Bool(),
+		maxMessageSize: addMaxMessageSizeFlag(cmd),
+		allowReserved: cmd.Flag("allow-reserved", "Accept procedures and topics reserved for the WAMP protocol "+
+			"in REGISTER and PUBLISH messages, e.g. to check messages of tests that routers reject them.").Bool(),
+		nonFinite: addNonFiniteFlag(cmd),
+		bigInt:    addBigIntFlag(cmd),
+	}
+}
+
+// request returns the request parsing data with the flags. The policies of
+// nonFinite and bigInt are only set for asJSON.
+func (f *readFlags) request(e *env, data string, asJSON bool) *wampprotocli.ParseRequest {
+	request := &wampprotocli.ParseRequest{
+		Serializer:     *f.serializer,
+		Data:           data,
+		NoValidate:     *f.noValidate,
+		Strict:         e.strict,
+		AllowReserved:  *f.allowReserved,
+		MaxMessageSize: *f.maxMessageSize,
+	}
+	if asJSON {
+		request.NonFinite, request.BigInt = *f.nonFinite, *f.bigInt
+	}
+
+	return request
+}
+
+func addSerializerFlag(cmd *kingpin.CmdClause, help string) *string {
+	return cmd.Flag("serializer", help).Short('s').Default(wampprotocli.JSONSerializer).
+		Enum(wampprotocli.SerializerNames()...)
+}
+
+func addNonFiniteFlag(cmd *kingpin.CmdClause) *string {
+	return cmd.Flag("nonfinite", "How to write NaN and infinite floats as JSON, which can't represent them: "+
+		"fail, or replace them with null or a string.").Default(wampprotocli.NonFiniteError).
+		Enum(wampprotocli.NonFinitePolicies()...)
+}
+
+func addBigIntFlag(cmd *kingpin.CmdClause) *string {
+	return cmd.Flag("bigint", "How to write integers beyond 2^53 as JSON: keep all digits, fail, clamp them "+
+		"to ±2^53 or replace them with a string.").Default(wampprotocli.BigIntExact).
+		Enum(wampprotocli.BigIntPolicies()...)
+}
+
+func addMaxMessageSizeFlag(cmd *kingpin.CmdClause) *int {
+	return cmd.Flag("max-message-size", "Reject serialized messages larger than this many bytes, even with "+
+		"--no-validate (0 is unlimited).").Default("0").Int()
+}
+
+func addEncodingFlag(cmd *kingpin.CmdClause) *string {
+	return cmd.Flag("output", "Encoding of the serialized bytes.").Short('o').Default(wampprotocli.HexOutput).
+		Enum(wampprotocli.HexOutput, wampprotocli.Base64Output)
+}
+
+// args returns the flags as the arguments of a message command, leaving
+// defaults implicit.
+func (f *serializeFlags) args() []string {
+	var args []string
+	if *f.serializer != wampprotocli.JSONSerializer {
+		args = append(args, "--serializer", *f.serializer)
+	}
+
+	if *f.encoding != wampprotocli.HexOutput {
+		args = append(args, "--output", *f.encoding)
+	}
+
+	if *f.noValidate {
+		args = append(args, "--no-validate")
+	}
+
+	if *f.sortKeys {
+		args = append(args, "--sort-keys")
+	}
+
+	if *f.explain {
+		args = append(args, "--explain")
+	}
+
+	if *f.nonFinite != wampprotocli.NonFiniteError {
+		args = append(args, "--nonfinite", *f.nonFinite)
+	}
+
+	if *f.bigInt != wampprotocli.BigIntExact {
+		args = append(args, "--bigint", *f.bigInt)
+	}
+
+	if *f.maxMessageSize != 0 {
+		args = append(args, "--max-message-size", strconv.Itoa(*f.maxMessageSize))
+	}
+
+	if *f.lenient {
+		args = append(args, "--lenient")
+	}
+
+	if *f.allowReserved {
+		args = append(args, "--allow-reserved")
+	}
+
+	if *f.expect != "" {
+		args = append(args, "--expect", *f.expect)
+	}
+
+	if *f.expectFile != "" {
+		args = append(args, "--expect-file", *f.expectFile)
+	}
+
+	if *f.expectSemantic {
+		args = append(args, "--expect-semantic")
+	}
+
+	if *f.verify {
+		args = append(args, "--verify-round-trip")
+	}
+
+	return args
+}
+
+// serialize serializes the positional message raw as Serialize does for
+// HTTP requests and returns the encoded bytes.
+func (f *encodeFlags) serialize(e *env, raw []any) (string, error) {
+	response, err := wampprotocli.Serialize(&wampprotocli.SerializeRequest{
+		Serializer:     *f.serializer,
+		Message:        raw,
+		Encoding:       *f.encoding,
+		NoValidate:     *f.noValidate,
+		Strict:         e.strict,
+		Lenient:        *f.lenient,
+		AllowReserved:  *f.allowReserved,
+		MaxMessageSize: *f.maxMessageSize,
+		SortKeys:       *f.sortKeys,
+		NonFinite:      *f.nonFinite,
+		BigInt:         *f.bigInt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	e.warnings.add(response.Warnings...)
+	return response.Data, nil
+}
+
+// argumentFlags are the flags for the arguments and keyword arguments of a
+// payload.
+type argumentFlags struct {
+	args   *[]string
+	kwargs *map[string]string
+}
+
+func addArgumentFlags(cmd *kingpin.CmdClause) *argumentFlags {
+	return &argumentFlags{
+		args: cmd.Flag("arg", "Positional argument, may be repeated. Values are decoded as JSON if they "+
+			"are valid JSON and are strings otherwise.").Short('a').Strings(),
+		kwargs: cmd.Flag("kwarg", "Keyword argument as KEY=VALUE, may be repeated. Values are decoded "+
+			"like --arg.").Short('k').PlaceHolder("KEY=VALUE").StringMap(),
+	}
+}
+
+// payload returns the decoded arguments and keyword arguments.
+func (a *argumentFlags) payload() *wampprotocli.PPTPayload {
+	args := make([]any, 0, len(*a.args))
+	for _, arg := range *a.args {
+		args = append(args, parseValue(arg))
+	}
+
+	kwargs := make(map[string]any, len(*a.kwargs))
+	for key, value := range *a.kwargs {
+		kwargs[key] = parseValue(value)
+	}
+
+	return &wampprotocli.PPTPayload{Args: args, Kwargs: kwargs}
+}
+
+// cryptoboxFlags are the flags for the keys of an end-to-end encrypted
+// exchange.
+type cryptoboxFlags struct {
+	privateKey    *string
+	peerPublicKey *string
+}
+
+func addCryptoboxFlags(cmd *kingpin.CmdClause, prefix string) *cryptoboxFlags {
+	return &cryptoboxFlags{
+		privateKey: cmd.Flag(prefix+"private-key", "Hex encoded cryptobox private key of this peer.").
+			PlaceHolder("HEX").String(),
+		peerPublicKey: cmd.Flag(prefix+"public-key", "Hex encoded cryptobox public key of the other peer.").
+			PlaceHolder("HEX").String(),
+	}
+}
+
+func (c *cryptoboxFlags) keys() (*wampprotocli.CryptoboxKeys, error) {
+	return wampprotocli.CryptoboxKeysFromHex(*c.privateKey, *c.peerPublicKey)
+}
+
+// payloadFlags are the flags of commands building payload-carrying messages.
+type payloadFlags struct {
+	arguments     *argumentFlags
+	pptScheme     *string
+	pptSerializer *string
+	pptCipher     *string
+	pptKeyID      *string
+	pptKeys       *cryptoboxFlags
+}
+
+func addPayloadFlags(cmd *kingpin.CmdClause) *payloadFlags {
+	return &payloadFlags{
+		arguments: addArgumentFlags(cmd),
+		pptScheme: cmd.Flag("ppt-scheme", "Carry the payload in payload passthru mode with this scheme, "+
+			"e.g. wamp, mqtt or x_custom.").String(),
+		pptSerializer: cmd.Flag("ppt-serializer", "Serialize the payload passthru payload separately and "+
+			"carry it as the single binary argument (native carries it as regular arguments).").
+			Enum(wampprotocli.PPTSerializerNames()...),
+		pptCipher: cmd.Flag("ppt-cipher", "Cipher the payload passthru payload is encrypted with, e.g. "+
+			wampprotocli.CryptoboxCipher+" with --ppt-private-key and --ppt-public-key or "+
+			wampprotocli.SealedBoxCipher+" with --ppt-public-key of the recipient.").String(),
+		pptKeyID: cmd.Flag("ppt-keyid", "ID of the key the payload passthru payload is encrypted with.").String(),
+		pptKeys:  addCryptoboxFlags(cmd, "ppt-"),
+	}
+}
+
+// appendTo appends the payload to the positional message raw, whose options
+// or details dictionary is options, serialized with serializer.
+func (p *payloadFlags) appendTo(raw []any, options map[string]any, serializer string) ([]any, error) {
+	keys, err := p.pptKeys.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	defer keys.Wipe()
+
+	payload := p.arguments.payload()
+	ppt := wampprotocli.PPT{Scheme: *p.pptScheme, Serializer: *p.pptSerializer, Cipher: *p.pptCipher,
+		KeyID: *p.pptKeyID, Keys: keys}
+	args, kwargs, err := wampprotocli.PackPPT(serializer, ppt, options, payload.Args, payload.Kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	return wampprotocli.AppendPayload(raw, args, kwargs), nil
+}
+
+// addOptionFlag adds a flag for the options or details dictionary of a
+// message. Values are always strings.
+func addOptionFlag(cmd *kingpin.CmdClause) *map[string]string {
+	return cmd.Flag("option", "Option or detail as KEY=VALUE, may be repeated. Values are strings.").
+		PlaceHolder("KEY=VALUE").StringMap()
+}
+
+// optionMap converts the values of an option flag into a dictionary.
+func optionMap(options *map[string]string) map[string]any {
+	result := make(map[string]any, len(*options))
+	for key, value := range *options {
+		result[key] = value
+	}
+
+	return result
+}
+
+// listValue converts values into a list as carried in options.
+func listValue[T any](values []T) []any {
+	list := make([]any, 0, len(values))
+	for _, value := range values {
+		list = append(list, value)
+	}
+
+	return list
+}
+
+// parseValue decodes value as JSON, keeping integers exact, or returns it as
+// a string if it isn't valid JSON.
+func parseValue(value string) any {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+	decoder.UseNumber()
+
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil || decoder.More() {
+		return value
+	}
+
+	return wampprotocli.NormalizeJSON(decoded)
+}
+
+// parseValues decodes the values of KEY=VALUE flags like --arg.
+func parseValues(values map[string]string) map[string]any {
+	parsed := make(map[string]any, len(values))
+	for key, value := range values {
+		parsed[key] = parseValue(value)
+	}
+
+	return parsed
+}
+
+// identityFlags are the flags for the details disclosing the identity of a
+// caller or publisher.
+type identityFlags struct {
+	role     string
+	session  *int64
+	authID   *string
+	authRole *string
+}
+
+func addIdentityFlags(cmd *kingpin.CmdClause, role string) *identityFlags {
+	return &identityFlags{
+		role: role,
+		session: cmd.Flag(role, fmt.Sprintf("Disclose the session ID of the %s.", role)).
+			PlaceHolder("ID").Int64(),
+		authID: cmd.Flag(role+"-authid", fmt.Sprintf("Disclose the authid of the %s.", role)).String(),
+		authRole: cmd.Flag(role+"-authrole", fmt.Sprintf("Disclose the authrole of the %s.", role)).
+			String(),
+	}
+}
+
+// applyTo adds the disclosed identity to details.
+func (i *identityFlags) applyTo(details map[string]any) {
+	if *i.session != 0 {
+		details[i.role] = *i.session
+	}
+
+	if *i.authID != "" {
+		details[i.role+"_authid"] = *i.authID
+	}
+
+	if *i.authRole != "" {
+		details[i.role+"_authrole"] = *i.authRole
+	}
+}
+
+// addMatchFlag adds the --match flag of commands building REGISTER and
+// SUBSCRIBE messages.
+func addMatchFlag(cmd *kingpin.CmdClause) *string {
+	return cmd.Flag("match", "Match policy of the pattern, sets the match option unless it is exact.").
+		Enum(wampprotocli.MatchPolicies()...)
+}
+
+// applyMatch sets the match option of options to policy, leaving exact
+// matching, the default, implicit.
+func applyMatch(options map[string]any, policy string) {
+	if policy != "" && policy != wampprotocli.MatchExact {
+		options[matchOption] = policy
+	}
+}
+
+func registerMessage(c *cli, cmd *kingpin.CmdClause) {
+	registerCallMessage(c, cmd)
+	registerInvocationMessage(c, cmd)
+	registerResultMessage(c, cmd)
+	registerPublishMessage(c, cmd)
+	registerSubscribeMessage(c, cmd)
+	registerEventMessage(c, cmd)
+	registerRegisterMessage(c, cmd)
+	registerWelcomeMessage(c, cmd)
+	registerGoodbyeMessage(c, cmd)
+	registerChallengeMessage(c, cmd)
+	registerErrorMessage(c, cmd)
+	registerUnsubscribeMessages(c, cmd)
+	registerPublishedMessage(c, cmd)
+	registerCancelMessage(c, cmd)
+	registerParseMessage(c, cmd)
+	registerConvertMessage(c, cmd)
+	registerValidateMessage(c, cmd)
+	registerDiffMessage(c, cmd)
+	registerExpectMessage(c, cmd)
+	registerWizardMessage(c, cmd)
+	registerSizeMessage(c, cmd)
+	registerExportCodeMessage(c, cmd)
+	registerDigestMessage(c, cmd)
+}
+
+func registerCallMessage(c *cli, cmd *kingpin.CmdClause) {
+	callCmd := cmd.Command("call", "Build a CALL message.")
+	requestID := callCmd.Arg("request-id", "Request ID of the call.").Required().Int64()
+	procedure := callCmd.Arg("procedure", "Procedure to call.").Required().String()
+	progress := callCmd.Flag("progress", "Mark the call as a progressive invocation, i.e. more calls "+
+		"with the same request ID follow.").Bool()
+	timeout := callCmd.Flag("timeout", "Have the dealer cancel the call if it doesn't complete within "+
+		"this many milliseconds, sets the integer timeout option.").PlaceHolder("MS").Int64()
+	discloseMe := callCmd.Flag("disclose-me", "Ask the dealer to disclose the caller's identity to the callee.").
+		Bool()
+	payload := addPayloadFlags(callCmd)
+	optionFlag := addOptionFlag(callCmd)
+	flags := addSerializeFlags(callCmd)
+	c.handle(callCmd, func(e *env) error {
+		options := optionMap(optionFlag)
+		if *progress {
+			options[progressOption] = true
+		}
+
+		if *timeout < 0 {
+			return fmt.Errorf("timeout must not be negative, was %d", *timeout)
+		} else if *timeout > 0 {
+			options["timeout"] = *timeout
+		}
+
+		if *discloseMe {
+			options[discloseMeOption] = true
+		}
+
+		raw, err := payload.appendTo([]any{messages.MessageTypeCall, *requestID, options, *procedure},
+			options, *flags.serializer)
+		if err != nil {
+			return err
+		}
+
+		return printMessage(e, flags, raw)
+	})
+}
+
+func registerInvocationMessage(c *cli, cmd *kingpin.CmdClause) {
+	invocationCmd := cmd.Command("invocation", "Build an INVOCATION message.")
+	requestID := invocationCmd.Arg("request-id", "Request ID of the invocation.").Required().Int64()
+	registrationID := invocationCmd.Arg("registration-id", "Registration ID of the procedure.").Required().Int64()
+	progress := invocationCmd.Flag("progress", "Mark the invocation as progressive, i.e. more "+
+		"invocations with the same request ID follow.").Bool()
+	caller := addIdentityFlags(invocationCmd, "caller")
+	payload := addPayloadFlags(invocationCmd)
+	detailFlag := addOptionFlag(invocationCmd)
+	flags := addSerializeFlags(invocationCmd)
+	c.handle(invocationCmd, func(e *env) error {
+		details := optionMap(detailFlag)
+		if *progress {
+			details[progressOption] = true
+		}
+
+		caller.applyTo(details)
+		raw, err := payload.appendTo([]any{messages.MessageTypeInvocation, *requestID, *registrationID, details},
+			details, *flags.serializer)
+		if err != nil {
+			return err
+		}
+
+		return printMessage(e, flags, raw)
+	})
+}
+
+func registerResultMessage(c *cli, cmd *kingpin.CmdClause) {
+	resultCmd := cmd.Command("result", "Build a RESULT message.")
+	requestID := resultCmd.Arg("request-id", "Request ID of the CALL.").Required().Int64()
+	progress := resultCmd.Flag("progress", "Mark the result as progressive, i.e. more results follow.").Bool()
+	payload := addPayloadFlags(resultCmd)
+	detailFlag := addOptionFlag(resultCmd)
+	flags := addSerializeFlags(resultCmd)
+	c.handle(resultCmd, func(e *env) error {
+		details := optionMap(detailFlag)
+		if *progress {
+			details[progressOption] = true
+		}
+
+		raw, err := payload.appendTo([]any{messages.MessageTypeResult, *requestID, details}, details,
+			*flags.serializer)
+		if err != nil {
+			return err
+		}
+
+		return printMessage(e, flags, raw)
+	})
+}
+
+func registerPublishMessage(c *cli, cmd *kingpin.CmdClause) {
+	publishCmd := cmd.Command("publish", "Build a PUBLISH message.")
+	requestID := publishCmd.Arg("request-id", "Request ID of the publication.").Required().Int64()
+	topic := publishCmd.Arg("topic", "Topic to publish to.").Required().String()
+	discloseMe := publishCmd.Flag("disclose-me", "Ask the broker to disclose the publisher's identity to "+
+		"subscribers.").Bool()
+	exclude := publishCmd.Flag("exclude", "Session ID not to deliver the event to, may be repeated.").
+		PlaceHolder("ID").Int64List()
+	excludeAuthID := publishCmd.Flag("exclude-authid", "Authid not to deliver the event to, may be repeated.").
+		Strings()
+	excludeAuthRole := publishCmd.Flag("exclude-authrole", "Authrole not to deliver the event to, may be "+
+		"repeated.").Strings()
+	eligible := publishCmd.Flag("eligible", "Session ID to deliver the event to exclusively, may be repeated.").
+		PlaceHolder("ID").Int64List()
+	eligibleAuthID := publishCmd.Flag("eligible-authid", "Authid to deliver the event to exclusively, may be "+
+		"repeated.").Strings()
+	eligibleAuthRole := publishCmd.Flag("eligible-authrole", "Authrole to deliver the event to exclusively, "+
+		"may be repeated.").Strings()
+	retain := publishCmd.Flag("retain", "Ask the broker to retain the event for future subscribers.").Bool()
+	payload := addPayloadFlags(publishCmd)
+	optionFlag := addOptionFlag(publishCmd)
+	flags := addSerializeFlags(publishCmd)
+	c.handle(publishCmd, func(e *env) error {
+		options := optionMap(optionFlag)
+		if *discloseMe {
+			options[discloseMeOption] = true
+		}
+
+		if *retain {
+			options["retain"] = true
+		}
+
+		for key, ids := range map[string][]int64{"exclude": *exclude, "eligible": *eligible} {
+			if len(ids) > 0 {
+				options[key] = listValue(ids)
+			}
+		}
+
+		for key, values := range map[string][]string{
+			"exclude_authid":    *excludeAuthID,
+			"exclude_authrole":  *excludeAuthRole,
+			"eligible_authid":   *eligibleAuthID,
+			"eligible_authrole": *eligibleAuthRole,
+		} {
+			if len(values) > 0 {
+				options[key] = listValue(values)
+			}
+		}
+
+		raw, err := payload.appendTo([]any{messages.MessageTypePublish, *requestID, options, *topic}, options,
+			*flags.serializer)
+		if err != nil {
+			return err
+		}
+
+		return printMessage(e, flags, raw)
+	})
+}
+
+func registerSubscribeMessage(c *cli, cmd *kingpin.CmdClause) {
+	subscribeCmd := cmd.Command("subscribe", "Build a SUBSCRIBE message.")
+	requestID := subscribeCmd.Arg("request-id", "Request ID of the subscription.").Required().Int64()
+	topic := subscribeCmd.Arg("topic", "Topic to subscribe to.").Required().String()
+	match := addMatchFlag(subscribeCmd)
+	getRetained := subscribeCmd.Flag("get-retained", "Ask the broker to send the retained event of the "+
+		"topic, if any.").Bool()
+	optionFlag := addOptionFlag(subscribeCmd)
+	flags := addSerializeFlags(subscribeCmd)
+	c.handle(subscribeCmd, func(e *env) error {
+		options := optionMap(optionFlag)
+		applyMatch(options, *match)
+		if *getRetained {
+			options["get_retained"] = true
+		}
+
+		return printMessage(e, flags, []any{messages.MessageTypeSubscribe, *requestID, options, *topic})
+	})
+}
+
+func registerEventMessage(c *cli, cmd *kingpin.CmdClause) {
+	eventCmd := cmd.Command("event", "Build an EVENT message.")
+	subscriptionID := eventCmd.Arg("subscription-id", "Subscription ID of the subscriber.").Required().Int64()
+	publicationID := eventCmd.Arg("publication-id", "Publication ID of the event.").Required().Int64()
+	publisher := addIdentityFlags(eventCmd, "publisher")
+	retained := eventCmd.Flag("retained", "Mark the event as a retained one, sent in response to "+
+		"get_retained.").Bool()
+	payload := addPayloadFlags(eventCmd)
+	detailFlag := addOptionFlag(eventCmd)
+	flags := addSerializeFlags(eventCmd)
+	c.handle(eventCmd, func(e *env) error {
+		details := optionMap(detailFlag)
+		publisher.applyTo(details)
+		if *retained {
+			details["retained"] = true
+		}
+
+		raw, err := payload.appendTo([]any{messages.MessageTypeEvent, *subscriptionID, *publicationID, details},
+			details, *flags.serializer)
+		if err != nil {
+			return err
+		}
+
+		return printMessage(e, flags, raw)
+	})
+}
+
+func registerRegisterMessage(c *cli, cmd *kingpin.CmdClause) {
+	registerCmd := cmd.Command("register", "Build a REGISTER message.")
+	requestID := registerCmd.Arg("request-id", "Request ID of the registration.").Required().Int64()
+	procedure := registerCmd.Arg("procedure", "Procedure to register.").Required().String()
+	match := addMatchFlag(registerCmd)
+	invoke := registerCmd.Flag("invoke", "Invocation policy of a shared registration, sets the invoke option unless "+
+		"it is single.").
+		Enum(wampprotocli.InvokePolicies()...)
+	optionFlag := addOptionFlag(registerCmd)
+	flags := addSerializeFlags(registerCmd)
+	c.handle(registerCmd, func(e *env) error {
+		options := optionMap(optionFlag)
+		applyMatch(options, *match)
+		if *invoke != "" && *invoke != wampprotocli.InvokeSingle {
+			options["invoke"] = *invoke
+		}
+
+		return printMessage(e, flags, []any{messages.MessageTypeRegister, *requestID, options, *procedure})
+	})
+}
+
+func registerWelcomeMessage(c *cli, cmd *kingpin.CmdClause) {
+	welcomeCmd := cmd.Command("welcome", "Build a WELCOME message.")
+	sessionID := welcomeCmd.Arg("session-id", "ID of the session the router opens.").Required().Int64()
+	roles := welcomeCmd.Flag("role", "Role the router announces, may be repeated (default: broker and dealer).").
+		Enums("broker", "dealer")
+	authID := welcomeCmd.Flag("authid", "Authid the client is authenticated as.").String()
+	authRole := welcomeCmd.Flag("authrole", "Authrole the client is authenticated with.").String()
+	authMethod := welcomeCmd.Flag("authmethod", "Method the client is authenticated with, e.g. cryptosign.").
+		String()
+	authProvider := welcomeCmd.Flag("authprovider", "Provider that authenticated the client, e.g. static.").
+		String()
+	detailFlag := addOptionFlag(welcomeCmd)
+	flags := addSerializeFlags(welcomeCmd)
+	c.handle(welcomeCmd, func(e *env) error {
+		details := optionMap(detailFlag)
+		announced := *roles
+		if len(announced) == 0 {
+			announced = []string{"broker", "dealer"}
+		}
+
+		roleDetails := map[string]any{}
+		for _, role := range announced {
+			roleDetails[role] = map[string]any{}
+		}
+
+		details["roles"] = roleDetails
+		for key, value := range map[string]string{
+			"authid":       *authID,
+			"authrole":     *authRole,
+			"authmethod":   *authMethod,
+			"authprovider": *authProvider,
+		} {
+			if value != "" {
+				details[key] = value
+			}
+		}
+
+		return printMessage(e, flags, []any{messages.MessageTypeWelcome, *sessionID, details})
+	})
+}
+
+func registerGoodbyeMessage(c *cli, cmd *kingpin.CmdClause) {
+	goodbyeCmd := cmd.Command("goodbye", "Build a GOODBYE message.")
+	reason := goodbyeCmd.Arg("reason", "Reason the session is closed, or the reason of the GOODBYE it "+
+		"answers, e.g. wamp.close.goodbye_and_out.").Default("wamp.close.close_realm").String()
+	message := goodbyeCmd.Flag("message", "Human readable message of the reason.").String()
+	detailFlag := addOptionFlag(goodbyeCmd)
+	flags := addSerializeFlags(goodbyeCmd)
+	c.handle(goodbyeCmd, func(e *env) error {
+		details := optionMap(detailFlag)
+		if *message != "" {
+			details["message"] = *message
+		}
+
+		return printMessage(e, flags, []any{messages.MessageTypeGoodbye, details, *reason})
+	})
+}
+
+func registerChallengeMessage(c *cli, cmd *kingpin.CmdClause) {
+	challengeCmd := cmd.Command("challenge", "Build a CHALLENGE message.")
+	authMethod := challengeCmd.Arg("authmethod", "Authentication method the router challenges, e.g. "+
+		"cryptosign or wampcra.").Required().String()
+	challenge := challengeCmd.Flag("challenge", "Challenge the client must sign, hex encoded random bytes "+
+		"for cryptosign or a JSON string for wampcra.").String()
+	salt := challengeCmd.Flag("salt", "Salt the wampcra secret is derived with.").String()
+	iterations := challengeCmd.Flag("iterations", "PBKDF2 iterations the wampcra secret is derived with.").
+		Int64()
+	keyLen := challengeCmd.Flag("keylen", "Length of the derived wampcra secret in bytes.").Int64()
+	extra := challengeCmd.Flag("extra", "Extra value as KEY=VALUE, may be repeated. Values are decoded "+
+		"like --arg.").PlaceHolder("KEY=VALUE").StringMap()
+	flags := addSerializeFlags(challengeCmd)
+	c.handle(challengeCmd, func(e *env) error {
+		extraValues := parseValues(*extra)
+		if *challenge != "" {
+			extraValues["challenge"] = *challenge
+		}
+
+		if *salt != "" {
+			extraValues["salt"] = *salt
+		}
+
+		for key, value := range map[string]int64{"iterations": *iterations, "keylen": *keyLen} {
+			if value < 0 {
+				return fmt.Errorf("%s must not be negative, was %d", key, value)
+			} else if value > 0 {
+				extraValues[key] = value
+			}
+		}
+
+		return printMessage(e, flags, []any{messages.MessageTypeChallenge, *authMethod, extraValues})
+	})
+}
+
+// errorRequestTypes returns the types of the requests an ERROR may answer by
+// the name of the command building them.
+func errorRequestTypes() map[string]int {
+	return map[string]int{
+		"subscribe":   messages.MessageTypeSubscribe,
+		"unsubscribe": messages.MessageTypeUnSubscribe,
+		"publish":     messages.MessageTypePublish,
+		"register":    messages.MessageTypeRegister,
+		"unregister":  messages.MessageTypeUnRegister,
+		"call":        messages.MessageTypeCall,
+		"cancel":      messages.MessageTypeCancel,
+		"invocation":  messages.MessageTypeInvocation,
+	}
+}
+
+func registerErrorMessage(c *cli, cmd *kingpin.CmdClause) {
+	types := errorRequestTypes()
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	errorCmd := cmd.Command("error", "Build an ERROR message.")
+	requestType := errorCmd.Arg("request-type", "Type of the failed request, e.g. call.").Required().
+		Enum(names...)
+	requestID := errorCmd.Arg("request-id", "Request ID of the failed request.").Required().Int64()
+	errorURI := errorCmd.Arg("error", "Error URI, e.g. wamp.error.no_such_procedure.").Required().String()
+	payload := addPayloadFlags(errorCmd)
+	detailFlag := addOptionFlag(errorCmd)
+	flags := addSerializeFlags(errorCmd)
+	c.handle(errorCmd, func(e *env) error {
+		details := optionMap(detailFlag)
+		raw, err := payload.appendTo([]any{messages.MessageTypeError, types[*requestType], *requestID, details,
+			*errorURI}, details, *flags.serializer)
+		if err != nil {
+			return err
+		}
+
+		return printMessage(e, flags, raw)
+	})
+}
+
+func registerUnsubscribeMessages(c *cli, cmd *kingpin.CmdClause) {
+	unsubscribeCmd := cmd.Command("unsubscribe", "Build an UNSUBSCRIBE message.")
+	requestID := unsubscribeCmd.Arg("request-id", "Request ID of the unsubscription.").Required().Int64()
+	subscriptionID := unsubscribeCmd.Arg("subscription-id", "Subscription ID to end.").Required().Int64()
+	unsubscribeFlags := addSerializeFlags(unsubscribeCmd)
+	c.handle(unsubscribeCmd, func(e *env) error {
+		return printMessage(e, unsubscribeFlags, []any{messages.MessageTypeUnSubscribe, *requestID,
+			*subscriptionID})
+	})
+
+	unsubscribedCmd := cmd.Command("unsubscribed", "Build an UNSUBSCRIBED message.")
+	unsubscribeID := unsubscribedCmd.Arg("request-id", "Request ID of the UNSUBSCRIBE.").Required().Int64()
+	unsubscribedFlags := addSerializeFlags(unsubscribedCmd)
+	c.handle(unsubscribedCmd, func(e *env) error {
+		return printMessage(e, unsubscribedFlags, []any{messages.MessageTypeUnSubscribed, *unsubscribeID})
+	})
+}
+
+func registerPublishedMessage(c *cli, cmd *kingpin.CmdClause) {
+	publishedCmd := cmd.Command("published", "Build a PUBLISHED message.")
+	requestID := publishedCmd.Arg("request-id", "Request ID of the acknowledged PUBLISH.").Required().Int64()
+	publicationID := publishedCmd.Arg("publication-id", "Publication ID the broker assigned.").Required().Int64()
+	flags := addSerializeFlags(publishedCmd)
+	c.handle(publishedCmd, func(e *env) error {
+		return printMessage(e, flags, []any{messages.MessageTypePublished, *requestID, *publicationID})
+	})
+}
+
+func registerCancelMessage(c *cli, cmd *kingpin.CmdClause) {
+	cancelCmd := cmd.Command("cancel", "Build a CANCEL message.")
+	requestID := cancelCmd.Arg("request-id", "Request ID of the CALL to cancel.").Required().Int64()
+	mode := cancelCmd.Flag("mode", "Cancellation mode, sets the mode option.").Enum(wampprotocli.CancelModes()...)
+	optionFlag := addOptionFlag(cancelCmd)
+	flags := addSerializeFlags(cancelCmd)
+	c.handle(cancelCmd, func(e *env) error {
+		options := optionMap(optionFlag)
+		if *mode != "" {
+			options["mode"] = *mode
+		}
+
+		return printMessage(e, flags, []any{messages.MessageTypeCancel, *requestID, options})
+	})
+}
+
+// printMessage serializes the positional message raw and prints it.
+func printMessage(e *env, flags *serializeFlags, raw []any) error {
+	data, err := flags.serialize(e, raw)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stdout, data)
+	if *flags.explain {
+		if err = explainMessage(e.stdout, raw); err != nil {
+			return err
+		}
+	}
+
+	if *flags.verify {
+		if err = flags.verifyRoundTrip(e, raw, data); err != nil {
+			return err
+		}
+	}
+
+	return flags.check(e, data)
+}
+
+// verifyRoundTrip deserializes the encoded serialized message data and
+// compares it with raw, printing the differences to stderr.
+func (f *serializeFlags) verifyRoundTrip(e *env, raw []any, data string) error {
+	serialized, err := wampprotocli.DecodeBytes(data)
+	if err != nil {
+		return err
+	}
+
+	diff, err := wampprotocli.DiffRoundTrip(*f.serializer, raw, serialized)
+	if err != nil {
+		return err
+	} else if len(diff) == 0 {
+		return nil
+	}
+
+	for _, line := range diff {
+		fmt.Fprintln(e.stderr, line)
+	}
+
+	return errors.New("serialized message differs from the message after a round trip")
+}
+
+// check compares the encoded serialized message data with the expected one,
+// if any, printing the differences to stderr.
+func (f *serializeFlags) check(e *env, data string) error {
+	if *f.expect != "" && *f.expectFile != "" {
+		return errors.New("--expect and --expect-file are mutually exclusive")
+	}
+
+	var expected []byte
+	var err error
+	switch {
+	case *f.expect != "":
+		if expected, err = wampprotocli.DecodeBytes(*f.expect); err != nil {
+			return fmt.Errorf("invalid --expect: %w", err)
+		}
+	case *f.expectFile != "":
+		if expected, err = os.ReadFile(*f.expectFile); err != nil {
+			return err
+		}
+
+		if decoded, err := wampprotocli.DecodeBytes(strings.TrimSpace(string(expected))); err == nil {
+			expected = decoded
+		}
+	case *f.expectSemantic:
+		return errors.New("--expect-semantic requires --expect or --expect-file")
+	default:
+		return nil
+	}
+
+	actual, err := wampprotocli.DecodeBytes(data)
+	if err != nil {
+		return err
+	}
+
+	var diff []string
+	if *f.expectSemantic {
+		expectedRaw, err := wampprotocli.DeserializeRaw(*f.serializer, expected)
+		if err != nil {
+			return fmt.Errorf("expected message is not a %s message: %w", *f.serializer, err)
+		}
+
+		actualRaw, err := wampprotocli.DeserializeRaw(*f.serializer, actual)
+		if err != nil {
+			return err
+		}
+
+		diff = wampprotocli.DiffMessages(expectedRaw, actualRaw)
+	} else {
+		diff = wampprotocli.DiffBytes(expected, actual)
+	}
+
+	if len(diff) == 0 {
+		return nil
+	}
+
+	for _, line := range diff {
+		fmt.Fprintln(e.stderr, line)
+	}
+
+	return errors.New("serialized message differs from the expected one")
+}
+
+// explainMessage prints a table of the fields of the positional message raw.
+func explainMessage(w io.Writer, raw []any) error {
+	rows := [][]string{{"position", "field", "type", "value", "spec"}}
+	for _, field := range wampprotocli.ExplainMessage(raw) {
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			value = []byte(fmt.Sprint(field.Value))
+		}
+
+		rows = append(rows, []string{strconv.Itoa(field.Position), field.Name, field.Type, string(value),
+			field.Spec})
+	}
+
+	fmt.Fprintln(w)
+	return writeTable(w, textOutput, rows)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerAuthOffline(c *cli, cmd *kingpin.CmdClause) {
+	offlineCmd := cmd.Command("offline", "Sign cryptosign challenges on a machine without network access.")
+
+	prepareCmd := offlineCmd.Command("prepare", "Bundle a cryptosign challenge into a signing request, printed "+
+		"as JSON, to take to the machine holding the private key.")
+	challenge := prepareCmd.Flag("challenge", "Hex encoded challenge.").PlaceHolder("HEX").String()
+	challengeMessage := prepareCmd.Flag("message", "Serialized CHALLENGE message, hex or base64 encoded, "+
+		"instead of --challenge.").PlaceHolder("DATA").String()
+	serializer := prepareCmd.Flag("serializer", "Serializer of --message.").Short('s').
+		Default(wampprotocli.JSONSerializer).Enum(wampprotocli.SerializerNames()...)
+	publicKey := prepareCmd.Flag("public-key", "Hex encoded public key that has to sign the challenge.").
+		PlaceHolder("HEX").String()
+	c.handle(prepareCmd, func(e *env) error {
+		challenge, err := offlineChallenge(*challenge, *challengeMessage, *serializer)
+		if err != nil {
+			return err
+		}
+
+		request, err := wampprotocli.NewOfflineSigningRequest(challenge, *publicKey)
+		if err != nil {
+			return err
+		}
+
+		return writeSigningRequest(e, request)
+	})
+
+	signCmd := offlineCmd.Command("sign", "Sign the challenge of a signing request and print the signed "+
+		"request, to take back to the machine running the session.")
+	signFile := signCmd.Arg("request", "Signing request file written by prepare.").Required().String()
+	privateKey := signCmd.Flag("private-key", "Hex encoded cryptosign private key.").PlaceHolder("HEX").
+		Required().String()
+	c.handle(signCmd, func(e *env) error {
+		var request wampprotocli.OfflineSigningRequest
+		if err := readJSONFile(*signFile, &request); err != nil {
+			return err
+		}
+
+		keyPair, err := cryptosignKeyPair(*privateKey)
+		if err != nil {
+			return err
+		}
+
+		defer keyPair.Wipe()
+		if err = request.Sign(keyPair); err != nil {
+			return err
+		}
+
+		return writeSigningRequest(e, &request)
+	})
+
+	completeCmd := offlineCmd.Command("complete", "Verify a signed request and print the serialized "+
+		"AUTHENTICATE message answering the challenge.")
+	signedFile := completeCmd.Arg("signed", "Signed request file written by sign.").Required().String()
+	preparedFile := completeCmd.Flag("request", "Signing request written by prepare, which the signed "+
+		"request has to answer.").PlaceHolder("FILE").String()
+	flags := addSerializeFlags(completeCmd)
+	c.handle(completeCmd, func(e *env) error {
+		var signed wampprotocli.OfflineSigningRequest
+		if err := readJSONFile(*signedFile, &signed); err != nil {
+			return err
+		}
+
+		if *preparedFile != "" {
+			var prepared wampprotocli.OfflineSigningRequest
+			if err := readJSONFile(*preparedFile, &prepared); err != nil {
+				return err
+			}
+
+			if !prepared.SameChallenge(&signed) {
+				return errors.New("signed request does not answer the prepared challenge")
+			}
+		}
+
+		raw, err := signed.Authenticate()
+		if err != nil {
+			return err
+		}
+
+		return printMessage(e, flags, raw)
+	})
+}
+
+// offlineChallenge returns the hex encoded challenge or the challenge of the
+// serialized CHALLENGE message.
+func offlineChallenge(challenge, message, serializer string) ([]byte, error) {
+	if (challenge == "") == (message == "") {
+		return nil, errors.New("either --challenge or --message is required")
+	}
+
+	if challenge != "" {
+		data, err := hex.DecodeString(challenge)
+		if err != nil {
+			return nil, errors.New("challenge must be hex encoded")
+		}
+
+		return data, nil
+	}
+
+	data, err := wampprotocli.DecodeBytes(message)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := wampprotocli.DeserializeRaw(serializer, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return wampprotocli.ChallengeFromMessage(raw)
+}
+
+func writeSigningRequest(e *env, request *wampprotocli.OfflineSigningRequest) error {
+	encoder := json.NewEncoder(e.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(request)
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+// hexdumpRowSize is the number of bytes per row of annotated hexdumps.
+const hexdumpRowSize = 16
+
+func registerParseMessage(c *cli, cmd *kingpin.CmdClause) {
+	parseCmd := cmd.Command("parse", "Deserialize a message serialized with --serializer and print its "+
+		"fields by name.")
+	data := parseCmd.Arg("data", "Hex or base64 encoded serialized message.").String()
+	flags := addReadFlags(parseCmd)
+	pptKeys := addCryptoboxFlags(parseCmd, "ppt-")
+	output := parseCmd.Flag("output", "Output format, json prints the parsed message as an object with "+
+		"the fields by name.").Default(textOutput).Enum(textOutput, jsonOutput)
+	stream := parseCmd.Flag("stream", "Parse the messages read from stdin in order instead of data, e.g. a "+
+		"captured session.").Bool()
+	framing := parseCmd.Flag("framing", "Framing of the messages read with --stream or --file: lines of hex or base64 "+
+		"encoded messages, or raw messages prefixed with their length as a 32-bit big-endian integer.").
+		Default(wampprotocli.LineFraming).Enum(wampprotocli.Framings()...)
+	file := parseCmd.Flag("file", "Parse the messages of a file, framed as by --framing, writing one line "+
+		"per message, or an error line for messages that fail to parse.").ExistingFile()
+	lenient := parseCmd.Flag("lenient", "Decode messages of unknown types, e.g. extension messages of a router, "+
+		"into their raw positional elements with a warning, and report invalid UTF-8 as a warning.").Bool()
+	annotate := parseCmd.Flag("annotate", "Follow the fields with a hexdump labelling the bytes of every field, "+
+		"e.g. to find where serializers disagree.").Bool()
+	wireTypes := parseCmd.Flag("wire-types", "Follow the fields with the exact wire type and value of every "+
+		"number, e.g. to tell integers encoded as floats apart.").Bool()
+	c.handle(parseCmd, func(e *env) error {
+		parse := func(serialized []byte) error {
+			// Only JSON output needs a policy for the values JSON can't
+			// represent.
+			request := flags.request(e, "", *output == jsonOutput)
+			request.Lenient = *lenient
+			request.AllowUnknownTypes = *lenient
+			request.WireTypes = *wireTypes
+			request.PPTPrivateKey = *pptKeys.privateKey
+			request.PPTPeerPublicKey = *pptKeys.peerPublicKey
+			response, err := wampprotocli.ParseBytes(request, serialized)
+			if err != nil {
+				return err
+			}
+
+			e.warnings.add(response.Warnings...)
+			var ranges []wampprotocli.ByteRange
+			if *annotate {
+				if ranges, err = wampprotocli.AnnotateMessage(*flags.serializer, serialized); err != nil {
+					return err
+				}
+			}
+
+			if *output == jsonOutput {
+				if *annotate {
+					return json.NewEncoder(e.stdout).Encode(annotatedResponse{response, ranges})
+				}
+
+				return json.NewEncoder(e.stdout).Encode(response)
+			}
+
+			if *file != "" {
+				return writeParsedRecord(e.stdout, response)
+			}
+
+			if err = writeParsedMessage(e.stdout, response); err != nil {
+				return err
+			}
+
+			if *wireTypes {
+				fmt.Fprintln(e.stdout)
+				if err = writeWireNumbers(e.stdout, response.Numbers); err != nil || !*annotate {
+					return err
+				}
+			} else if !*annotate {
+				return nil
+			}
+
+			fmt.Fprintln(e.stdout)
+			return writeAnnotatedHexdump(e.stdout, serialized, ranges)
+		}
+
+		sources := 0
+		for _, given := range []bool{*data != "", *stream, *file != ""} {
+			if given {
+				sources++
+			}
+		}
+
+		switch {
+		case sources > 1:
+			return errors.New("data, --stream and --file are mutually exclusive")
+		case *file != "" && *annotate && *output == textOutput:
+			return errors.New("--annotate with --file requires --output json")
+		case *file != "" && *wireTypes && *output == textOutput:
+			return errors.New("--wire-types with --file requires --output json")
+		case *stream:
+			return parseStream(e, *framing, *output, *flags.maxMessageSize, parse)
+		case *file != "":
+			return parseFile(e, *file, *framing, *output, *flags.maxMessageSize, parse)
+		case *data == "":
+			return errors.New("data, --stream or --file is required")
+		default:
+			serialized, err := wampprotocli.DecodeBytes(*data)
+			if err != nil {
+				return err
+			}
+
+			return parse(serialized)
+		}
+	})
+}
+
+// parseStream parses the messages read from stdin with parse, separating
+// those written as text by a blank line.
+func parseStream(e *env, framing, format string, maxMessageSize int, parse func(data []byte) error) error {
+	reader, err := wampprotocli.NewMessageReader(e.stdin, framing, maxMessageSize)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; ; i++ {
+		data, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("message %d: %w", i, err)
+		}
+
+		if i > 1 && format == textOutput {
+			fmt.Fprintln(e.stdout)
+		}
+
+		if err = parse(data); err != nil {
+			return fmt.Errorf("message %d: %w", i, err)
+		}
+	}
+}
+
+// parseFile parses the messages of the file path with parse, writing an
+// error line or object for those that fail, and fails if any did.
+func parseFile(e *env, path, framing, format string, maxMessageSize int, parse func(data []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	reader, err := wampprotocli.NewMessageReader(f, framing, maxMessageSize)
+	if err != nil {
+		return err
+	}
+
+	var total, failed int
+	for {
+		data, err := reader.Next()
+		var streamErr *wampprotocli.StreamError
+		if errors.Is(err, io.EOF) {
+			break
+		} else if errors.As(err, &streamErr) {
+			return fmt.Errorf("message %d: %w", total+1, err)
+		}
+
+		total++
+		if err == nil {
+			err = parse(data)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		failed++
+		if format == jsonOutput {
+			err = json.NewEncoder(e.stdout).Encode(parseError{Error: err.Error(),
+				Violations: wampprotocli.Violations(err)})
+		} else {
+			_, err = fmt.Fprintf(e.stdout, "error: %s\n", singleLine(err.Error()))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d messages failed to parse", failed, total)
+	}
+
+	return nil
+}
+
+// parseError is the JSON line of message parse --file for a message that
+// fails to parse.
+type parseError struct {
+	Error      string                   `json:"error"`
+	Violations []wampprotocli.Violation `json:"violations,omitempty"`
+}
+
+// annotatedResponse is the JSON output of message parse --annotate.
+type annotatedResponse struct {
+	*wampprotocli.ParseResponse
+	Annotations []wampprotocli.ByteRange `json:"annotations"`
+}
+
+// writeAnnotatedHexdump writes a hexdump of data with a row for every byte
+// range, wrapped at hexdumpRowSize bytes, followed by the field of the range.
+func writeAnnotatedHexdump(w io.Writer, data []byte, ranges []wampprotocli.ByteRange) error {
+	rows := [][]string{{"offset", "bytes", "field"}}
+	appendRows := func(start, end int, field string) {
+		for offset := start; offset < end; offset += hexdumpRowSize {
+			rows = append(rows, []string{fmt.Sprintf("%08x", offset),
+				fmt.Sprintf("% x", data[offset:min(offset+hexdumpRowSize, end)]), field})
+			field = ""
+		}
+	}
+
+	// The bytes between the ranges, e.g. separators and the end of the array,
+	// are dumped unlabelled.
+	offset := 0
+	for _, r := range ranges {
+		appendRows(offset, r.Start, "")
+		appendRows(r.Start, r.End, r.Field)
+		offset = r.End
+	}
+
+	appendRows(offset, len(data), "")
+	return writeTable(w, textOutput, rows)
+}
+
+// writeParsedMessage writes the name of a parsed message followed by a table
+// of its fields and the unpacked payload passthru payload, if any.
+func writeParsedMessage(w io.Writer, response *wampprotocli.ParseResponse) error {
+	rows := [][]string{{"field", "value"}}
+	for _, field := range wampprotocli.ExplainMessage(response.Message)[1:] {
+		value := parsedValue(field.Value)
+		if requestType, ok := field.Value.(int64); ok && field.Name == "REQUEST.Type" {
+			value = fmt.Sprintf("%s (%s)", value, wampprotocli.MessageName(int(requestType)))
+		}
+
+		rows = append(rows, []string{parsedFieldName(field), value})
+	}
+
+	if response.Payload != nil {
+		rows = append(rows, []string{"Payload.Arguments", parsedValue(response.Payload.Args)})
+		if response.Payload.Kwargs != nil {
+			rows = append(rows, []string{"Payload.ArgumentsKw", parsedValue(response.Payload.Kwargs)})
+		}
+	}
+
+	fmt.Fprintf(w, "%s (%d)\n\n", response.Name, response.Type)
+	return writeTable(w, textOutput, rows)
+}
+
+// writeWireNumbers writes the wire type and value of every number of a
+// parsed message.
+func writeWireNumbers(w io.Writer, numbers []wampprotocli.WireNumber) error {
+	rows := [][]string{{"path", "wire type", "value"}}
+	for _, number := range numbers {
+		rows = append(rows, []string{number.Path, number.WireType, number.Value})
+	}
+
+	return writeTable(w, textOutput, rows)
+}
+
+// writeParsedRecord writes the name of a parsed message followed by its
+// fields as NAME=VALUE on a single line.
+func writeParsedRecord(w io.Writer, response *wampprotocli.ParseResponse) error {
+	record := []string{response.Name}
+	for _, field := range wampprotocli.ExplainMessage(response.Message)[1:] {
+		record = append(record, parsedFieldName(field)+"="+parsedValue(field.Value))
+	}
+
+	if response.Payload != nil {
+		record = append(record, "Payload.Arguments="+parsedValue(response.Payload.Args))
+		if response.Payload.Kwargs != nil {
+			record = append(record, "Payload.ArgumentsKw="+parsedValue(response.Payload.Kwargs))
+		}
+	}
+
+	_, err := fmt.Fprintln(w, singleLine(strings.Join(record, " ")))
+	return err
+}
+
+// parsedFieldName returns the spec name of a field, or its position in the
+// message if the spec doesn't define it.
+func parsedFieldName(field wampprotocli.ExplainedField) string {
+	if field.Name == "unknown" {
+		return fmt.Sprintf("[%d]", field.Position)
+	}
+
+	return field.Name
+}
+
+// parsedValue returns value as JSON, with strings unquoted.
+func parsedValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(data)
+}
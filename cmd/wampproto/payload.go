@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerPayload(c *cli, cmd *kingpin.CmdClause) {
+	serializer := cmd.Flag("serializer", "Serializer of the payload.").Short('s').
+		Default(wampprotocli.CBORSerializer).Enum(wampprotocli.SerializerNames()...)
+	keyFlags := addCryptoboxFlags(cmd, "")
+	encoding := cmd.Flag("output", "Encoding of binary output.").Short('o').
+		Default(wampprotocli.HexOutput).Enum(wampprotocli.HexOutput, wampprotocli.Base64Output)
+
+	printBytes := func(e *env, data []byte) error {
+		encoded, err := wampprotocli.EncodeBytes(data, *encoding)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(e.stdout, encoded)
+		return nil
+	}
+
+	// Missing keys are reported by the operations requiring them.
+	keys := func() (*wampprotocli.CryptoboxKeys, error) {
+		keys, err := keyFlags.keys()
+		if keys == nil {
+			keys = &wampprotocli.CryptoboxKeys{}
+		}
+
+		return keys, err
+	}
+
+	encryptCmd := cmd.Command("encrypt", "Serialize a payload as {\"args\": [...], \"kwargs\": {...}} and "+
+		"encrypt it end-to-end with "+wampprotocli.CryptoboxCipher+".")
+	arguments := addArgumentFlags(encryptCmd)
+	c.handle(encryptCmd, func(e *env) error {
+		cryptoboxKeys, err := keys()
+		defer cryptoboxKeys.Wipe()
+		if err != nil {
+			return err
+		}
+
+		data, err := wampprotocli.SerializePayload(*serializer, arguments.payload())
+		if err != nil {
+			return err
+		}
+
+		ciphertext, err := wampprotocli.EncryptPayload(data, *cryptoboxKeys)
+		if err != nil {
+			return err
+		}
+
+		return printBytes(e, ciphertext)
+	})
+
+	decryptCmd := cmd.Command("decrypt", "Decrypt a payload encrypted with payload encrypt and print it as JSON.")
+	input := decryptCmd.Arg("ciphertext", "Hex or base64 encoded ciphertext.").Required().String()
+	c.handle(decryptCmd, func(e *env) error {
+		cryptoboxKeys, err := keys()
+		defer cryptoboxKeys.Wipe()
+		if err != nil {
+			return err
+		}
+
+		ciphertext, err := wampprotocli.DecodeBytes(*input)
+		if err != nil {
+			return err
+		}
+
+		data, err := wampprotocli.DecryptPayload(ciphertext, *cryptoboxKeys)
+		if err != nil {
+			return err
+		}
+
+		payload, err := wampprotocli.DeserializePayload(*serializer, data)
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(e.stdout).Encode(payload)
+	})
+
+	sealCmd := cmd.Command("seal", "Seal serialized bytes anonymously for the recipient with --public-key, "+
+		"using a sealed box.")
+	plaintext := sealCmd.Arg("data", "Hex or base64 encoded bytes to seal.").Required().String()
+	c.handle(sealCmd, func(e *env) error {
+		recipientKeys, err := keys()
+		defer recipientKeys.Wipe()
+		if err != nil {
+			return err
+		}
+
+		data, err := wampprotocli.DecodeBytes(*plaintext)
+		if err != nil {
+			return err
+		}
+
+		ciphertext, err := wampprotocli.SealPayload(data, recipientKeys.PeerPublicKey)
+		if err != nil {
+			return err
+		}
+
+		return printBytes(e, ciphertext)
+	})
+
+	openCmd := cmd.Command("open", "Open bytes sealed with payload seal using the recipient's --private-key.")
+	sealed := openCmd.Arg("ciphertext", "Hex or base64 encoded sealed bytes.").Required().String()
+	c.handle(openCmd, func(e *env) error {
+		recipientKeys, err := keys()
+		defer recipientKeys.Wipe()
+		if err != nil {
+			return err
+		}
+
+		ciphertext, err := wampprotocli.DecodeBytes(*sealed)
+		if err != nil {
+			return err
+		}
+
+		data, err := wampprotocli.OpenPayload(ciphertext, recipientKeys.PrivateKey)
+		if err != nil {
+			return err
+		}
+
+		return printBytes(e, data)
+	})
+}
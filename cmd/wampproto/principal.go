@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerAuthExportPrincipal(c *cli, cmd *kingpin.CmdClause) {
+	exportCmd := cmd.Command("export-principal", "Print the router configuration that authenticates a "+
+		"principal, e.g. to set up the router of an interop test.")
+	format := exportCmd.Flag("format", "Router configuration format: the auth section of a Crossbar.io "+
+		"transport or a Go key store for nexus.").Default(wampprotocli.CrossbarFormat).
+		Enum(wampprotocli.PrincipalFormats()...)
+	method := exportCmd.Flag("method", "Authentication method.").Default(wampprotocli.CryptosignAuth).
+		Enum(wampprotocli.PrincipalMethods()...)
+	authID := exportCmd.Flag("authid", "Authid of the principal.").Required().String()
+	realm := exportCmd.Flag("realm", "Realm the principal joins.").Default("realm1").String()
+	role := exportCmd.Flag("role", "Role the router assigns to the principal.").Default("anonymous").String()
+	publicKey := exportCmd.Flag("public-key", "Hex encoded cryptosign public key.").PlaceHolder("HEX").String()
+	privateKey := exportCmd.Flag("private-key", "Hex encoded cryptosign private key to derive the public key "+
+		"from, instead of --public-key.").PlaceHolder("HEX").String()
+	secret := exportCmd.Flag("secret", "Ticket of ticket or secret of wampcra principals.").String()
+	c.handle(exportCmd, func(e *env) error {
+		principal := wampprotocli.Principal{
+			AuthID: *authID,
+			Realm:  *realm,
+			Role:   *role,
+			Method: *method,
+			Secret: *secret,
+		}
+
+		if *method == wampprotocli.CryptosignAuth {
+			key, err := principalPublicKey(*publicKey, *privateKey)
+			if err != nil {
+				return err
+			}
+
+			principal.PublicKey = key
+		}
+
+		config, err := wampprotocli.ExportPrincipal(principal, *format)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(e.stdout, strings.TrimSuffix(string(config), "\n"))
+		return nil
+	})
+}
+
+// principalPublicKey returns the hex encoded public key given directly or
+// derived from the private key.
+func principalPublicKey(publicKey, privateKey string) (string, error) {
+	if (publicKey == "") == (privateKey == "") {
+		return "", errors.New("either --public-key or --private-key is required for cryptosign")
+	}
+
+	if publicKey != "" {
+		return publicKey, nil
+	}
+
+	keyPair, err := cryptosignKeyPair(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	defer keyPair.Wipe()
+	return hex.EncodeToString(keyPair.PublicKey), nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strings"
+)
+
+const (
+	cpuProfile = "cpu"
+	memProfile = "mem"
+)
+
+// parseProfileSpec parses a specification like "cpu=cpu.out,mem=mem.out"
+// into the profile kinds and the files they are written to.
+func parseProfileSpec(spec string) (map[string]string, error) {
+	profiles := make(map[string]string)
+	for _, item := range strings.Split(spec, ",") {
+		kind, path, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid profile %q, must be of the form kind=file", item)
+		}
+
+		if kind != cpuProfile && kind != memProfile {
+			return nil, fmt.Errorf("unknown profile kind %q, must be %s or %s", kind, cpuProfile, memProfile)
+		}
+
+		profiles[kind] = path
+	}
+
+	return profiles, nil
+}
+
+// startProfiling starts the profiles described by spec. The returned function
+// stops CPU profiling and writes the memory profile; it must always be called.
+func startProfiling(spec string) (func() error, error) {
+	profiles, err := parseProfileSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var cpuFile *os.File
+	if path, ok := profiles[cpuProfile]; ok {
+		cpuFile, err = os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cpu profile: %w", err)
+		}
+
+		if err = runtimepprof.StartCPUProfile(cpuFile); err != nil {
+			_ = cpuFile.Close()
+			return nil, fmt.Errorf("failed to start cpu profile: %w", err)
+		}
+	}
+
+	return func() error {
+		var errs []error
+		if cpuFile != nil {
+			runtimepprof.StopCPUProfile()
+			errs = append(errs, cpuFile.Close())
+		}
+
+		if path, ok := profiles[memProfile]; ok {
+			errs = append(errs, writeHeapProfile(path))
+		}
+
+		return errors.Join(errs...)
+	}, nil
+}
+
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create memory profile: %w", err)
+	}
+
+	// Collect garbage first so that the profile reflects live memory.
+	runtime.GC()
+	if err = runtimepprof.WriteHeapProfile(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write memory profile: %w", err)
+	}
+
+	return file.Close()
+}
+
+// withPprof serves the pprof endpoints under /debug/pprof/ next to handler.
+func withPprof(handler http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", handler)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return mux
+}
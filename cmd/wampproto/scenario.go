@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+// scenarioLine is a serialized scenario step, printed as one line of NDJSON.
+type scenarioLine struct {
+	wampprotocli.ScenarioStep
+	Name string `json:"name"`
+	Data string `json:"data"`
+}
+
+// writeScenario serializes every step and prints it as NDJSON, in order.
+func writeScenario(e *env, flags *encodeFlags, steps []wampprotocli.ScenarioStep) error {
+	encoder := json.NewEncoder(e.stdout)
+	for _, step := range steps {
+		data, err := flags.serialize(e, step.Message)
+		if err != nil {
+			return err
+		}
+
+		messageType, _ := step.Message[0].(int)
+		line := scenarioLine{ScenarioStep: step, Name: wampprotocli.MessageName(messageType), Data: data}
+		if err = encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func registerScenario(c *cli, cmd *kingpin.CmdClause) {
+	flags := addEncodeFlags(cmd)
+
+	progressiveResultsCmd := cmd.Command("progressive-results", "Emit the RESULT messages of a call with "+
+		"progressive call results: progressive results followed by the final one.")
+	requestID := progressiveResultsCmd.Arg("request-id", "Request ID of the CALL.").Required().Int64()
+	count := progressiveResultsCmd.Flag("count", "Number of progressive results before the final one.").
+		Default("3").Int()
+	c.handle(progressiveResultsCmd, func(e *env) error {
+		steps, err := wampprotocli.ProgressiveResults(*requestID, *count)
+		if err != nil {
+			return err
+		}
+
+		return writeScenario(e, flags, steps)
+	})
+
+	progressiveInvocationsCmd := cmd.Command("progressive-invocations", "Emit the CALL and INVOCATION "+
+		"messages of a call with progressive call invocations: progressive calls, each followed by the "+
+		"invocation the dealer forwards it as, and the final call and invocation.")
+	callRequestID := progressiveInvocationsCmd.Arg("request-id", "Request ID of the CALL.").Required().Int64()
+	procedure := progressiveInvocationsCmd.Arg("procedure", "Procedure to call.").Required().String()
+	invocationID := progressiveInvocationsCmd.Flag("invocation-id", "Request ID of the INVOCATION.").
+		Default("1").Int64()
+	registrationID := progressiveInvocationsCmd.Flag("registration-id", "Registration ID of the procedure.").
+		Default("1").Int64()
+	invocationCount := progressiveInvocationsCmd.Flag("count", "Number of progressive calls before the final "+
+		"one.").Default("3").Int()
+	c.handle(progressiveInvocationsCmd, func(e *env) error {
+		steps, err := wampprotocli.ProgressiveInvocations(wampprotocli.CallIDs{
+			RequestID:      *callRequestID,
+			InvocationID:   *invocationID,
+			RegistrationID: *registrationID,
+			Procedure:      *procedure,
+		}, *invocationCount)
+		if err != nil {
+			return err
+		}
+
+		return writeScenario(e, flags, steps)
+	})
+
+	cancelCallCmd := cmd.Command("cancel-call", "Emit the messages of a call canceled by its caller while "+
+		"the callee executes it, up to the ERROR the caller receives.")
+	cancelRequestID := cancelCallCmd.Arg("request-id", "Request ID of the CALL.").Required().Int64()
+	cancelProcedure := cancelCallCmd.Arg("procedure", "Procedure to call.").Required().String()
+	cancelInvocationID := cancelCallCmd.Flag("invocation-id", "Request ID of the INVOCATION.").
+		Default("1").Int64()
+	cancelRegistrationID := cancelCallCmd.Flag("registration-id", "Registration ID of the procedure.").
+		Default("1").Int64()
+	mode := cancelCallCmd.Flag("mode", "Cancellation mode of the CANCEL.").
+		Default(wampprotocli.CancelKill).Enum(wampprotocli.CancelModes()...)
+	c.handle(cancelCallCmd, func(e *env) error {
+		steps, err := wampprotocli.CancelCall(wampprotocli.CallIDs{
+			RequestID:      *cancelRequestID,
+			InvocationID:   *cancelInvocationID,
+			RegistrationID: *cancelRegistrationID,
+			Procedure:      *cancelProcedure,
+		}, *mode)
+		if err != nil {
+			return err
+		}
+
+		return writeScenario(e, flags, steps)
+	})
+
+	callTimeoutCmd := cmd.Command("call-timeout", "Emit the messages of a call with a timeout as a dealer "+
+		"enforcing it produces them: the RESULT if the callee responds in time, otherwise the INTERRUPT and "+
+		"the wamp.error.timeout ERROR.")
+	timeoutRequestID := callTimeoutCmd.Arg("request-id", "Request ID of the CALL.").Required().Int64()
+	timeoutProcedure := callTimeoutCmd.Arg("procedure", "Procedure to call.").Required().String()
+	timeoutInvocationID := callTimeoutCmd.Flag("invocation-id", "Request ID of the INVOCATION.").
+		Default("1").Int64()
+	timeoutRegistrationID := callTimeoutCmd.Flag("registration-id", "Registration ID of the procedure.").
+		Default("1").Int64()
+	timeout := callTimeoutCmd.Flag("timeout", "Timeout of the call in milliseconds (0 disables it).").
+		PlaceHolder("MS").Required().Int64()
+	duration := callTimeoutCmd.Flag("duration", "Milliseconds the callee takes to respond.").
+		PlaceHolder("MS").Required().Int64()
+	c.handle(callTimeoutCmd, func(e *env) error {
+		steps, err := wampprotocli.CallTimeout(wampprotocli.CallIDs{
+			RequestID:      *timeoutRequestID,
+			InvocationID:   *timeoutInvocationID,
+			RegistrationID: *timeoutRegistrationID,
+			Procedure:      *timeoutProcedure,
+		}, *timeout, *duration)
+		if err != nil {
+			return err
+		}
+
+		return writeScenario(e, flags, steps)
+	})
+
+	retainedEventCmd := cmd.Command("retained-event", "Emit the messages of an event the broker retains and "+
+		"delivers to a later subscriber that asks for it with get_retained.")
+	topic := retainedEventCmd.Arg("topic", "Topic of the event.").Required().String()
+	publishRequestID := retainedEventCmd.Flag("publish-request-id", "Request ID of the PUBLISH.").
+		Default("1").Int64()
+	subscribeRequestID := retainedEventCmd.Flag("subscribe-request-id", "Request ID of the SUBSCRIBE.").
+		Default("1").Int64()
+	subscriptionID := retainedEventCmd.Flag("subscription-id", "Subscription ID of the topic.").
+		Default("1").Int64()
+	publicationID := retainedEventCmd.Flag("publication-id", "Publication ID of the event.").
+		Default("1").Int64()
+	c.handle(retainedEventCmd, func(e *env) error {
+		return writeScenario(e, flags, wampprotocli.RetainedEvent(wampprotocli.TopicIDs{
+			PublishRequestID:   *publishRequestID,
+			SubscribeRequestID: *subscribeRequestID,
+			SubscriptionID:     *subscriptionID,
+			PublicationID:      *publicationID,
+			Topic:              *topic,
+		}))
+	})
+
+	sharedRegistrationCmd := cmd.Command("shared-registration", "Emit the messages of calls to a procedure "+
+		"several callees registered, each invocation going to the callee the invocation policy picks.")
+	sharedProcedure := sharedRegistrationCmd.Arg("procedure", "Procedure the callees register.").Required().String()
+	callees := sharedRegistrationCmd.Flag("callee", "Name of a callee, in the order they register, may be "+
+		"repeated.").Required().Strings()
+	invoke := sharedRegistrationCmd.Flag("invoke", "Invocation policy of the registration.").
+		Default(wampprotocli.InvokeSingle).Enum(wampprotocli.InvokePolicies()...)
+	sharedMatch := sharedRegistrationCmd.Flag("match", "Match policy of the registration.").
+		Enum(wampprotocli.MatchPolicies()...)
+	sharedRegistrationID := sharedRegistrationCmd.Flag("registration-id", "Registration ID of the procedure.").
+		Default("1").Int64()
+	calls := sharedRegistrationCmd.Flag("calls", "Number of successive calls.").Default("1").Int()
+	c.handle(sharedRegistrationCmd, func(e *env) error {
+		steps, err := wampprotocli.SharedRegistration(wampprotocli.Registration{
+			ID:        *sharedRegistrationID,
+			Procedure: *sharedProcedure,
+			Match:     *sharedMatch,
+			Invoke:    *invoke,
+			Callees:   *callees,
+		}, *calls)
+		if err != nil {
+			return err
+		}
+
+		return writeScenario(e, flags, steps)
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellRedact(t *testing.T) {
+	for line, expected := range map[string]string{
+		"auth cra sign-challenge x --secret s3cr3t":          "auth cra sign-challenge x --secret '<redacted>'",
+		"auth cra sign-challenge x --secret=s3cr3t":          "auth cra sign-challenge x '--secret=<redacted>'",
+		"auth cra sign-challenge x --derived-key abc":        "auth cra sign-challenge x --derived-key '<redacted>'",
+		"auth ticket authenticate --ticket t0k3n":            "auth ticket authenticate --ticket '<redacted>'",
+		"set private-key 0011":                               "set private-key '<redacted>'",
+		"--set private-key=0011 message call 1 a.b":          "--set 'private-key=<redacted>' message call 1 a.b",
+		"--set serializer=cbor message call 1 a.b":           "--set serializer=cbor message call 1 a.b",
+		"set serializer cbor":                                "set serializer cbor",
+		"auth export-principal --public-key 0011 --authid a": "auth export-principal --public-key 0011 --authid a",
+	} {
+		s := &shell{e: &env{}}
+		if redacted := s.redact(line); redacted != expected {
+			t.Errorf("redact(%q) = %q, expected %q", line, redacted, expected)
+		}
+
+		s.e.showSecrets = true
+		if shown := s.redact(line); shown != line {
+			t.Errorf("redact(%q) with secrets shown = %q", line, shown)
+		}
+	}
+}
+
+// ticketCapture writes a pcap capture of a RawSocket connection to port 8080
+// whose AUTHENTICATE carries ticket.
+func ticketCapture(t *testing.T, ticket string) string {
+	t.Helper()
+
+	message := []byte(`[5,"` + ticket + `",{}]`)
+	stream := []byte{0x7f, 0xf1, 0x00, 0x00, 0x00, 0x00, 0x00, byte(len(message))}
+	stream = append(stream, message...)
+
+	// An IPv4 packet from 10.0.0.1:50000 to 10.0.0.2:8080 carrying stream.
+	packet := make([]byte, 40, 40+len(stream))
+	packet[0], packet[8], packet[9] = 0x45, 64, 6
+	binary.BigEndian.PutUint16(packet[2:4], uint16(40+len(stream)))
+	copy(packet[12:16], []byte{10, 0, 0, 1})
+	copy(packet[16:20], []byte{10, 0, 0, 2})
+	binary.BigEndian.PutUint16(packet[20:22], 50000)
+	binary.BigEndian.PutUint16(packet[22:24], 8080)
+	binary.BigEndian.PutUint32(packet[24:28], 1)
+	packet[32], packet[33] = 0x50, 0x18
+	packet = append(packet, stream...)
+
+	// A little-endian pcap header with the raw IP link type, and one record.
+	capture := make([]byte, 24+16)
+	binary.LittleEndian.PutUint32(capture[0:4], 0xa1b2c3d4)
+	binary.LittleEndian.PutUint16(capture[4:6], 2)
+	binary.LittleEndian.PutUint16(capture[6:8], 4)
+	binary.LittleEndian.PutUint32(capture[16:20], 65535)
+	binary.LittleEndian.PutUint32(capture[20:24], 101)
+	binary.LittleEndian.PutUint32(capture[32:36], uint32(len(packet)))
+	binary.LittleEndian.PutUint32(capture[36:40], uint32(len(packet)))
+	capture = append(capture, packet...)
+
+	path := filepath.Join(t.TempDir(), "ticket.pcap")
+	if err := os.WriteFile(path, capture, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func runCommand(t *testing.T, args []string, stdin string) string {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	if err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr); err != nil {
+		t.Fatalf("%v: %v: %s", args, err, stderr.String())
+	}
+
+	return stdout.String()
+}
+
+func TestCaptureDecodeRedaction(t *testing.T) {
+	const ticket = "t0k3n-of-alice"
+	path := ticketCapture(t, ticket)
+	for _, tc := range []struct {
+		args  []string
+		stdin string
+		shown bool
+	}{
+		{args: []string{"capture", "decode", path, "--port", "8080"}},
+		{args: []string{"--show-secrets", "capture", "decode", path, "--port", "8080"}, shown: true},
+		{args: []string{"batch"}, stdin: "capture decode " + path + " --port 8080\n"},
+		{args: []string{"--show-secrets", "batch"}, stdin: "capture decode " + path + " --port 8080\n", shown: true},
+	} {
+		output := runCommand(t, tc.args, tc.stdin)
+		if !strings.Contains(output, "AUTHENTICATE") {
+			t.Fatalf("%v: no AUTHENTICATE decoded: %s", tc.args, output)
+		}
+
+		if strings.Contains(output, ticket) != tc.shown {
+			t.Errorf("%v: expected the ticket to be shown: %v, got %s", tc.args, tc.shown, output)
+		}
+	}
+}
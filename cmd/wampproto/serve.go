@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+func registerServe(c *cli, cmd *kingpin.CmdClause) {
+	host := cmd.Flag("host", "Address to listen on.").Default("127.0.0.1").String()
+	port := cmd.Flag("port", "Port to listen on.").Default("8080").Uint16()
+	timeout := cmd.Flag("timeout", "Stop serving after this duration (0 serves until interrupted).").
+		Default("0").Duration()
+	enablePprof := cmd.Flag("pprof", "Also serve pprof endpoints under /debug/pprof/.").Bool()
+	maxMessageSize := cmd.Flag("max-message-size", "Reject serialized messages larger than this many bytes "+
+		"unless a request sets max_message_size (0 is unlimited).").Default("0").Int()
+
+	c.handle(cmd, func(e *env) error {
+		ctx := e.ctx
+		if *timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, *timeout)
+			defer cancel()
+		}
+
+		if *maxMessageSize < 0 {
+			return fmt.Errorf("max message size must not be negative, was %d", *maxMessageSize)
+		}
+
+		handler := wampprotocli.NewHTTPHandler(e.logger.Handler(), wampprotocli.HTTPOptions{
+			MaxMessageSize: *maxMessageSize,
+			Strict:         e.strict,
+			OnWarnings: func(warnings []wampprotocli.Warning) {
+				e.warnings.add(warnings...)
+			},
+		})
+		if *enablePprof {
+			handler = withPprof(handler)
+		}
+
+		address := net.JoinHostPort(*host, strconv.Itoa(int(*port)))
+		return serveHTTP(ctx, address, handler, e.logger)
+	})
+}
+
+// serveHTTP serves handler on address until ctx is done and then shuts the
+// server down gracefully.
+func serveHTTP(ctx context.Context, address string, handler http.Handler, logger *slog.Logger) error {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("listening", "url", "http://"+listener.Addr().String())
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Serve(listener) }()
+
+	select {
+	case err = <-errCh:
+		return err
+	case <-ctx.Done():
+		logger.Info("shutting down", "reason", context.Cause(ctx))
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return server.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"golang.org/x/term"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+const shellPrompt = "wampproto> "
+
+// shell is the state of an interactive session: the settings applied to
+// every command that accepts them and the command history.
+type shell struct {
+	e        *env
+	settings map[string]string
+	history  []string
+	// replaying is set while a script is replayed.
+	replaying bool
+	// flags caches the flags of each command, including those of its parents.
+	flags map[string][]*kingpin.FlagModel
+	app   *kingpin.ApplicationModel
+}
+
+func registerShell(c *cli, cmd *kingpin.CmdClause) {
+	historyFile := cmd.Flag("history-file", "File the command history is loaded from and appended to, "+
+		"~/.wampproto/history by default.").PlaceHolder("FILE").String()
+	noHistory := cmd.Flag("no-history", "Keep the history in memory only.").Bool()
+	settings := cmd.Flag("set", "Initial setting, e.g. serializer=cbor, may be repeated.").
+		PlaceHolder("FLAG=VALUE").StringMap()
+
+	c.handle(cmd, func(e *env) error {
+		s := &shell{e: e, settings: map[string]string{}, flags: map[string][]*kingpin.FlagModel{}}
+		for name, value := range *settings {
+			s.settings[name] = value
+		}
+
+		path := *historyFile
+		if *noHistory {
+			path = ""
+		} else if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return fmt.Errorf("cannot locate the history file, use --history-file or --no-history: %w", err)
+			}
+
+			path = filepath.Join(home, ".wampproto", "history")
+		}
+
+		if path != "" {
+			if err := s.loadHistory(path); err != nil {
+				return err
+			}
+		}
+
+		return s.run(path)
+	})
+}
+
+// run reads and executes lines until the input ends or exit is entered. On a
+// terminal lines are edited with completion, otherwise they are read without
+// a prompt so that scripts can be piped in.
+func (s *shell) run(historyFile string) error {
+	if f, ok := s.e.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		return s.runTerminal(f, historyFile)
+	}
+
+	scanner := bufio.NewScanner(s.e.stdin)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxBatchLineSize)
+	for scanner.Scan() {
+		if done, err := s.process(scanner.Text(), historyFile); err != nil || done {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// runTerminal reads lines from the terminal f in raw mode, completing the
+// word at the cursor when tab is pressed.
+func (s *shell) runTerminal(f *os.File, historyFile string) error {
+	fd := int(f.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = term.Restore(fd, state) }()
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{f, s.e.stdout}, shellPrompt)
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		_ = t.SetSize(width, height)
+	}
+
+	t.AutoCompleteCallback = s.complete
+	// Output has to go through the terminal, which translates line endings
+	// for raw mode.
+	s.e.stdout, s.e.stderr = t, t
+	for {
+		line, err := t.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if done, err := s.process(line, historyFile); err != nil || done {
+			return err
+		}
+	}
+}
+
+// process executes line, adding it to the history, and reports whether the
+// shell should exit. Errors of the line are printed; only failing to record
+// the history is returned.
+func (s *shell) process(line, historyFile string) (bool, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return false, nil
+	}
+
+	line, err := s.expandHistory(line)
+	if err != nil {
+		fmt.Fprintf(s.e.stdout, "error: %s\n", err)
+		return false, nil
+	}
+
+	s.history = append(s.history, line)
+	if historyFile != "" {
+		if err = appendHistory(historyFile, s.redact(line)); err != nil {
+			return false, err
+		}
+	}
+
+	done, err := s.execute(line)
+	if err != nil {
+		fmt.Fprintf(s.e.stdout, "error: %s\n", err)
+	}
+
+	return done, nil
+}
+
+// execute runs a built-in or a command line and reports whether the shell
+// should exit.
+func (s *shell) execute(line string) (bool, error) {
+	args, err := splitArgs(line)
+	if err != nil || len(args) == 0 {
+		return false, err
+	}
+
+	switch args[0] {
+	case "exit", "quit":
+		return true, nil
+	case "set":
+		if len(args) != 3 {
+			return false, errors.New("usage: set FLAG VALUE")
+		}
+
+		s.settings[strings.TrimPrefix(args[1], "--")] = args[2]
+	case "unset":
+		if len(args) != 2 {
+			return false, errors.New("usage: unset FLAG")
+		}
+
+		delete(s.settings, strings.TrimPrefix(args[1], "--"))
+	case "show":
+		names := make([]string, 0, len(s.settings))
+		for name := range s.settings {
+			names = append(names, name)
+		}
+
+		slices.Sort(names)
+		for _, name := range names {
+			value := s.settings[name]
+			if !s.e.showSecrets && wampprotocli.IsSecretName(name) {
+				value = wampprotocli.RedactedSecret
+			}
+
+			fmt.Fprintf(s.e.stdout, "%s=%s\n", name, value)
+		}
+	case "history":
+		for i, line := range s.history {
+			fmt.Fprintf(s.e.stdout, "%5d  %s\n", i+1, s.redact(line))
+		}
+	case "replay":
+		if len(args) != 2 {
+			return false, errors.New("usage: replay FILE")
+		}
+
+		return s.replay(args[1])
+	case "shell":
+		return false, errors.New("shells cannot be nested")
+	default:
+		args = s.applySettings(args)
+		if s.e.showSecrets {
+			args = append([]string{"--show-secrets"}, args...)
+		}
+
+		return false, execute(s.e.ctx, args, strings.NewReader(""), s.e.stdout, s.e.stderr, s.e.warnings)
+	}
+
+	return false, nil
+}
+
+// replay executes the lines of the script at path, e.g. a saved history, as
+// if they were entered, echoing each of them. It fails if any line does.
+func (s *shell) replay(path string) (bool, error) {
+	if s.replaying {
+		return false, errors.New("replays cannot be nested")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	s.replaying = true
+	defer func() { s.replaying = false }()
+
+	failed, total := 0, 0
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		total++
+		fmt.Fprintf(s.e.stdout, "%s%s\n", shellPrompt, s.redact(line))
+		done, err := s.execute(line)
+		if err != nil {
+			failed++
+			fmt.Fprintf(s.e.stdout, "error: %s\n", err)
+		} else if done {
+			return true, nil
+		}
+	}
+
+	if failed > 0 {
+		return false, fmt.Errorf("%d of %d replayed lines failed", failed, total)
+	}
+
+	return false, nil
+}
+
+// expandHistory replaces !! with the previous line and !N with the N-th line
+// of the history.
+func (s *shell) expandHistory(line string) (string, error) {
+	if !strings.HasPrefix(line, "!") {
+		return line, nil
+	}
+
+	if line == "!!" {
+		if len(s.history) == 0 {
+			return "", errors.New("history is empty")
+		}
+
+		return s.history[len(s.history)-1], nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(s.history) {
+		return "", fmt.Errorf("no history entry %s", line[1:])
+	}
+
+	return s.history[n-1], nil
+}
+
+// redact returns line with the values of secret flags and settings replaced,
+// unless secrets are shown. The history file and listing are redacted, while
+// !! and !N still repeat the line as entered.
+func (s *shell) redact(line string) string {
+	if s.e.showSecrets {
+		return line
+	}
+
+	args, err := splitArgs(line)
+	if err != nil {
+		return line
+	}
+
+	redacted := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case i == 0 && arg == "set" && len(args) == 3 && wampprotocli.IsSecretName(args[1]):
+			args[2], redacted = wampprotocli.RedactedSecret, true
+			i = len(args)
+		case arg == "--set" && i+1 < len(args):
+			if name, _, ok := strings.Cut(args[i+1], "="); ok && wampprotocli.IsSecretName(name) {
+				args[i+1], redacted = name+"="+wampprotocli.RedactedSecret, true
+			}
+
+			i++
+		case !strings.HasPrefix(arg, "--") || !wampprotocli.IsSecretName(strings.TrimPrefix(arg, "--")):
+		case strings.Contains(arg, "="):
+			name, _, _ := strings.Cut(arg, "=")
+			args[i], redacted = name+"="+wampprotocli.RedactedSecret, true
+		case i+1 < len(args):
+			args[i+1], redacted = wampprotocli.RedactedSecret, true
+			i++
+		}
+	}
+
+	if !redacted {
+		return line
+	}
+
+	return quoteArgs(args)
+}
+
+// applySettings adds a flag for every setting the command of args accepts and
+// doesn't set itself.
+func (s *shell) applySettings(args []string) []string {
+	if len(s.settings) == 0 {
+		return args
+	}
+
+	accepted := s.commandFlags(args)
+	names := make([]string, 0, len(s.settings))
+	for name := range s.settings {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	for _, name := range names {
+		index := slices.IndexFunc(accepted, func(flag *kingpin.FlagModel) bool { return flag.Name == name })
+		if index < 0 || slices.ContainsFunc(args, func(arg string) bool {
+			return arg == "--"+name || strings.HasPrefix(arg, "--"+name+"=") ||
+				(accepted[index].Short != 0 && strings.HasPrefix(arg, "-"+string(accepted[index].Short)))
+		}) {
+			continue
+		}
+
+		args = append(args, fmt.Sprintf("--%s=%s", name, s.settings[name]))
+	}
+
+	return args
+}
+
+// commandFlags returns the flags of the command args invoke, including those
+// of its parent commands and the global ones.
+func (s *shell) commandFlags(args []string) []*kingpin.FlagModel {
+	var words []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			words = append(words, arg)
+		}
+	}
+
+	key := strings.Join(words, " ")
+	if flags, ok := s.flags[key]; ok {
+		return flags
+	}
+
+	flags := slices.Clone(s.model().Flags)
+	commands := s.model().Commands
+	for _, word := range words {
+		index := slices.IndexFunc(commands, func(command *kingpin.CmdModel) bool { return command.Name == word })
+		if index < 0 {
+			break
+		}
+
+		flags = append(flags, commands[index].Flags...)
+
+		commands = commands[index].Commands
+	}
+
+	s.flags[key] = flags
+	return flags
+}
+
+// model returns the model of the application with all commands.
+func (s *shell) model() *kingpin.ApplicationModel {
+	if s.app == nil {
+		c := newCLI(io.Discard, io.Discard, func(int) {})
+		for _, command := range topLevelCommands() {
+			command.register(c, c.app.Command(command.name, command.help))
+		}
+
+		s.app = c.app.Model()
+	}
+
+	return s.app
+}
+
+// loadHistory reads the history from path, which may not exist yet.
+func (s *shell) loadHistory(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			s.history = append(s.history, line)
+		}
+	}
+
+	return nil
+}
+
+func appendHistory(path, line string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(f, line)
+	return errors.Join(err, f.Close())
+}
+
+// isTerminal reports whether r is a terminal.
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
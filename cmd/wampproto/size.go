@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerSizeMessage(c *cli, cmd *kingpin.CmdClause) {
+	sizeCmd := cmd.Command("size", "Serialize a message with every serializer and compare the sizes. The "+
+		"message is a JSON array, e.g. '[48, 1, {}, \"com.example.add\"]', or the arguments of a message "+
+		"command, e.g. 'call 1 com.example.add --arg 2', which may also follow --.")
+	message := sizeCmd.Arg("message", "Message as a JSON array or message command.").Required().Strings()
+	noValidate := sizeCmd.Flag("no-validate", "Skip spec validation, e.g. to compare invalid messages of "+
+		"negative tests.").Bool()
+	output := sizeCmd.Flag("output", "Output format.").Default(textOutput).Enum(textOutput, markdownOutput,
+		jsonOutput)
+	c.handle(sizeCmd, func(e *env) error {
+		raw, err := resolveMessage(e, *noValidate, *message)
+		if err != nil {
+			return err
+		}
+
+		msg, err := wampprotocli.MessageFromRaw(raw)
+		if err != nil {
+			return err
+		}
+
+		sizes, err := wampprotocli.MessageSizes(msg)
+		if err != nil {
+			return err
+		}
+
+		return writeMessageSizes(e.stdout, *output, wampprotocli.MessageName(msg.Type()), sizes)
+	})
+}
+
+// resolvingCommands are the message commands that don't build a message from
+// their arguments.
+//
+//nolint:gochecknoglobals
+var resolvingCommands = []string{"size", "export-code", "wizard", "digest", "parse", "convert", "validate", "diff",
+	"expect"}
+
+// resolveMessage returns the positional message args describe as a JSON
+// array or message command, validating it unless validation is disabled.
+// Message commands build it with CBOR, which keeps binary payloads and the
+// types of numbers intact.
+func resolveMessage(e *env, noValidate bool, args []string) ([]any, error) {
+	if len(args) == 1 && strings.HasPrefix(strings.TrimSpace(args[0]), "[") {
+		var raw []any
+		if err := wampprotocli.DecodeRequest(strings.NewReader(args[0]), &raw); err != nil {
+			return nil, fmt.Errorf("invalid message: %w", err)
+		}
+
+		response, err := wampprotocli.Serialize(&wampprotocli.SerializeRequest{
+			Serializer: wampprotocli.CBORSerializer,
+			Message:    raw,
+			Encoding:   wampprotocli.Base64Output,
+			NoValidate: noValidate,
+			Strict:     e.strict,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		e.warnings.add(response.Warnings...)
+		return decodeResolvedMessage(response.Data)
+	}
+
+	if len(args) == 1 {
+		var err error
+		if args, err = splitArgs(args[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) == 0 || slices.Contains(resolvingCommands, args[0]) {
+		return nil, fmt.Errorf("%q is not a message command", strings.Join(args, " "))
+	}
+
+	args = append([]string{"message"}, args...)
+	args = append(args, "--serializer", wampprotocli.CBORSerializer, "--output", wampprotocli.Base64Output)
+	if noValidate {
+		args = append(args, "--no-validate")
+	}
+
+	if e.strict {
+		args = append([]string{"--strict"}, args...)
+	}
+
+	var output bytes.Buffer
+	if err := execute(e.ctx, args, strings.NewReader(""), &output, e.stderr, e.warnings); err != nil {
+		return nil, err
+	}
+
+	return decodeResolvedMessage(strings.TrimSpace(output.String()))
+}
+
+func decodeResolvedMessage(encoded string) ([]any, error) {
+	data, err := wampprotocli.DecodeBytes(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return wampprotocli.DeserializeRaw(wampprotocli.CBORSerializer, data)
+}
+
+func writeMessageSizes(w io.Writer, format, name string, sizes []wampprotocli.MessageSize) error {
+	if format == jsonOutput {
+		return json.NewEncoder(w).Encode(map[string]any{"message": name, "sizes": sizes})
+	}
+
+	rows := [][]string{{"serializer", "bytes", "overhead"}}
+	for _, size := range sizes {
+		rows = append(rows, []string{size.Serializer, strconv.Itoa(size.Size),
+			fmt.Sprintf("+%.1f%%", size.Overhead*100)})
+	}
+
+	fmt.Fprintf(w, "%s\n\n", name)
+	return writeTable(w, format, rows)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-go/messages"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerAuthTicket(c *cli, cmd *kingpin.CmdClause) {
+	ticketCmd := cmd.Command("ticket", "Messages of ticket authentication, where the client sends a ticket "+
+		"the router checks, e.g. a password or token.")
+
+	challengeCmd := ticketCmd.Command("challenge", "Build the CHALLENGE a router sends to request the ticket.")
+	challengeExtra := challengeCmd.Flag("extra", "Extra value as KEY=VALUE, may be repeated. Values are "+
+		"decoded like --arg.").PlaceHolder("KEY=VALUE").StringMap()
+	challengeFlags := addSerializeFlags(challengeCmd)
+	c.handle(challengeCmd, func(e *env) error {
+		return printMessage(e, challengeFlags, []any{messages.MessageTypeChallenge, wampprotocli.TicketAuth,
+			parseValues(*challengeExtra)})
+	})
+
+	authenticateCmd := ticketCmd.Command("authenticate", "Build the AUTHENTICATE carrying the ticket.")
+	// A flag rather than an argument, so that the shell history redacts it.
+	ticket := authenticateCmd.Flag("ticket", "Ticket of the principal.").Required().String()
+	authenticateExtra := authenticateCmd.Flag("extra", "Extra value as KEY=VALUE, may be repeated. Values "+
+		"are decoded like --arg.").PlaceHolder("KEY=VALUE").StringMap()
+	authenticateFlags := addSerializeFlags(authenticateCmd)
+	c.handle(authenticateCmd, func(e *env) error {
+		if *ticket == "" {
+			return errors.New("ticket must not be empty")
+		}
+
+		return printMessage(e, authenticateFlags, []any{messages.MessageTypeAuthenticate, *ticket,
+			parseValues(*authenticateExtra)})
+	})
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerURI(c *cli, cmd *kingpin.CmdClause) {
+	matchCmd := cmd.Command("match", "Report whether a URI matches a subscription or registration pattern, "+
+		"printing true or false.")
+	pattern := matchCmd.Arg("pattern", "Topic or procedure pattern.").Required().String()
+	uri := matchCmd.Arg("uri", "Topic or procedure URI to match against the pattern.").Required().String()
+	policy := matchCmd.Flag("policy", "Match policy of the pattern.").
+		Default(wampprotocli.MatchExact).Enum(wampprotocli.MatchPolicies()...)
+
+	c.handle(matchCmd, func(e *env) error {
+		matched, err := wampprotocli.MatchURI(*pattern, *uri, *policy)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(e.stdout, matched)
+		return nil
+	})
+
+	matchAllCmd := cmd.Command("match-all", "Print the subscriptions that receive an event published to a topic "+
+		"as a JSON array, as a reference for broker implementations.")
+	subscriptionsFile := matchAllCmd.Flag("subscriptions", "JSON file with an array of subscriptions, "+
+		`e.g. [{"id": 1, "topic": "com.app", "match": "prefix", "subscribers": ["alice"]}].`).
+		PlaceHolder("FILE").Required().String()
+	topic := matchAllCmd.Arg("topic", "Topic of the event.").Required().String()
+
+	c.handle(matchAllCmd, func(e *env) error {
+		var subscriptions []wampprotocli.Subscription
+		if err := readJSONFile(*subscriptionsFile, &subscriptions); err != nil {
+			return err
+		}
+
+		matching, err := wampprotocli.MatchSubscriptions(subscriptions, *topic)
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(e.stdout).Encode(matching)
+	})
+
+	dispatchCmd := cmd.Command("dispatch", "Print the registration and callees a dealer routes calls of a "+
+		"procedure to as JSON, as a reference for dealer implementations.")
+	registrationsFile := dispatchCmd.Flag("registrations", "JSON file with an array of registrations, e.g. "+
+		`[{"id": 1, "procedure": "com.app", "match": "prefix", "invoke": "roundrobin", "callees": ["a", "b"]}].`).
+		PlaceHolder("FILE").Required().String()
+	calls := dispatchCmd.Flag("calls", "Number of successive calls to route.").Default("1").Int()
+	procedure := dispatchCmd.Arg("procedure", "Procedure of the call.").Required().String()
+
+	c.handle(dispatchCmd, func(e *env) error {
+		if *calls < 1 {
+			return fmt.Errorf("calls must be at least 1, was %d", *calls)
+		}
+
+		var registrations []wampprotocli.Registration
+		if err := readJSONFile(*registrationsFile, &registrations); err != nil {
+			return err
+		}
+
+		registration, err := wampprotocli.MatchRegistration(registrations, *procedure)
+		if err != nil {
+			return err
+		} else if registration == nil {
+			return fmt.Errorf("no registration matches %s, a dealer responds with wamp.error.no_such_procedure",
+				*procedure)
+		}
+
+		callees, err := wampprotocli.SelectCallees(*registration, *calls)
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(e.stdout).Encode(dispatch{Registration: registration, Callees: callees})
+	})
+
+	splitCmd := cmd.Command("split", "Print the components of a URI, its depth and the match policies it can "+
+		"be registered or subscribed with as JSON.")
+	splitURI := splitCmd.Arg("uri", "URI or pattern to split.").Required().String()
+	c.handle(splitCmd, func(e *env) error {
+		return json.NewEncoder(e.stdout).Encode(wampprotocli.SplitURI(*splitURI))
+	})
+
+	realmCmd := cmd.Command("realm", "Validate and generate realm names.")
+
+	realmValidateCmd := realmCmd.Command("validate", "Check a realm name against the WAMP URI rules.")
+	realm := realmValidateCmd.Arg("name", "Realm name to validate.").Required().String()
+	realmChars := addURICharFlags(realmValidateCmd)
+	c.handle(realmValidateCmd, func(e *env) error {
+		strict, err := realmChars.strict()
+		if err != nil {
+			return err
+		}
+
+		if err = wampprotocli.ValidateRealm(*realm, strict); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(e.stdout, "valid")
+		return nil
+	})
+
+	realmRandomCmd := realmCmd.Command("random", "Generate a random realm name, e.g. for an isolated realm per "+
+		"CI job.")
+	prefix := realmRandomCmd.Flag("prefix", "URI the realm is generated below.").Default("test").String()
+	c.handle(realmRandomCmd, func(e *env) error {
+		name, err := wampprotocli.RandomRealm(*prefix)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(e.stdout, name)
+		return nil
+	})
+}
+
+// dispatch is the output of uri dispatch.
+type dispatch struct {
+	Registration *wampprotocli.Registration `json:"registration"`
+	Callees      []string                   `json:"callees"`
+}
+
+// readJSONFile decodes the JSON document in path into v, rejecting unknown
+// fields so that typos don't go unnoticed.
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(v); err != nil {
+		return fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+func registerValidate(c *cli, cmd *kingpin.CmdClause) {
+	uriCmd := cmd.Command("uri", "Check a URI against the WAMP URI rules.")
+	uri := uriCmd.Arg("uri", "URI to validate.").Required().String()
+	chars := addURICharFlags(uriCmd)
+	wildcard := uriCmd.Flag("wildcard", "Validate a wildcard pattern, which may contain empty components.").Bool()
+	allowReserved := uriCmd.Flag("allow-reserved", "Accept URIs reserved for the WAMP protocol under wamp., "+
+		"which are otherwise reported.").Bool()
+
+	c.handle(uriCmd, func(e *env) error {
+		strict, err := chars.strict()
+		if err != nil {
+			return err
+		}
+
+		if err = wampprotocli.ValidateURI(*uri, strict, *wildcard); err != nil {
+			return err
+		}
+
+		if wampprotocli.IsReservedURI(*uri) && !*allowReserved {
+			message := fmt.Sprintf("URI %q is reserved for the WAMP protocol", *uri)
+			if e.strict {
+				return errors.New(message)
+			}
+
+			e.warnings.add(wampprotocli.Warning{Category: wampprotocli.CategoryReserved, Message: message})
+		}
+
+		fmt.Fprintln(e.stdout, "valid")
+		return nil
+	})
+}
+
+// uriCharFlags select the characters URI components may contain.
+type uriCharFlags struct {
+	strictChars *bool
+	loose       *bool
+}
+
+func addURICharFlags(cmd *kingpin.CmdClause) *uriCharFlags {
+	return &uriCharFlags{
+		strictChars: cmd.Flag("strict-chars", "Only allow lowercase letters, digits and _ in components.").Bool(),
+		loose: cmd.Flag("loose", "Allow any character except whitespace and # in components "+
+			"(default).").Bool(),
+	}
+}
+
+// strict reports whether the strict rule set was selected.
+func (f *uriCharFlags) strict() (bool, error) {
+	if *f.strictChars && *f.loose {
+		return false, errors.New("--strict-chars and --loose are mutually exclusive")
+	}
+
+	return *f.strictChars, nil
+}
+
+func registerValidateMessage(c *cli, cmd *kingpin.CmdClause) {
+	validateCmd := cmd.Command("validate", "Validate a message serialized with --serializer strictly against "+
+		"the WAMP spec, reporting every violation and failing if there is any.")
+	data := validateCmd.Arg("data", "Hex or base64 encoded serialized message.").Required().String()
+	serializer := addSerializerFlag(validateCmd, "Serializer the message is encoded with.")
+	allowReserved := validateCmd.Flag("allow-reserved", "Accept procedures and topics reserved for the WAMP "+
+		"protocol in REGISTER and PUBLISH messages.").Bool()
+	output := validateCmd.Flag("output", "Output format of the report.").Default(textOutput).
+		Enum(textOutput, markdownOutput, jsonOutput)
+	c.handle(validateCmd, func(e *env) error {
+		input, err := wampprotocli.DecodeBytes(*data)
+		if err != nil {
+			return err
+		}
+
+		report := wampprotocli.ValidateSerialized(*serializer, input, wampprotocli.ValidationOptions{
+			AllowReserved: *allowReserved,
+		})
+		if err = writeValidationReport(e.stdout, *output, report); err != nil {
+			return err
+		}
+
+		if !report.Valid {
+			return errors.New("message violates the WAMP spec")
+		}
+
+		return nil
+	})
+}
+
+// writeValidationReport writes report as JSON, or as the message type
+// followed by a table of the violations.
+func writeValidationReport(w io.Writer, output string, report *wampprotocli.ValidationReport) error {
+	if output == jsonOutput {
+		return json.NewEncoder(w).Encode(report)
+	}
+
+	if report.Name != "" {
+		fmt.Fprintf(w, "%s (%d)\n\n", report.Name, report.Type)
+	}
+
+	if report.Valid {
+		fmt.Fprintln(w, "valid")
+		return nil
+	}
+
+	rows := [][]string{{"category", "violation", "spec"}}
+	for _, violation := range report.Violations {
+		rows = append(rows, []string{violation.Category, violation.Message, violation.Spec})
+	}
+
+	return writeTable(w, output, rows)
+}
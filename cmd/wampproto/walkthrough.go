@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-go/messages"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+const (
+	cryptosignMethod = "cryptosign"
+	challengeSize    = 32
+)
+
+// walkthrough prints the steps of an authentication, exchanging the messages
+// with a live router if transport is set and simulating the router otherwise.
+type walkthrough struct {
+	e          *env
+	serializer string
+	transport  *webSocketTransport
+	// scanner waits for the user between steps if stdin is a terminal.
+	scanner *bufio.Scanner
+	step    int
+}
+
+func registerAuthWalkthrough(c *cli, cmd *kingpin.CmdClause) {
+	walkthroughCmd := cmd.Command("walkthrough", "Step through an authentication, showing every message "+
+		"decoded and serialized, against a simulated or a live router.")
+	method := walkthroughCmd.Flag("method", "Authentication method.").Default(cryptosignMethod).
+		Enum(cryptosignMethod)
+	realm := walkthroughCmd.Flag("realm", "Realm to join.").Default("realm1").String()
+	authID := walkthroughCmd.Flag("authid", "Authid to authenticate as.").String()
+	privateKey := walkthroughCmd.Flag("private-key", "Hex encoded cryptosign private key, a generated one by "+
+		"default.").PlaceHolder("HEX").String()
+	serializer := walkthroughCmd.Flag("serializer", "Serializer to encode messages with.").Short('s').
+		Default(wampprotocli.JSONSerializer).Enum(wampprotocli.SerializerNames()...)
+	url := walkthroughCmd.Flag("url", "WebSocket URL of a router to authenticate with instead of simulating "+
+		"it, e.g. ws://localhost:8080/ws.").String()
+	timeout := walkthroughCmd.Flag("timeout", "Time to wait for each message of the router.").Default("5s").
+		Duration()
+	c.handle(walkthroughCmd, func(e *env) error {
+		keyPair, err := cryptosignKeyPair(*privateKey)
+		if err != nil {
+			return err
+		}
+
+		defer keyPair.Wipe()
+
+		w := &walkthrough{e: e, serializer: *serializer}
+		if isTerminal(e.stdin) {
+			w.scanner = bufio.NewScanner(e.stdin)
+		}
+
+		if *url != "" {
+			if w.transport, err = dialWebSocket(e, *url, *serializer, *timeout); err != nil {
+				return err
+			}
+
+			defer func() { _ = w.transport.conn.Close() }()
+		}
+
+		switch *method {
+		case cryptosignMethod:
+			return w.cryptosign(*realm, *authID, keyPair)
+		default:
+			return fmt.Errorf("unsupported authentication method %q", *method)
+		}
+	})
+}
+
+// cryptosignKeyPair returns the key pair of the hex encoded private key seed,
+// or a generated one if it is empty.
+func cryptosignKeyPair(privateKey string) (*wampprotocli.KeyPair, error) {
+	if privateKey == "" {
+		_, generated, err := ed25519.GenerateKey(wampprotocli.RandomReader())
+		if err != nil {
+			return nil, err
+		}
+
+		defer clear(generated)
+		return wampprotocli.KeyPairFromSeed(generated.Seed())
+	}
+
+	seed, err := wampprotocli.DecodeSecretHex(privateKey, "private key")
+	if err != nil {
+		return nil, err
+	}
+
+	defer clear(seed)
+	return wampprotocli.KeyPairFromSeed(seed)
+}
+
+// cryptosign walks through a cryptosign authentication.
+func (w *walkthrough) cryptosign(realm, authID string, keyPair *wampprotocli.KeyPair) error {
+	publicKey := hex.EncodeToString(keyPair.PublicKey)
+	details := map[string]any{
+		"roles": map[string]any{
+			"caller": map[string]any{}, "callee": map[string]any{},
+			"publisher": map[string]any{}, "subscriber": map[string]any{},
+		},
+		"authmethods": []any{cryptosignMethod},
+		"authextra":   map[string]any{"pubkey": publicKey},
+	}
+	if authID != "" {
+		details["authid"] = authID
+	}
+
+	err := w.send("HELLO", fmt.Sprintf("The client asks to join %s, offering cryptosign as its only "+
+		"authentication method and announcing its public key %s in authextra.", realm, publicKey),
+		[]any{messages.MessageTypeHello, realm, details})
+	if err != nil {
+		return err
+	}
+
+	challenge := make([]byte, challengeSize)
+	if _, err = io.ReadFull(wampprotocli.RandomReader(), challenge); err != nil {
+		return err
+	}
+
+	raw, err := w.receive("CHALLENGE", "The router looks up the public key and challenges the client to prove "+
+		"it holds the private key by signing random bytes.", []any{messages.MessageTypeChallenge,
+		cryptosignMethod, map[string]any{"challenge": hex.EncodeToString(challenge)}})
+	if err != nil {
+		return err
+	} else if rawMessageType(raw) != messages.MessageTypeChallenge {
+		return fmt.Errorf("router sent %s instead of CHALLENGE", wampprotocli.MessageName(rawMessageType(raw)))
+	}
+
+	extra, _ := raw[len(raw)-1].(map[string]any)
+	received, _ := extra["challenge"].(string)
+	if challenge, err = hex.DecodeString(received); err != nil || len(challenge) == 0 {
+		return fmt.Errorf("CHALLENGE carries no hex encoded challenge: %v", extra["challenge"])
+	}
+
+	signed, err := wampprotocli.SignChallenge(challenge, keyPair)
+	if err != nil {
+		return err
+	}
+
+	w.begin("Signature", "The client signs the challenge with ed25519 and sends the 64 byte signature "+
+		"followed by the challenge.")
+	fmt.Fprintf(w.e.stdout, "challenge: %x\n", challenge)
+	fmt.Fprintf(w.e.stdout, "signature: %x\n", signed[:ed25519.SignatureSize])
+	fmt.Fprintf(w.e.stdout, "signed:    %x\n", signed)
+	w.pause()
+
+	err = w.send("AUTHENTICATE", "The client answers the challenge with the hex encoded signature.",
+		[]any{messages.MessageTypeAuthenticate, hex.EncodeToString(signed), map[string]any{}})
+	if err != nil {
+		return err
+	}
+
+	welcome, err := w.simulatedWelcome(signed, challenge, keyPair, authID)
+	if err != nil {
+		return err
+	}
+
+	raw, err = w.receive("WELCOME", "The router verifies the signature with the public key and that the "+
+		"signed challenge is the one it sent, then opens the session.", welcome)
+	if err != nil {
+		return err
+	} else if rawMessageType(raw) != messages.MessageTypeWelcome {
+		return fmt.Errorf("router sent %s instead of WELCOME", wampprotocli.MessageName(rawMessageType(raw)))
+	}
+
+	return nil
+}
+
+// simulatedWelcome returns the WELCOME a router sends for the signed challenge.
+func (w *walkthrough) simulatedWelcome(signed, challenge []byte, keyPair *wampprotocli.KeyPair,
+	authID string) ([]any, error) {
+	if w.transport != nil {
+		return nil, nil
+	}
+
+	valid, err := wampprotocli.VerifyChallengeSignature(signed, challenge, keyPair.PublicKey)
+	if err != nil {
+		return nil, err
+	} else if !valid {
+		return nil, errors.New("signature is invalid")
+	}
+
+	sessionID, err := wampprotocli.RandomIDGenerator{}.NextID()
+	if err != nil {
+		return nil, err
+	}
+
+	if authID == "" {
+		authID = hex.EncodeToString(keyPair.PublicKey)
+	}
+
+	return []any{messages.MessageTypeWelcome, sessionID, map[string]any{
+		"authid":     authID,
+		"authmethod": cryptosignMethod,
+		"roles":      map[string]any{"dealer": map[string]any{}, "broker": map[string]any{}},
+	}}, nil
+}
+
+// send shows the client message raw and sends it to the live router.
+func (w *walkthrough) send(name, explanation string, raw []any) error {
+	data, err := w.serialize(raw)
+	if err != nil {
+		return err
+	}
+
+	w.begin(name+", client to router", explanation)
+	w.show(raw, data)
+	if w.transport != nil {
+		if err = w.transport.Send(data); err != nil {
+			return err
+		}
+	}
+
+	w.pause()
+	return nil
+}
+
+// receive shows and returns the message the live router sends next, or
+// simulated if the router is simulated. An ABORT of the router is an error.
+func (w *walkthrough) receive(name, explanation string, simulated []any) ([]any, error) {
+	var data []byte
+	var raw []any
+	var err error
+	if w.transport == nil {
+		raw = simulated
+		if data, err = w.serialize(raw); err != nil {
+			return nil, err
+		}
+	} else {
+		if data, err = w.transport.Receive(); err != nil {
+			return nil, fmt.Errorf("failed to receive %s: %w", name, err)
+		}
+
+		if raw, err = wampprotocli.DeserializeRaw(w.serializer, data); err != nil {
+			return nil, err
+		}
+
+		name = wampprotocli.MessageName(rawMessageType(raw))
+	}
+
+	w.begin(name+", router to client", explanation)
+	w.show(raw, data)
+	if rawMessageType(raw) == messages.MessageTypeAbort {
+		reason, _ := raw[len(raw)-1].(string)
+		return nil, fmt.Errorf("router aborted the session: %s", reason)
+	}
+
+	w.pause()
+	return raw, nil
+}
+
+func (w *walkthrough) serialize(raw []any) ([]byte, error) {
+	message, err := wampprotocli.MessageFromRaw(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return wampprotocli.SerializeMessage(w.serializer, message)
+}
+
+// begin prints the heading and explanation of the next step.
+func (w *walkthrough) begin(title, explanation string) {
+	w.step++
+	if w.step > 1 {
+		fmt.Fprintln(w.e.stdout)
+	}
+
+	fmt.Fprintf(w.e.stdout, "== %d. %s ==\n%s\n", w.step, title, explanation)
+}
+
+// show prints a message decoded and serialized.
+func (w *walkthrough) show(raw []any, data []byte) {
+	decoded, err := json.Marshal(raw)
+	if err != nil {
+		decoded = []byte(fmt.Sprint(raw))
+	}
+
+	fmt.Fprintf(w.e.stdout, "decoded: %s\n", decoded)
+	fmt.Fprintf(w.e.stdout, "bytes:   %x (%d bytes, %s)\n", data, len(data), w.serializer)
+}
+
+// pause waits for the user to continue if stdin is a terminal.
+func (w *walkthrough) pause() {
+	if w.scanner == nil {
+		return
+	}
+
+	fmt.Fprint(w.e.stderr, "Press Enter to continue...")
+	w.scanner.Scan()
+}
+
+// rawMessageType returns the type of the positional message raw, or 0.
+func rawMessageType(raw []any) int {
+	if len(raw) == 0 {
+		return 0
+	}
+
+	messageType, _ := messages.AsInt64(raw[0])
+	return int(messageType)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+// maxLoggedWarnings bounds how many warnings are kept for logging, so that a
+// long-running http-serve doesn't accumulate them without limit. Counts are
+// always exact.
+const maxLoggedWarnings = 100
+
+// warningCollector gathers the validation warnings of every command executed
+// by one invocation, including batch command lines and http-serve requests.
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings []wampprotocli.Warning
+	counts   map[string]int
+	total    int
+}
+
+func (w *warningCollector) add(warnings ...wampprotocli.Warning) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.counts == nil {
+		w.counts = make(map[string]int)
+	}
+
+	for _, warning := range warnings {
+		w.counts[warning.Category]++
+		w.total++
+		if len(w.warnings) < maxLoggedWarnings {
+			w.warnings = append(w.warnings, warning)
+		}
+	}
+}
+
+// summarize logs the collected warnings followed by a summary with counts by
+// category and returns the total number of warnings.
+func (w *warningCollector) summarize(logger *slog.Logger) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.total == 0 {
+		return 0
+	}
+
+	for _, warning := range w.warnings {
+		logger.Warn("validation warning", "category", warning.Category, "message", warning.Message,
+			"spec", warning.Spec)
+	}
+
+	categories := make([]string, 0, len(w.counts))
+	for category := range w.counts {
+		categories = append(categories, category)
+	}
+	slices.Sort(categories)
+
+	attrs := make([]any, 0, len(categories))
+	for _, category := range categories {
+		attrs = append(attrs, slog.Int(category, w.counts[category]))
+	}
+
+	logger.Warn("validation summary", "total", w.total, "omitted", w.total-len(w.warnings),
+		slog.Group("categories", attrs...))
+	return w.total
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/xconnio/wampproto-go/messages"
+
+	"github.com/xconnio/wampproto-cli"
+)
+
+// wizard asks for the parts of a message on stdin, writing its prompts to
+// stderr so that stdout only carries the result.
+type wizard struct {
+	e       *env
+	scanner *bufio.Scanner
+}
+
+// wizardMessageTypes returns the message types the wizard builds by the name
+// of the command building them.
+func wizardMessageTypes() map[string]int {
+	return map[string]int{
+		"call":         messages.MessageTypeCall,
+		"invocation":   messages.MessageTypeInvocation,
+		"result":       messages.MessageTypeResult,
+		"publish":      messages.MessageTypePublish,
+		"subscribe":    messages.MessageTypeSubscribe,
+		"event":        messages.MessageTypeEvent,
+		"register":     messages.MessageTypeRegister,
+		"welcome":      messages.MessageTypeWelcome,
+		"goodbye":      messages.MessageTypeGoodbye,
+		"challenge":    messages.MessageTypeChallenge,
+		"error":        messages.MessageTypeError,
+		"unsubscribe":  messages.MessageTypeUnSubscribe,
+		"unsubscribed": messages.MessageTypeUnSubscribed,
+		"published":    messages.MessageTypePublished,
+		"cancel":       messages.MessageTypeCancel,
+	}
+}
+
+func registerWizardMessage(c *cli, cmd *kingpin.CmdClause) {
+	wizardCmd := cmd.Command("wizard", "Build a message by answering prompts for its type, IDs, options and "+
+		"arguments, printing the equivalent command and the serialized message.")
+	flags := addSerializeFlags(wizardCmd)
+	c.handle(wizardCmd, func(e *env) error {
+		w := &wizard{e: e, scanner: bufio.NewScanner(e.stdin)}
+		args, err := w.build()
+		if err != nil {
+			return err
+		}
+
+		args = append(args, flags.args()...)
+		if e.strict {
+			args = append([]string{"--strict"}, args...)
+		}
+
+		fmt.Fprintf(e.stdout, "wampproto %s\n", quoteArgs(args))
+		return execute(e.ctx, args, strings.NewReader(""), e.stdout, e.stderr, e.warnings)
+	})
+}
+
+// build asks for the parts of a message and returns the arguments of the
+// message command building it.
+func (w *wizard) build() ([]string, error) {
+	types := wizardMessageTypes()
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	name, err := w.ask(fmt.Sprintf("Message type (%s)", strings.Join(names, ", ")), func(value string) error {
+		if _, ok := types[value]; !ok {
+			return fmt.Errorf("must be one of %s", strings.Join(names, ", "))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	command := messageCommand(name)
+	args := []string{"message", name}
+	for _, arg := range command.Args {
+		value, err := w.ask(fmt.Sprintf("%s (%s)", arg.Name, strings.TrimSuffix(arg.Help, ".")), func(value string) error {
+			switch {
+			case arg.Name == "request-type":
+				requestTypes := errorRequestTypes()
+				if _, ok := requestTypes[value]; !ok {
+					names := make([]string, 0, len(requestTypes))
+					for name := range requestTypes {
+						names = append(names, name)
+					}
+
+					slices.Sort(names)
+					return fmt.Errorf("must be one of %s", strings.Join(names, ", "))
+				}
+			case strings.HasSuffix(arg.Name, "-id"):
+				if id, err := strconv.ParseInt(value, 10, 64); err != nil || id < 1 || id > wampprotocli.MaxID {
+					return fmt.Errorf("must be an integer between 1 and %d", int64(wampprotocli.MaxID))
+				}
+			default:
+				return wampprotocli.ValidateURI(value, false, true)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, value)
+	}
+
+	hasFlag := func(name string) bool {
+		return slices.ContainsFunc(command.Flags, func(flag *kingpin.FlagModel) bool { return flag.Name == name })
+	}
+
+	if hasFlag("option") {
+		options, err := w.askOptions(command, types[name])
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, options...)
+	}
+
+	if hasFlag("extra") {
+		for {
+			value, err := w.ask("Extra value as KEY=VALUE, JSON or a string, empty to continue", checkKeyValue)
+			if err != nil {
+				return nil, err
+			} else if value == "" {
+				break
+			}
+
+			args = append(args, "--extra", value)
+		}
+	}
+
+	if !hasFlag("arg") {
+		return args, nil
+	}
+
+	for {
+		value, err := w.ask("Positional argument, JSON or a string, empty to continue", nil)
+		if err != nil {
+			return nil, err
+		} else if value == "" {
+			break
+		}
+
+		args = append(args, "--arg", value)
+	}
+
+	for {
+		value, err := w.ask("Keyword argument as KEY=VALUE, empty to finish", checkKeyValue)
+		if err != nil {
+			return nil, err
+		} else if value == "" {
+			return args, nil
+		}
+
+		args = append(args, "--kwarg", value)
+	}
+}
+
+// askOptions asks for the options or details of a message of messageType,
+// offering only the keys the spec defines, and returns the arguments of
+// command setting them.
+func (w *wizard) askOptions(command *kingpin.CmdModel, messageType int) ([]string, error) {
+	keys := wampprotocli.OptionKeys(messageType)
+	if len(keys) == 0 {
+		fmt.Fprintf(w.e.stderr, "%s option keys: only _custom keys\n", wampprotocli.MessageName(messageType))
+	} else {
+		fmt.Fprintf(w.e.stderr, "%s option keys: %s, or _custom keys\n", wampprotocli.MessageName(messageType),
+			strings.Join(keys, ", "))
+	}
+
+	var args []string
+	for {
+		value, err := w.ask("Option as KEY=VALUE with a JSON or string value, empty to continue",
+			func(value string) error {
+				_, err := optionArgs(command, keys, value)
+				return err
+			})
+		if err != nil {
+			return nil, err
+		} else if value == "" {
+			return args, nil
+		}
+
+		option, _ := optionArgs(command, keys, value)
+		args = append(args, option...)
+	}
+}
+
+// ask prompts for a value until check accepts it. check may be nil to accept
+// any value.
+func (w *wizard) ask(prompt string, check func(string) error) (string, error) {
+	for {
+		fmt.Fprintf(w.e.stderr, "%s: ", prompt)
+		if !w.scanner.Scan() {
+			if err := w.scanner.Err(); err != nil {
+				return "", err
+			}
+
+			return "", errors.New("input ended before the message was complete")
+		}
+
+		value := strings.TrimSpace(w.scanner.Text())
+		if check == nil {
+			return value, nil
+		}
+
+		err := check(value)
+		if err == nil {
+			return value, nil
+		}
+
+		fmt.Fprintf(w.e.stderr, "invalid: %s\n", err)
+	}
+}
+
+// messageCommand returns the model of the message command called name.
+func messageCommand(name string) *kingpin.CmdModel {
+	c := newCLI(io.Discard, io.Discard, func(int) {})
+	registerMessage(c, c.app.Command("message", ""))
+	for _, command := range c.app.Model().Commands[0].Commands {
+		if command.Name == name {
+			return command
+		}
+	}
+
+	return nil
+}
+
+// optionArgs returns the arguments of command setting the option KEY=VALUE,
+// whose key must be one of keys or start with "_". Options are set with their
+// dedicated flag if there is one, as --option only carries strings.
+func optionArgs(command *kingpin.CmdModel, keys []string, option string) ([]string, error) {
+	if option == "" {
+		return nil, nil
+	} else if err := checkKeyValue(option); err != nil {
+		return nil, err
+	}
+
+	key, raw, _ := strings.Cut(option, "=")
+	if !strings.HasPrefix(key, "_") && !slices.Contains(keys, key) {
+		return nil, fmt.Errorf("unknown key %q", key)
+	}
+
+	value := parseValue(raw)
+	name := strings.ReplaceAll(key, "_", "-")
+	index := slices.IndexFunc(command.Flags, func(flag *kingpin.FlagModel) bool { return flag.Name == name })
+	if index < 0 {
+		if s, ok := value.(string); ok {
+			return []string{"--option", key + "=" + s}, nil
+		}
+
+		return nil, fmt.Errorf("%s can only be a string, the command has no flag for other values", key)
+	}
+
+	if command.Flags[index].IsBoolFlag() {
+		if value != true {
+			return nil, fmt.Errorf("%s can only be true, leave it out otherwise", key)
+		}
+
+		return []string{"--" + name}, nil
+	}
+
+	values, ok := value.([]any)
+	if !ok {
+		values = []any{value}
+	}
+
+	var args []string
+	for _, v := range values {
+		args = append(args, "--"+name, fmt.Sprint(v))
+	}
+
+	return args, nil
+}
+
+func checkKeyValue(value string) error {
+	if key, _, ok := strings.Cut(value, "="); value != "" && (!ok || key == "") {
+		return errors.New("must be KEY=VALUE")
+	}
+
+	return nil
+}
+
+// unquotedChars are the characters of arguments that need no quoting.
+const unquotedChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-.,:=/@%+"
+
+// quoteArgs joins args into a command line that splitArgs and POSIX shells
+// split into args again.
+func quoteArgs(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "" && strings.Trim(arg, unquotedChars) == "" {
+			quoted = append(quoted, arg)
+			continue
+		}
+
+		quoted = append(quoted, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,402 @@
+package wampprotocli
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// Languages of exported code.
+const (
+	GoLanguage     = "go"
+	PythonLanguage = "python"
+	JSLanguage     = "js"
+)
+
+// CodeLanguages returns the languages messages are exported as code in.
+func CodeLanguages() []string {
+	return []string{GoLanguage, PythonLanguage, JSLanguage}
+}
+
+// codeMessage is a positional message taken apart for code generation.
+type codeMessage struct {
+	raw     []any
+	name    string
+	options map[string]any
+	uri     string
+	args    []any
+	kwargs  map[string]any
+}
+
+// field returns the field at position, or nil if the message is shorter.
+func (m *codeMessage) field(position int) any {
+	if position >= len(m.raw) {
+		return nil
+	}
+
+	return m.raw[position]
+}
+
+// ExportCode returns the code that produces the positional message raw with
+// a client library: wampproto-go constructors serialized with serializer for
+// Go, and the session calls of Autobahn|Python and Autobahn|JS, which only
+// exist for the messages clients initiate.
+func ExportCode(raw []any, language, serializer string) (string, error) {
+	if len(raw) == 0 {
+		return "", fmt.Errorf("message must not be empty")
+	}
+
+	messageType := rawMessageType(raw)
+	m := &codeMessage{raw: raw, name: MessageName(messageType)}
+	if position, _ := optionKeys(messageType); position > 0 {
+		m.options, _ = m.field(position).(map[string]any)
+	}
+
+	schema, _ := messageSchema(messageType)
+	for i, field := range schema {
+		switch field.name {
+		case "Procedure", "Topic":
+			m.uri, _ = m.field(i + 1).(string)
+		case "Arguments":
+			m.args, _ = m.field(i + 1).([]any)
+		case "ArgumentsKw":
+			m.kwargs, _ = m.field(i + 1).(map[string]any)
+		}
+	}
+
+	switch language {
+	case GoLanguage:
+		return goCode(m, messageType, serializer)
+	case PythonLanguage:
+		return pythonCode(m, messageType)
+	case JSLanguage:
+		return jsCode(m, messageType)
+	default:
+		return "", fmt.Errorf("unknown language %q, must be one of %v", language, CodeLanguages())
+	}
+}
+
+func goCode(m *codeMessage, messageType int, serializer string) (string, error) {
+	var constructor string
+	var fields []any
+	switch messageType {
+	case messages.MessageTypeCall, messages.MessageTypePublish:
+		constructor, fields = "New"+m.title(), []any{m.field(1), m.options, m.uri, m.args, m.kwargs}
+	case messages.MessageTypeSubscribe, messages.MessageTypeRegister:
+		constructor, fields = "New"+m.title(), []any{m.field(1), m.options, m.uri}
+	case messages.MessageTypeEvent, messages.MessageTypeInvocation:
+		constructor, fields = "New"+m.title(), []any{m.field(1), m.field(2), m.field(3), m.args, m.kwargs}
+	case messages.MessageTypeResult, messages.MessageTypeYield:
+		constructor, fields = "New"+m.title(), []any{m.field(1), m.field(2), m.args, m.kwargs}
+	default:
+		return "", fmt.Errorf("code export does not support %s messages", m.name)
+	}
+
+	serializerType := map[string]string{
+		JSONSerializer:    "JSONSerializer",
+		CBORSerializer:    "CBORSerializer",
+		MsgPackSerializer: "MsgPackSerializer",
+	}[serializer]
+	if serializerType == "" {
+		return "", fmt.Errorf("unknown serializer %q", serializer)
+	}
+
+	arguments := make([]string, 0, len(fields))
+	for i, field := range fields {
+		// IDs are passed as untyped constants.
+		if i < 2 && isInteger(field) {
+			arguments = append(arguments, fmt.Sprint(field))
+		} else {
+			arguments = append(arguments, goLiteral(field))
+		}
+	}
+
+	return fmt.Sprintf(`import (
+	"github.com/xconnio/wampproto-go/messages"
+	"github.com/xconnio/wampproto-go/serializers"
+)
+
+message := messages.%s(%s)
+data, err := (&serializers.%s{}).Serialize(message)
+`, constructor, strings.Join(arguments, ", "), serializerType), nil
+}
+
+// title returns the name of the message as wampproto-go capitalizes it.
+func (m *codeMessage) title() string {
+	return m.name[:1] + strings.ToLower(m.name[1:])
+}
+
+// pythonOptions are the option keys the option classes of Autobahn|Python
+// take as keyword arguments.
+func pythonOptions(messageType int) (string, []string) {
+	switch messageType {
+	case messages.MessageTypePublish:
+		return "PublishOptions", []string{"acknowledge", "exclude_me", "exclude", "exclude_authid",
+			"exclude_authrole", "eligible", "eligible_authid", "eligible_authrole", retainOption}
+	case messages.MessageTypeSubscribe:
+		return "SubscribeOptions", []string{matchOption, getRetainedOption}
+	case messages.MessageTypeRegister:
+		return "RegisterOptions", []string{matchOption, invokeOption, "concurrency", "force_reregister"}
+	default:
+		return "CallOptions", nil
+	}
+}
+
+func pythonCode(m *codeMessage, messageType int) (string, error) {
+	class, supported := pythonOptions(messageType)
+
+	var lines, options, unsupported []string
+	for _, key := range sortedKeys(m.options) {
+		if contains(supported, key) {
+			options = append(options, key+"="+pythonLiteral(m.options[key]))
+		} else {
+			unsupported = append(unsupported, key)
+		}
+	}
+
+	if len(unsupported) > 0 {
+		lines = append(lines, fmt.Sprintf("# %s has no keyword argument for option(s) %s", class,
+			strings.Join(unsupported, ", ")))
+	}
+
+	call := []string{pythonLiteral(m.uri)}
+	for _, arg := range m.args {
+		call = append(call, pythonLiteral(arg))
+	}
+
+	if len(options) > 0 {
+		call = append(call, fmt.Sprintf("options=%s(%s)", class, strings.Join(options, ", ")))
+	}
+
+	if len(m.kwargs) > 0 {
+		call = append(call, "**"+pythonLiteral(m.kwargs))
+	}
+
+	imports := "from autobahn.wamp.types import " + class + "\n\n"
+	if len(options) == 0 {
+		imports = ""
+	}
+
+	switch messageType {
+	case messages.MessageTypeCall:
+		lines = append(lines, fmt.Sprintf("result = await session.call(%s)", strings.Join(call, ", ")))
+	case messages.MessageTypePublish:
+		lines = append(lines, fmt.Sprintf("await session.publish(%s)", strings.Join(call, ", ")))
+	case messages.MessageTypeSubscribe:
+		lines = append(lines, "def on_event(*args, **kwargs):", "    print(args, kwargs)", "",
+			fmt.Sprintf("await session.subscribe(on_event, %s)", strings.Join(call, ", ")))
+	case messages.MessageTypeRegister:
+		lines = append(lines, "def endpoint(*args, **kwargs):", "    return args", "",
+			fmt.Sprintf("await session.register(endpoint, %s)", strings.Join(call, ", ")))
+	default:
+		return "", fmt.Errorf("sessions of Autobahn|Python don't send %s messages", m.name)
+	}
+
+	return imports + strings.Join(lines, "\n") + "\n", nil
+}
+
+func jsCode(m *codeMessage, messageType int) (string, error) {
+	args, kwargs, options := jsLiteral(m.args), jsLiteral(m.kwargs), jsLiteral(m.options)
+	if m.args == nil {
+		args = "[]"
+	}
+
+	if m.kwargs == nil {
+		kwargs = "{}"
+	}
+
+	if m.options == nil {
+		options = "{}"
+	}
+
+	uri := jsLiteral(m.uri)
+	switch messageType {
+	case messages.MessageTypeCall:
+		return fmt.Sprintf("const result = await session.call(%s, %s, %s, %s);\n", uri, args, kwargs, options), nil
+	case messages.MessageTypePublish:
+		return fmt.Sprintf("await session.publish(%s, %s, %s, %s);\n", uri, args, kwargs, options), nil
+	case messages.MessageTypeSubscribe:
+		return fmt.Sprintf("const onEvent = (args, kwargs) => console.log(args, kwargs);\n\n"+
+			"await session.subscribe(%s, onEvent, %s);\n", uri, options), nil
+	case messages.MessageTypeRegister:
+		return fmt.Sprintf("const endpoint = (args, kwargs) => args;\n\n"+
+			"await session.register(%s, endpoint, %s);\n", uri, options), nil
+	default:
+		return "", fmt.Errorf("sessions of Autobahn|JS don't send %s messages", m.name)
+	}
+}
+
+func isInteger(value any) bool {
+	switch value.(type) {
+	case int, int64, uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+func asFloat(value any) float64 {
+	if f, ok := value.(float32); ok {
+		return float64(f)
+	}
+
+	return value.(float64)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// goLiteral returns value as a Go expression of the same dynamic type as
+// wampproto-go decodes it.
+func goLiteral(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return fmt.Sprintf("int64(%d)", v)
+	case int64:
+		return fmt.Sprintf("int64(%d)", v)
+	case uint64:
+		if v > math.MaxInt64 {
+			return fmt.Sprintf("uint64(%d)", v)
+		}
+
+		return fmt.Sprintf("int64(%d)", v)
+	case float32:
+		return fmt.Sprintf("float64(%s)", strconv.FormatFloat(float64(v), 'g', -1, 32))
+	case float64:
+		return fmt.Sprintf("float64(%s)", strconv.FormatFloat(v, 'g', -1, 64))
+	case []byte:
+		return fmt.Sprintf("[]byte(%q)", v)
+	case []any:
+		if v == nil {
+			return "nil"
+		}
+
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, goLiteral(item))
+		}
+
+		return "[]any{" + strings.Join(items, ", ") + "}"
+	case map[string]any:
+		if v == nil {
+			return "nil"
+		}
+
+		items := make([]string, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			items = append(items, strconv.Quote(key)+": "+goLiteral(v[key]))
+		}
+
+		return "map[string]any{" + strings.Join(items, ", ") + "}"
+	default:
+		return fmt.Sprintf("%#v", v)
+	}
+}
+
+func pythonLiteral(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "None"
+	case bool:
+		if v {
+			return "True"
+		}
+
+		return "False"
+	case string:
+		return strconv.Quote(v)
+	case float32, float64:
+		f := asFloat(v)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return fmt.Sprintf("float(%q)", strconv.FormatFloat(f, 'g', -1, 64))
+		}
+
+		literal := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(literal, ".e") {
+			literal += ".0"
+		}
+
+		return literal
+	case []byte:
+		var b strings.Builder
+		b.WriteString("b'")
+		for _, c := range v {
+			fmt.Fprintf(&b, "\\x%02x", c)
+		}
+
+		return b.String() + "'"
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, pythonLiteral(item))
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		items := make([]string, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			items = append(items, strconv.Quote(key)+": "+pythonLiteral(v[key]))
+		}
+
+		return "{" + strings.Join(items, ", ") + "}"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func jsLiteral(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	case []byte:
+		items := make([]string, 0, len(v))
+		for _, c := range v {
+			items = append(items, strconv.Itoa(int(c)))
+		}
+
+		return "new Uint8Array([" + strings.Join(items, ", ") + "])"
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, jsLiteral(item))
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]any:
+		items := make([]string, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			items = append(items, strconv.Quote(key)+": "+jsLiteral(v[key]))
+		}
+
+		return "{" + strings.Join(items, ", ") + "}"
+	case float32, float64:
+		f := asFloat(v)
+		if math.IsNaN(f) {
+			return "NaN"
+		} else if math.IsInf(f, 0) {
+			return map[bool]string{true: "Infinity", false: "-Infinity"}[f > 0]
+		}
+
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
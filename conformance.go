@@ -0,0 +1,194 @@
+package wampprotocli
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ValidationReport lists every violation of the WAMP spec found in a
+// serialized message.
+type ValidationReport struct {
+	// Type and Name are those of the message, if it could be decoded.
+	Type       int         `json:"type,omitempty"`
+	Name       string      `json:"name,omitempty"`
+	Valid      bool        `json:"valid"`
+	Violations []Violation `json:"violations"`
+}
+
+// optionKinds are the types of the values of the option and detail keys the
+// spec defines.
+//
+//nolint:gochecknoglobals
+var optionKinds = map[string]string{
+	"acknowledge": "bool", "exclude_me": "bool", "disclose_me": "bool", retainOption: "bool",
+	getRetainedOption: "bool", retainedOption: "bool", "receive_progress": "bool", progressOption: "bool",
+	"disclose_caller": "bool", "force_reregister": "bool", "resumable": "bool", "resumed": "bool",
+
+	"agent": "string", "authid": "string", "authrole": "string", "authmethod": "string",
+	"authprovider": "string", "realm": "string", matchOption: "string", invokeOption: "string",
+	modeOption: "string", messageOption: "string", reasonOption: "string", pptSchemeOption: "string",
+	pptSerializerOption: "string", pptCipherOption: "string", pptKeyIDOption: "string",
+	"publisher_authid": "string", "publisher_authrole": "string", "caller_authid": "string",
+	"caller_authrole": "string", "topic": "string", "procedure": "string", "resume-token": "string",
+
+	timeoutOption: "int", "concurrency": "int", "publisher": "id", "caller": "id", "resume-session": "id",
+
+	"roles": "dict", "authextra": "dict",
+
+	"authmethods": "list", "exclude": "list", "eligible": "list", "exclude_authid": "list",
+	"exclude_authrole": "list", "eligible_authid": "list", "eligible_authrole": "list", forwardForOption: "list",
+}
+
+// ValidateSerialized validates the serialized message data strictly: unlike
+// Parse, it doesn't stop at the first problem but reports every violation,
+// and it checks the raw message before wampproto-go converts it, so that
+// the arity and the types of the fields, IDs encoded as floats and the types
+// of option values are checked as well. Warnings count as violations.
+func ValidateSerialized(serializerName string, data []byte, options ValidationOptions) *ValidationReport {
+	options.Strict = true
+	report := &ValidationReport{}
+	_, err := ValidatePayload(serializerName, data, options)
+	report.add(err)
+
+	raw, err := DeserializeRaw(serializerName, data)
+	if err != nil {
+		report.Violations = append(report.Violations, Violation{Category: CategoryStructure,
+			Spec: SpecSerializations, Message: err.Error()})
+		return report.finish()
+	}
+
+	report.Type = rawMessageType(raw)
+	report.Name = MessageName(report.Type)
+	structure := validateStructure(raw)
+	if len(structure) > 0 {
+		report.Violations = append(report.Violations, structure...)
+		return report.finish()
+	}
+
+	if _, err = MessageFromRaw(raw); err != nil {
+		_, spec := messageSchema(report.Type)
+		report.Violations = append(report.Violations, Violation{Category: CategoryStructure, Spec: spec,
+			Message: err.Error()})
+		return report.finish()
+	}
+
+	_, err = validateRules(report.Type, raw, options)
+	report.add(err)
+	return report.finish()
+}
+
+// add appends the violations err consists of.
+func (r *ValidationReport) add(err error) {
+	r.Violations = append(r.Violations, Violations(err)...)
+}
+
+func (r *ValidationReport) finish() *ValidationReport {
+	r.Valid = len(r.Violations) == 0
+	return r
+}
+
+// validateStructure checks the message type, the number of fields and their
+// types, and the types of the option values of the positional message raw.
+func validateStructure(raw []any) []Violation {
+	if len(raw) == 0 || !isIntegral(raw[0]) {
+		return []Violation{{Category: CategoryStructure, Spec: SpecMessageType,
+			Message: "message must be a list starting with an integer message type"}}
+	}
+
+	messageType := rawMessageType(raw)
+	schema, spec := messageSchema(messageType)
+	if schema == nil {
+		return []Violation{{Category: CategoryStructure, Spec: SpecMessageType,
+			Message: fmt.Sprintf("unknown message type %v", raw[0])}}
+	}
+
+	required := len(schema)
+	for required > 0 && (schema[required-1].name == "Arguments" || schema[required-1].name == "ArgumentsKw") {
+		required--
+	}
+
+	name := MessageName(messageType)
+	var violations []Violation
+	if fields := len(raw) - 1; fields < required || fields > len(schema) {
+		expected := fmt.Sprint(required)
+		if required < len(schema) {
+			expected = fmt.Sprintf("%d to %d", required, len(schema))
+		}
+
+		violations = append(violations, Violation{Category: CategoryStructure, Spec: spec,
+			Message: fmt.Sprintf("%s must have %s fields after the message type, has %d", name, expected, fields)})
+	}
+
+	for i, field := range schema {
+		if i+1 >= len(raw) {
+			break
+		}
+
+		if !hasKind(raw[i+1], field.dataType) {
+			violations = append(violations, Violation{Category: CategoryStructure, Spec: spec,
+				Message: fmt.Sprintf("%s field %s at position %d must be of type %s, was %s", name, field.name,
+					i+1, field.dataType, diffValue(raw[i+1]))})
+		}
+	}
+
+	return append(violations, validateOptionKinds(messageType, raw)...)
+}
+
+// validateOptionKinds checks the types of the values of the option or
+// detail keys the spec defines for the message type.
+func validateOptionKinds(messageType int, raw []any) []Violation {
+	position, allowed := optionKeys(messageType)
+	if allowed == nil || position >= len(raw) {
+		return nil
+	}
+
+	options, _ := raw[position].(map[string]any)
+	_, spec := messageSchema(messageType)
+
+	var violations []Violation
+	for _, key := range sortedKeys(options) {
+		kind, ok := optionKinds[key]
+		if !ok || !slices.Contains(allowed, key) || hasKind(options[key], kind) {
+			continue
+		}
+
+		violations = append(violations, Violation{Category: CategoryOption, Spec: spec,
+			Message: fmt.Sprintf("%s option %s must be of type %s, was %s", MessageName(messageType), key, kind,
+				diffValue(options[key]))})
+	}
+
+	return violations
+}
+
+// hasKind reports whether value has the spec type kind, e.g. id or dict.
+func hasKind(value any, kind string) bool {
+	switch kind {
+	case "id", "int":
+		return isIntegral(value)
+	case "uri", "string":
+		_, ok := value.(string)
+		return ok
+	case "bool":
+		_, ok := value.(bool)
+		return ok
+	case "dict":
+		_, ok := value.(map[string]any)
+		return ok
+	case "list":
+		_, ok := value.([]any)
+		return ok
+	default:
+		return true
+	}
+}
+
+// isIntegral reports whether value was decoded as an integer, as opposed to
+// a float, even if the float is integral.
+func isIntegral(value any) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return true
+	default:
+		return false
+	}
+}
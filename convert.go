@@ -0,0 +1,32 @@
+package wampprotocli
+
+// ConvertMessage re-serializes the message data, serialized with from, with
+// to. Unlike deserializing into a typed message, it keeps every field,
+// including those wampproto-go doesn't know, and only converts binary values
+// between native binary values and the base64 strings JSON carries them as.
+// Converting to JSON applies the policies nonFinite and bigInt, as for
+// SerializeRequest, and returns the warnings they report.
+func ConvertMessage(from, to string, data []byte, nonFinite, bigInt string) ([]byte, []Warning, error) {
+	if _, err := SerializerByName(to); err != nil {
+		return nil, nil, err
+	}
+
+	raw, err := DeserializeRaw(from, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var warnings []Warning
+	if to == JSONSerializer {
+		if raw, warnings, err = applyJSONPolicies(raw, nonFinite, bigInt); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	converted, err := serializeValue(to, convertBinary(raw, from, to))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return converted, warnings, nil
+}
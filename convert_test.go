@@ -0,0 +1,31 @@
+package wampprotocli
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestConvertMessageNonFinite(t *testing.T) {
+	// A CBOR CALL whose argument is NaN.
+	data, err := hex.DecodeString("85183001a063612e6281f97e00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err = ConvertMessage(CBORSerializer, MsgPackSerializer, data, "", ""); err != nil {
+		t.Errorf("to msgpack: %v", err)
+	}
+
+	if _, _, err = ConvertMessage(CBORSerializer, JSONSerializer, data, "", ""); err == nil {
+		t.Error("to json: expected an error for NaN")
+	}
+
+	converted, warnings, err := ConvertMessage(CBORSerializer, JSONSerializer, data, NonFiniteNull, "")
+	if err != nil {
+		t.Fatalf("to json: %v", err)
+	}
+
+	if string(converted) != `[48,1,{},"a.b",[null]]` || len(warnings) != 1 {
+		t.Errorf("to json: got %s with warnings %v", converted, warnings)
+	}
+}
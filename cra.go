@@ -0,0 +1,129 @@
+package wampprotocli
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/xconnio/wampproto-go/auth"
+)
+
+// craNonceSize is the number of random bytes of the nonce of a wampcra
+// challenge, as wampproto-go and Autobahn generate it.
+const craNonceSize = 16
+
+// craTimestampLayout is the UTC timestamp with milliseconds Crossbar.io puts
+// into wampcra challenges.
+const craTimestampLayout = "2006-01-02T15:04:05.000Z"
+
+// CRAChallenge is the challenge of a wampcra authentication, which the router
+// sends as a JSON string in the "challenge" extra of CHALLENGE and the client
+// signs with HMAC-SHA256.
+type CRAChallenge struct {
+	AuthID       string `json:"authid"`
+	AuthRole     string `json:"authrole"`
+	AuthMethod   string `json:"authmethod"`
+	AuthProvider string `json:"authprovider"`
+	Nonce        string `json:"nonce"`
+	Timestamp    string `json:"timestamp"`
+	Session      int64  `json:"session"`
+}
+
+// NewCRAChallenge returns a challenge for the session of authID. An empty
+// nonce is replaced with 16 random bytes, base64 encoded, an empty timestamp
+// with the time of the clock set with SetClock and a session of 0 with a
+// random session ID.
+func NewCRAChallenge(authID, authRole, authProvider string, session int64, nonce,
+	timestamp string) (*CRAChallenge, error) {
+	if authID == "" {
+		return nil, errors.New("authid must not be empty")
+	}
+
+	if session < 0 || session > MaxID {
+		return nil, fmt.Errorf("session must be in [1, %d], was %d", MaxID, session)
+	}
+
+	var err error
+	if session == 0 {
+		if session, err = (RandomIDGenerator{}).NextID(); err != nil {
+			return nil, err
+		}
+	}
+
+	if nonce == "" {
+		random := make([]byte, craNonceSize)
+		if _, err = io.ReadFull(RandomReader(), random); err != nil {
+			return nil, err
+		}
+
+		nonce = base64.StdEncoding.EncodeToString(random)
+	}
+
+	if timestamp == "" {
+		timestamp = clock().UTC().Format(craTimestampLayout)
+	}
+
+	return &CRAChallenge{
+		AuthID:       authID,
+		AuthRole:     authRole,
+		AuthMethod:   WAMPCRAAuth,
+		AuthProvider: authProvider,
+		Nonce:        nonce,
+		Timestamp:    timestamp,
+		Session:      session,
+	}, nil
+}
+
+// String returns the JSON string the client signs.
+func (c *CRAChallenge) String() string {
+	data, _ := json.Marshal(c)
+	return string(data)
+}
+
+// Defaults of the PBKDF2 parameters of salted wampcra secrets.
+const (
+	DefaultCRAIterations = 1000
+	DefaultCRAKeyLen     = 32
+)
+
+// DeriveCRAKey derives the key of a salted wampcra secret with PBKDF2-SHA256
+// as wampproto-go does and returns it base64 encoded, as Autobahn and
+// Crossbar.io sign with it. Iterations and keyLen of 0 default to
+// DefaultCRAIterations and DefaultCRAKeyLen.
+func DeriveCRAKey(secret, salt string, iterations, keyLen int) ([]byte, error) {
+	if iterations < 0 || keyLen < 0 {
+		return nil, fmt.Errorf("iterations and keylen must not be negative, were %d and %d", iterations, keyLen)
+	}
+
+	return auth.DeriveCRAKey(salt, secret, iterations, keyLen), nil
+}
+
+// CRAKey returns the key wampcra signs challenges with: the secret itself,
+// or, if salt isn't empty, the key DeriveCRAKey derives from it.
+func CRAKey(secret, salt string, iterations, keyLen int) ([]byte, error) {
+	if salt == "" {
+		return []byte(secret), nil
+	}
+
+	return DeriveCRAKey(secret, salt, iterations, keyLen)
+}
+
+// SignCRAChallenge signs a wampcra challenge with key and returns the
+// HMAC-SHA256 signature base64 encoded, as carried in AUTHENTICATE.
+func SignCRAChallenge(challenge string, key []byte) string {
+	return auth.SignCRAChallenge(challenge, key)
+}
+
+// VerifyCRASignature checks the base64 encoded signature of a wampcra
+// challenge, comparing it with the expected one in constant time.
+func VerifyCRASignature(signature, challenge string, key []byte) (bool, error) {
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, errors.New("signature must be base64 encoded")
+	}
+
+	return hmac.Equal(decoded, auth.SignCRAChallengeBytes(challenge, key)), nil
+}
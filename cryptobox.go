@@ -0,0 +1,159 @@
+package wampprotocli
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+// CryptoboxCipher is the ppt_cipher of payloads encrypted end-to-end with
+// cryptobox, i.e. Curve25519 key agreement and XSalsa20-Poly1305.
+const CryptoboxCipher = "xsalsa20poly1305"
+
+// SealedBoxCipher is the implementation specific ppt_cipher of payloads
+// sealed anonymously for a recipient, which only requires its public key.
+const SealedBoxCipher = "x_sealedbox"
+
+const (
+	cryptoboxKeySize   = 32
+	cryptoboxNonceSize = 24
+)
+
+// CryptoboxKeys are the keys one peer of an end-to-end encrypted exchange
+// uses: its own private key and the public key of the other peer.
+type CryptoboxKeys struct {
+	PrivateKey    []byte
+	PeerPublicKey []byte
+}
+
+// Wipe overwrites the private key with zeros once it is no longer needed.
+func (k *CryptoboxKeys) Wipe() {
+	if k != nil {
+		clear(k.PrivateKey)
+	}
+}
+
+// GenerateCryptoboxKey returns a new random Curve25519 key pair.
+func GenerateCryptoboxKey() (publicKey, privateKey []byte, err error) {
+	public, private, err := box.GenerateKey(RandomReader())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return public[:], private[:], nil
+}
+
+// cryptoboxKey converts key into the array form nacl expects.
+func cryptoboxKey(key []byte, name string) (*[cryptoboxKeySize]byte, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("%s is required", name)
+	} else if len(key) != cryptoboxKeySize {
+		return nil, fmt.Errorf("%s must be %d bytes, was %d", name, cryptoboxKeySize, len(key))
+	}
+
+	return (*[cryptoboxKeySize]byte)(key), nil
+}
+
+// EncryptPayload encrypts a serialized payload for the peer whose public key
+// is part of keys. The result is the random nonce followed by the ciphertext.
+func EncryptPayload(payload []byte, keys CryptoboxKeys) ([]byte, error) {
+	privateKey, err := cryptoboxKey(keys.PrivateKey, "private key")
+	if err != nil {
+		return nil, err
+	}
+
+	peerPublicKey, err := cryptoboxKey(keys.PeerPublicKey, "public key")
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [cryptoboxNonceSize]byte
+	if _, err = io.ReadFull(RandomReader(), nonce[:]); err != nil {
+		return nil, err
+	}
+
+	return box.Seal(nonce[:], payload, &nonce, peerPublicKey, privateKey), nil
+}
+
+// DecryptPayload reverses EncryptPayload, keys being those of the receiving
+// peer.
+func DecryptPayload(ciphertext []byte, keys CryptoboxKeys) ([]byte, error) {
+	privateKey, err := cryptoboxKey(keys.PrivateKey, "private key")
+	if err != nil {
+		return nil, err
+	}
+
+	peerPublicKey, err := cryptoboxKey(keys.PeerPublicKey, "public key")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < cryptoboxNonceSize+box.Overhead {
+		return nil, fmt.Errorf("ciphertext must be at least %d bytes, was %d", cryptoboxNonceSize+box.Overhead,
+			len(ciphertext))
+	}
+
+	nonce := (*[cryptoboxNonceSize]byte)(ciphertext[:cryptoboxNonceSize])
+	payload, ok := box.Open(nil, ciphertext[cryptoboxNonceSize:], nonce, peerPublicKey, privateKey)
+	if !ok {
+		return nil, errors.New("failed to decrypt payload: authentication failed")
+	}
+
+	return payload, nil
+}
+
+// CryptoboxKeysFromHex decodes hex encoded keys, either of which may be
+// empty. It returns nil if neither key is given.
+func CryptoboxKeysFromHex(privateKey, peerPublicKey string) (*CryptoboxKeys, error) {
+	if privateKey == "" && peerPublicKey == "" {
+		return nil, nil
+	}
+
+	private, err := DecodeSecretHex(privateKey, "private key")
+	if err != nil {
+		return nil, err
+	}
+
+	peerPublic, err := hex.DecodeString(peerPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("public key must be hex encoded: %w", err)
+	}
+
+	return &CryptoboxKeys{PrivateKey: private, PeerPublicKey: peerPublic}, nil
+}
+
+// SealPayload encrypts a serialized payload anonymously for the recipient
+// with recipientPublicKey, using an ephemeral key pair. Only the recipient
+// can open it, but it cannot tell who sealed it.
+func SealPayload(payload, recipientPublicKey []byte) ([]byte, error) {
+	publicKey, err := cryptoboxKey(recipientPublicKey, "public key")
+	if err != nil {
+		return nil, err
+	}
+
+	return box.SealAnonymous(nil, payload, publicKey, RandomReader())
+}
+
+// OpenPayload reverses SealPayload with the recipient's private key.
+func OpenPayload(ciphertext, privateKey []byte) ([]byte, error) {
+	private, err := cryptoboxKey(privateKey, "private key")
+	if err != nil {
+		return nil, err
+	}
+
+	public, err := curve25519.X25519(private[:], curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, ok := box.OpenAnonymous(nil, ciphertext, (*[cryptoboxKeySize]byte)(public), private)
+	if !ok {
+		return nil, errors.New("failed to open sealed payload: authentication failed")
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,130 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// The known-answer vector of the NaCl distribution (tests/box.c): Alice
+// encrypts for Bob with a fixed nonce.
+const (
+	aliceSecretKey = "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a"
+	alicePublicKey = "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a"
+	bobSecretKey   = "5dab087e624a8a4b79e17f8b83800ee66f3bb1292618b6fd1c2f8b27ff88e0eb"
+	bobPublicKey   = "de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f"
+	boxNonce       = "69696ee955b62b73cd62bda875fc73d68219e0036b7a0b37"
+	boxPlaintext   = "be075fc53c81f2d5cf141316ebeb0c7b5228c52a4c62cbd44b66849b64244ffce5ecbaaf33bd751a1ac728d45e6c" +
+		"61296cdc3c01233561f41db66cce314adb310e3be8250c46f06dceea3a7fa1348057e2f6556ad6b1318a024a838f21af1fde04" +
+		"8977eb48f59ffd4924ca1c60902e52f0a089bc76897040e082f937763848645e0705"
+	boxCiphertext = "f3ffc7703f9400e52a7dfb4b3d3305d98e993b9f48681273c29650ba32fc76ce48332ea7164d96a4476fb8c531a1186a" +
+		"c0dfc17c98dce87b4da7f011ec48c97271d2c20f9b928fe2270d6fb863d51738b48eeee314a7cc8ab932164548e526ae9022" +
+		"4368517acfeabd6bb3732bc0e9da99832b61ca01b6de56244a9e88d5f9b37973f622a43d14a6599b1f654cb45a74e355a5"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestEncryptPayloadKnownAnswer(t *testing.T) {
+	nonce := mustDecodeHex(t, boxNonce)
+	previous := SetRandomSource(bytes.NewReader(nonce))
+	defer SetRandomSource(previous)
+
+	alice := CryptoboxKeys{PrivateKey: mustDecodeHex(t, aliceSecretKey), PeerPublicKey: mustDecodeHex(t, bobPublicKey)}
+	ciphertext, err := EncryptPayload(mustDecodeHex(t, boxPlaintext), alice)
+	if err != nil {
+		t.Fatalf("EncryptPayload: %v", err)
+	}
+
+	expected := append(nonce, mustDecodeHex(t, boxCiphertext)...)
+	if !bytes.Equal(ciphertext, expected) {
+		t.Fatalf("expected %x, got %x", expected, ciphertext)
+	}
+
+	bob := CryptoboxKeys{PrivateKey: mustDecodeHex(t, bobSecretKey), PeerPublicKey: mustDecodeHex(t, alicePublicKey)}
+	payload, err := DecryptPayload(ciphertext, bob)
+	if err != nil {
+		t.Fatalf("DecryptPayload: %v", err)
+	}
+
+	if hex.EncodeToString(payload) != boxPlaintext {
+		t.Errorf("expected %s, got %x", boxPlaintext, payload)
+	}
+}
+
+func TestDecryptPayloadInvalid(t *testing.T) {
+	ciphertext := append(mustDecodeHex(t, boxNonce), mustDecodeHex(t, boxCiphertext)...)
+	bob := CryptoboxKeys{PrivateKey: mustDecodeHex(t, bobSecretKey), PeerPublicKey: mustDecodeHex(t, alicePublicKey)}
+
+	for _, test := range []struct {
+		name       string
+		ciphertext func() []byte
+		keys       CryptoboxKeys
+	}{
+		{"tampered ciphertext", func() []byte {
+			tampered := bytes.Clone(ciphertext)
+			tampered[len(tampered)-1] ^= 1
+			return tampered
+		}, bob},
+		{"tampered nonce", func() []byte {
+			tampered := bytes.Clone(ciphertext)
+			tampered[0] ^= 1
+			return tampered
+		}, bob},
+		{"tampered tag", func() []byte {
+			tampered := bytes.Clone(ciphertext)
+			tampered[cryptoboxNonceSize] ^= 1
+			return tampered
+		}, bob},
+		{"wrong private key", func() []byte { return ciphertext },
+			CryptoboxKeys{PrivateKey: mustDecodeHex(t, aliceSecretKey), PeerPublicKey: bob.PeerPublicKey}},
+		{"wrong peer public key", func() []byte { return ciphertext },
+			CryptoboxKeys{PrivateKey: bob.PrivateKey, PeerPublicKey: mustDecodeHex(t, bobPublicKey)}},
+		{"truncated ciphertext", func() []byte { return ciphertext[:len(ciphertext)-1] }, bob},
+		{"nonce and tag only", func() []byte { return ciphertext[:cryptoboxNonceSize+15] }, bob},
+		{"empty", func() []byte { return nil }, bob},
+		{"short private key", func() []byte { return ciphertext },
+			CryptoboxKeys{PrivateKey: bob.PrivateKey[:31], PeerPublicKey: bob.PeerPublicKey}},
+		{"missing peer public key", func() []byte { return ciphertext }, CryptoboxKeys{PrivateKey: bob.PrivateKey}},
+	} {
+		if payload, err := DecryptPayload(test.ciphertext(), test.keys); err == nil {
+			t.Errorf("%s: expected an error, got %x", test.name, payload)
+		}
+	}
+}
+
+func TestOpenPayload(t *testing.T) {
+	payload := []byte("payload")
+	sealed, err := SealPayload(payload, mustDecodeHex(t, bobPublicKey))
+	if err != nil {
+		t.Fatalf("SealPayload: %v", err)
+	}
+
+	if opened, err := OpenPayload(sealed, mustDecodeHex(t, bobSecretKey)); err != nil || !bytes.Equal(opened, payload) {
+		t.Fatalf("OpenPayload: expected %s, got %s, %v", payload, opened, err)
+	}
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+	for _, test := range []struct {
+		name       string
+		ciphertext []byte
+		privateKey string
+	}{
+		{"tampered", tampered, bobSecretKey},
+		{"wrong private key", sealed, aliceSecretKey},
+		{"truncated", sealed[:len(sealed)-1], bobSecretKey},
+		{"shorter than the ephemeral key", sealed[:16], bobSecretKey},
+	} {
+		if opened, err := OpenPayload(test.ciphertext, mustDecodeHex(t, test.privateKey)); err == nil {
+			t.Errorf("%s: expected an error, got %s", test.name, opened)
+		}
+	}
+}
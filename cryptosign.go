@@ -0,0 +1,70 @@
+package wampprotocli
+
+import (
+	"crypto/ed25519"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/xconnio/wampproto-go/auth"
+)
+
+// KeyPair is an ed25519 key pair as used by cryptosign.
+type KeyPair struct {
+	PublicKey  ed25519.PublicKey
+	PrivateKey ed25519.PrivateKey
+}
+
+// Seed returns the 32 byte private key seed, which is what cryptosign
+// implementations exchange as the "private key".
+func (k *KeyPair) Seed() []byte {
+	return k.PrivateKey.Seed()
+}
+
+// Wipe overwrites the private key with zeros once it is no longer needed.
+func (k *KeyPair) Wipe() {
+	if k != nil {
+		clear(k.PrivateKey)
+	}
+}
+
+// KeyPairFromSeed derives the key pair belonging to a private key seed.
+func KeyPairFromSeed(seed []byte) (*KeyPair, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("private key must be %d bytes, was %d", ed25519.SeedSize, len(seed))
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	return &KeyPair{PublicKey: privateKey.Public().(ed25519.PublicKey), PrivateKey: privateKey}, nil
+}
+
+// SignChallenge signs a cryptosign challenge and returns the signature
+// followed by the challenge, as carried in AUTHENTICATE.
+func SignChallenge(challenge []byte, keyPair *KeyPair) ([]byte, error) {
+	signed, err := auth.SignCryptoSignChallenge(hex.EncodeToString(challenge), keyPair.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return hex.DecodeString(signed)
+}
+
+// VerifySignature checks a signature produced by SignChallenge.
+func VerifySignature(signature []byte, publicKey ed25519.PublicKey) (bool, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false, fmt.Errorf("public key must be %d bytes, was %d", ed25519.PublicKeySize, len(publicKey))
+	}
+
+	return auth.VerifyCryptoSignSignature(hex.EncodeToString(signature), publicKey)
+}
+
+// VerifyChallengeSignature checks a signature produced by SignChallenge and
+// that the challenge it signs is challenge, comparing them in constant time.
+func VerifyChallengeSignature(signature, challenge []byte, publicKey ed25519.PublicKey) (bool, error) {
+	valid, err := VerifySignature(signature, publicKey)
+	if err != nil || !valid {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(signature[ed25519.SignatureSize:], challenge) == 1, nil
+}
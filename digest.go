@@ -0,0 +1,58 @@
+package wampprotocli
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// Algorithms of message digests.
+const (
+	SHA256Digest     = "sha256"
+	HMACSHA256Digest = "hmac-sha256"
+)
+
+// DigestAlgorithms returns the algorithms messages are digested with.
+func DigestAlgorithms() []string {
+	return []string{SHA256Digest, HMACSHA256Digest}
+}
+
+// Digest returns the digest of the serialized message data, a checksum for
+// sha256 and an integrity tag keyed with key for hmac-sha256.
+func Digest(data []byte, algorithm string, key []byte) ([]byte, error) {
+	switch algorithm {
+	case SHA256Digest:
+		if len(key) > 0 {
+			return nil, fmt.Errorf("%s takes no key", algorithm)
+		}
+
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	case HMACSHA256Digest:
+		if len(key) == 0 {
+			return nil, fmt.Errorf("%s requires a key", algorithm)
+		}
+
+		mac := hmac.New(sha256.New, key)
+		mac.Write(data)
+		return mac.Sum(nil), nil
+	default:
+		return nil, fmt.Errorf("unknown digest algorithm %q, must be one of %v", algorithm, DigestAlgorithms())
+	}
+}
+
+// VerifyDigest returns the digest of data and an error if it differs from
+// expected. Digests are compared in constant time.
+func VerifyDigest(data []byte, algorithm string, key, expected []byte) ([]byte, error) {
+	digest, err := Digest(data, algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(digest, expected) {
+		return digest, errors.New("digest does not match")
+	}
+
+	return digest, nil
+}
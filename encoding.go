@@ -0,0 +1,39 @@
+package wampprotocli
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	HexOutput    = "hex"
+	Base64Output = "base64"
+)
+
+// EncodeBytes renders data in the requested textual encoding.
+func EncodeBytes(data []byte, encoding string) (string, error) {
+	switch encoding {
+	case HexOutput, "":
+		return hex.EncodeToString(data), nil
+	case Base64Output:
+		return base64.StdEncoding.EncodeToString(data), nil
+	default:
+		return "", fmt.Errorf("unknown output encoding %q", encoding)
+	}
+}
+
+// DecodeBytes decodes a hex or base64 encoded string. Hex is tried first
+// because every hex string is also valid base64.
+func DecodeBytes(input string) ([]byte, error) {
+	if data, err := hex.DecodeString(input); err == nil {
+		return data, nil
+	}
+
+	if data, err := base64.StdEncoding.DecodeString(input); err == nil {
+		return data, nil
+	}
+
+	return nil, errors.New("input must be hex or base64 encoded")
+}
@@ -0,0 +1,268 @@
+package wampprotocli
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// diffRowSize is the number of bytes per row of byte diffs.
+const diffRowSize = 16
+
+// DiffBytes returns the rows of a hex dump of expected and actual, marking
+// the rows that differ with - for expected and + for actual, or nil if they
+// are equal.
+func DiffBytes(expected, actual []byte) []string {
+	if string(expected) == string(actual) {
+		return nil
+	}
+
+	var lines []string
+	for offset := 0; offset < max(len(expected), len(actual)); offset += diffRowSize {
+		expectedRow, actualRow := diffRow(expected, offset), diffRow(actual, offset)
+		if string(expectedRow) == string(actualRow) {
+			lines = append(lines, fmt.Sprintf("  %08x  % x", offset, expectedRow))
+			continue
+		}
+
+		if len(expectedRow) > 0 {
+			lines = append(lines, fmt.Sprintf("- %08x  % x", offset, expectedRow))
+		}
+
+		if len(actualRow) > 0 {
+			lines = append(lines, fmt.Sprintf("+ %08x  % x", offset, actualRow))
+		}
+	}
+
+	return append(lines, fmt.Sprintf("expected %d bytes, got %d", len(expected), len(actual)))
+}
+
+func diffRow(data []byte, offset int) []byte {
+	if offset >= len(data) {
+		return nil
+	}
+
+	return data[offset:min(offset+diffRowSize, len(data))]
+}
+
+// DiffMessages compares the positional messages expected and actual by
+// content, so that map key order, the width numbers are encoded with and
+// whether integral numbers are encoded as floats don't matter. It returns a
+// line for every field that differs, or nil if they are equal.
+func DiffMessages(expected, actual []any) []string {
+	var lines []string
+	diffValues("", normalizeValue(expected), normalizeValue(actual), &lines)
+	return lines
+}
+
+func diffValues(path string, expected, actual any, lines *[]string) {
+	expectedList, expectedIsList := expected.([]any)
+	actualList, actualIsList := actual.([]any)
+	if expectedIsList && actualIsList {
+		for i := 0; i < max(len(expectedList), len(actualList)); i++ {
+			field := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(actualList):
+				*lines = append(*lines, fmt.Sprintf("%s: expected %s, missing", field, diffValue(expectedList[i])))
+			case i >= len(expectedList):
+				*lines = append(*lines, fmt.Sprintf("%s: unexpected %s", field, diffValue(actualList[i])))
+			default:
+				diffValues(field, expectedList[i], actualList[i], lines)
+			}
+		}
+
+		return
+	}
+
+	expectedMap, expectedIsMap := expected.(map[string]any)
+	actualMap, actualIsMap := actual.(map[string]any)
+	if expectedIsMap && actualIsMap {
+		for _, key := range sortedKeys(expectedMap) {
+			field := path + "." + key
+			if value, ok := actualMap[key]; ok {
+				diffValues(field, expectedMap[key], value, lines)
+			} else {
+				*lines = append(*lines, fmt.Sprintf("%s: expected %s, missing", field,
+					diffValue(expectedMap[key])))
+			}
+		}
+
+		for _, key := range sortedKeys(actualMap) {
+			if _, ok := expectedMap[key]; !ok {
+				*lines = append(*lines, fmt.Sprintf("%s.%s: unexpected %s", path, key, diffValue(actualMap[key])))
+			}
+		}
+
+		return
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		*lines = append(*lines, fmt.Sprintf("%s: expected %s, got %s", path, diffValue(expected),
+			diffValue(actual)))
+	}
+}
+
+func diffValue(value any) string {
+	// JSON would replace invalid UTF-8 and hide the difference.
+	if s, ok := value.(string); ok && !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(data)
+}
+
+// normalizeValue returns value with integral numbers as int64 and other
+// numbers as float64, as serializers differ in the types they decode
+// numbers as.
+func normalizeValue(value any) any {
+	switch v := value.(type) {
+	case []any:
+		normalized := make([]any, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeValue(item)
+		}
+
+		return normalized
+	case map[string]any:
+		normalized := make(map[string]any, len(v))
+		for key, item := range v {
+			normalized[key] = normalizeValue(item)
+		}
+
+		return normalized
+	case float32, float64:
+		f := asFloat(v)
+		if f == math.Trunc(f) && math.Abs(f) < math.MaxInt64 {
+			return int64(f)
+		}
+
+		return f
+	case uint64:
+		if v > math.MaxInt64 {
+			return v
+		}
+
+		return int64(v)
+	default:
+		if i, ok := messages.AsInt64(v); ok {
+			return i
+		}
+
+		return v
+	}
+}
+
+// DiffRoundTrip deserializes data, the positional message raw serialized with
+// serializer, and compares the result with raw as DiffMessages does, so that
+// fields lost or altered by serializing show.
+func DiffRoundTrip(serializer string, raw []any, data []byte) ([]string, error) {
+	deserialized, err := DeserializeRaw(serializer, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize the serialized message: %w", err)
+	}
+
+	return DiffMessages(raw, deserialized), nil
+}
+
+// DiffSerialized decodes the hex or base64 encoded messages first and
+// second, serialized with the serializers of the same names, and compares
+// them as DiffMessages does. Binary values compare equal whether they are
+// carried natively or as JSON strings.
+func DiffSerialized(firstSerializer, first, secondSerializer, second string) ([]string, error) {
+	firstRaw, err := decodeSerialized(firstSerializer, first)
+	if err != nil {
+		return nil, fmt.Errorf("invalid first message: %w", err)
+	}
+
+	secondRaw, err := decodeSerialized(secondSerializer, second)
+	if err != nil {
+		return nil, fmt.Errorf("invalid second message: %w", err)
+	}
+
+	converted, _ := convertBinary(secondRaw, secondSerializer, firstSerializer).([]any)
+	return DiffMessages(firstRaw, converted), nil
+}
+
+func decodeSerialized(serializer, encoded string) ([]any, error) {
+	data, err := DecodeBytes(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	return DeserializeRaw(serializer, data)
+}
+
+// ExpectFields compares the fields of the positional message raw, keyed as
+// NamedFields keys them, with expected and returns a line for every field
+// that differs, compared as DiffMessages compares them. A key matches the
+// field of that key or the single field whose key ends with _ and the key,
+// e.g. request_id matches call_request_id of RESULT. A key options.NAME
+// checks the entry NAME of the options or details only.
+func ExpectFields(raw []any, expected map[string]any) []string {
+	fields := NamedFields(raw)
+
+	var lines []string
+	for _, key := range sortedKeys(expected) {
+		want := normalizeValue(expected[key])
+		fieldKey, option, isOption := strings.Cut(key, ".")
+		if !isOption || fieldKey != "options" {
+			fieldKey, option, isOption = key, "", false
+		}
+
+		value, found := expectedField(fields, fieldKey, isOption)
+		if found && isOption {
+			value, found = value.(map[string]any)[option]
+		}
+
+		if !found {
+			lines = append(lines, fmt.Sprintf("%s: expected %s, missing", key, diffValue(want)))
+			continue
+		}
+
+		diffValues(key, want, normalizeValue(value), &lines)
+	}
+
+	return lines
+}
+
+// expectedField returns the field of fields matching key as ExpectFields
+// describes, or the options or details dictionary if options is set.
+func expectedField(fields map[string]any, key string, options bool) (any, bool) {
+	if options {
+		for _, name := range []string{"options", "details"} {
+			if value, ok := fields[name].(map[string]any); ok {
+				return value, true
+			}
+		}
+
+		return nil, false
+	}
+
+	if value, ok := fields[key]; ok {
+		return value, true
+	}
+
+	var matches []string
+	for name := range fields {
+		if strings.HasSuffix(name, "_"+key) {
+			matches = append(matches, name)
+		}
+	}
+
+	if len(matches) != 1 {
+		return nil, false
+	}
+
+	return fields[matches[0]], true
+}
@@ -0,0 +1,180 @@
+package wampprotocli
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// ExplainedField is one positional field of a message with its meaning.
+type ExplainedField struct {
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Value    any    `json:"value"`
+	Spec     string `json:"spec"`
+}
+
+type fieldSchema struct {
+	name     string
+	dataType string
+}
+
+// messageSchema returns the fields following the message type of a message
+// type, named as in the spec, and the spec section defining it.
+func messageSchema(messageType int) ([]fieldSchema, string) {
+	payload := []fieldSchema{{"Arguments", "list"}, {"ArgumentsKw", "dict"}}
+
+	switch messageType {
+	case messages.MessageTypeHello:
+		return []fieldSchema{{"Realm", "uri"}, {"Details", "dict"}}, SpecSessionEstablishment
+	case messages.MessageTypeWelcome:
+		return []fieldSchema{{"Session", "id"}, {"Details", "dict"}}, SpecSessionEstablishment
+	case messages.MessageTypeAbort:
+		return []fieldSchema{{"Details", "dict"}, {"Reason", "uri"}}, SpecSessionEstablishment
+	case messages.MessageTypeChallenge:
+		return []fieldSchema{{"AuthMethod", "string"}, {"Extra", "dict"}}, SpecAuthentication
+	case messages.MessageTypeAuthenticate:
+		return []fieldSchema{{"Signature", "string"}, {"Extra", "dict"}}, SpecAuthentication
+	case messages.MessageTypeGoodbye:
+		return []fieldSchema{{"Details", "dict"}, {"Reason", "uri"}}, SpecSessionClosing
+	case messages.MessageTypeError:
+		return append([]fieldSchema{{"REQUEST.Type", "int"}, {"REQUEST.Request", "id"}, {"Details", "dict"},
+			{"Error", "uri"}}, payload...), SpecErrors
+	case messages.MessageTypePublish:
+		return append([]fieldSchema{{"Request", "id"}, {"Options", "dict"}, {"Topic", "uri"}}, payload...),
+			SpecPublishing
+	case messages.MessageTypePublished:
+		return []fieldSchema{{"PUBLISH.Request", "id"}, {"Publication", "id"}}, SpecPublishing
+	case messages.MessageTypeSubscribe:
+		return []fieldSchema{{"Request", "id"}, {"Options", "dict"}, {"Topic", "uri"}}, SpecSubscribing
+	case messages.MessageTypeSubscribed:
+		return []fieldSchema{{"SUBSCRIBE.Request", "id"}, {"Subscription", "id"}}, SpecSubscribing
+	case messages.MessageTypeUnSubscribe:
+		return []fieldSchema{{"Request", "id"}, {"SUBSCRIBED.Subscription", "id"}}, SpecSubscribing
+	case messages.MessageTypeUnSubscribed:
+		return []fieldSchema{{"UNSUBSCRIBE.Request", "id"}}, SpecSubscribing
+	case messages.MessageTypeEvent:
+		return append([]fieldSchema{{"SUBSCRIBED.Subscription", "id"}, {"PUBLISHED.Publication", "id"},
+			{"Details", "dict"}}, payload...), SpecPublishing
+	case messages.MessageTypeCall:
+		return append([]fieldSchema{{"Request", "id"}, {"Options", "dict"}, {"Procedure", "uri"}}, payload...),
+			SpecCalling
+	case messages.MessageTypeCancel:
+		return []fieldSchema{{"CALL.Request", "id"}, {"Options", "dict"}}, SpecCanceling
+	case messages.MessageTypeResult:
+		return append([]fieldSchema{{"CALL.Request", "id"}, {"Details", "dict"}}, payload...), SpecCalling
+	case messages.MessageTypeRegister:
+		return []fieldSchema{{"Request", "id"}, {"Options", "dict"}, {"Procedure", "uri"}}, SpecRegistering
+	case messages.MessageTypeRegistered:
+		return []fieldSchema{{"REGISTER.Request", "id"}, {"Registration", "id"}}, SpecRegistering
+	case messages.MessageTypeUnRegister:
+		return []fieldSchema{{"Request", "id"}, {"REGISTERED.Registration", "id"}}, SpecRegistering
+	case messages.MessageTypeUnRegistered:
+		return []fieldSchema{{"UNREGISTER.Request", "id"}}, SpecRegistering
+	case messages.MessageTypeInvocation:
+		return append([]fieldSchema{{"Request", "id"}, {"REGISTERED.Registration", "id"}, {"Details", "dict"}},
+			payload...), SpecCalling
+	case messages.MessageTypeInterrupt:
+		return []fieldSchema{{"INVOCATION.Request", "id"}, {"Options", "dict"}}, SpecCanceling
+	case messages.MessageTypeYield:
+		return append([]fieldSchema{{"INVOCATION.Request", "id"}, {"Options", "dict"}}, payload...), SpecCalling
+	default:
+		return nil, ""
+	}
+}
+
+// ExplainMessage names every field of the positional message raw, with its
+// spec type and the spec section defining it. The arguments of messages
+// whose options set ppt_scheme are explained as carrying the single binary
+// payload of Payload Passthru Mode.
+func ExplainMessage(raw []any) []ExplainedField {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	messageType := rawMessageType(raw)
+	schema, spec := messageSchema(messageType)
+	fields := []ExplainedField{{
+		Position: 0,
+		Name:     "MessageType",
+		Type:     "int",
+		Value:    raw[0],
+		Spec:     SpecMessageType,
+	}}
+
+	ppt := false
+	if position, _ := optionKeys(messageType); position > 0 && position < len(raw) {
+		options, _ := raw[position].(map[string]any)
+		_, ppt = options[pptSchemeOption]
+	}
+
+	for i, value := range raw[1:] {
+		field := ExplainedField{Position: i + 1, Name: "unknown", Type: "unknown", Value: value, Spec: spec}
+		if i < len(schema) {
+			field.Name, field.Type = schema[i].name, schema[i].dataType
+		}
+
+		if ppt && field.Name == "Arguments" {
+			field.Type = "list[Payload|binary]"
+			field.Spec = SpecPayloadPassthru
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// NamedFields returns the fields of the positional message raw following its
+// type by stable snake case keys derived from their spec names, e.g.
+// request_id for Request, arguments_kw for ArgumentsKw and call_request_id
+// for CALL.Request. Fields the spec doesn't define are keyed by position,
+// e.g. field_5.
+func NamedFields(raw []any) map[string]any {
+	fields := map[string]any{}
+	for _, field := range ExplainMessage(raw) {
+		if field.Position == 0 {
+			continue
+		}
+
+		key := "field_" + strconv.Itoa(field.Position)
+		if field.Name != "unknown" {
+			key = fieldKey(field.Name, field.Type)
+		}
+
+		fields[key] = field.Value
+	}
+
+	return fields
+}
+
+// fieldKey returns the snake case key of the field with the spec name and
+// type, suffixing the names of IDs that don't say so with _id. Prefixes
+// repeating the name, as in REQUEST.Request, are dropped.
+func fieldKey(name, dataType string) string {
+	if prefix, rest, ok := strings.Cut(name, "."); ok && strings.EqualFold(prefix, rest) {
+		name = rest
+	}
+
+	var key strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '.':
+			key.WriteByte('_')
+		case unicode.IsUpper(r) && i > 0 && name[i-1] != '.' && !unicode.IsUpper(rune(name[i-1])):
+			key.WriteByte('_')
+			key.WriteRune(unicode.ToLower(r))
+		default:
+			key.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	if dataType == "id" && !strings.HasSuffix(key.String(), "_id") {
+		key.WriteString("_id")
+	}
+
+	return key.String()
+}
@@ -0,0 +1,122 @@
+package wampprotocli
+
+import (
+	"time"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// ExportedMessage is a transcript record with the fields of its message
+// flattened into named fields, as log analysis tools like Elasticsearch
+// index them.
+type ExportedMessage struct {
+	Timestamp      time.Time      `json:"@timestamp"`
+	Session        string         `json:"session"`
+	Direction      string         `json:"direction"`
+	Serializer     string         `json:"serializer"`
+	Size           int            `json:"size"`
+	Type           int            `json:"message_type,omitempty"`
+	Name           string         `json:"message_name,omitempty"`
+	RequestID      *int64         `json:"request_id,omitempty"`
+	SessionID      *int64         `json:"session_id,omitempty"`
+	PublicationID  *int64         `json:"publication_id,omitempty"`
+	SubscriptionID *int64         `json:"subscription_id,omitempty"`
+	RegistrationID *int64         `json:"registration_id,omitempty"`
+	URI            string         `json:"uri,omitempty"`
+	Options        map[string]any `json:"options,omitempty"`
+	Args           []any          `json:"args,omitempty"`
+	Kwargs         map[string]any `json:"kwargs,omitempty"`
+	// DecodeError explains why the message could not be decoded.
+	DecodeError string `json:"decode_error,omitempty"`
+}
+
+// ExportRecord flattens record into an ExportedMessage. The URI is the realm
+// of HELLO, the reason of ABORT and GOODBYE, the error of ERROR and the
+// procedure or topic of other messages.
+func ExportRecord(record *TranscriptRecord) *ExportedMessage {
+	exported := &ExportedMessage{
+		Timestamp:  record.Time,
+		Session:    record.Session,
+		Direction:  record.Direction,
+		Serializer: record.Serializer,
+		Size:       len(record.Data),
+	}
+
+	raw, err := DeserializeRaw(record.Serializer, record.Data)
+	if err != nil {
+		exported.DecodeError = err.Error()
+		return exported
+	}
+
+	exported.Type = rawMessageType(raw)
+	exported.Name = MessageName(exported.Type)
+
+	for _, field := range idFields(exported.Type) {
+		if field.position >= len(raw) {
+			continue
+		}
+
+		id, ok := messages.AsInt64(raw[field.position])
+		if !ok {
+			continue
+		}
+
+		switch field.name {
+		case requestIDField:
+			exported.RequestID = &id
+		case sessionIDField:
+			exported.SessionID = &id
+		case publicationIDField:
+			exported.PublicationID = &id
+		case subscriptionIDField:
+			exported.SubscriptionID = &id
+		case registrationIDField:
+			exported.RegistrationID = &id
+		}
+	}
+
+	optionsPosition, uriPosition, argsPosition := exportPositions(exported.Type)
+	if optionsPosition > 0 && optionsPosition < len(raw) {
+		exported.Options, _ = raw[optionsPosition].(map[string]any)
+	}
+
+	if uriPosition > 0 {
+		exported.URI, _ = rawString(raw, uriPosition)
+	}
+
+	if argsPosition > 0 && argsPosition < len(raw) {
+		exported.Args, _ = raw[argsPosition].([]any)
+	}
+
+	if argsPosition > 0 && argsPosition+1 < len(raw) {
+		exported.Kwargs, _ = raw[argsPosition+1].(map[string]any)
+	}
+
+	return exported
+}
+
+// exportPositions returns the positions of the options or details, of the
+// URI and of the arguments of a message type, each 0 if it has none.
+func exportPositions(messageType int) (options, uri, args int) {
+	switch messageType {
+	case messages.MessageTypeHello:
+		return 2, 1, 0
+	case messages.MessageTypeAbort, messages.MessageTypeGoodbye:
+		return 1, 2, 0
+	case messages.MessageTypeError:
+		return 3, 4, 5
+	case messages.MessageTypeSubscribe, messages.MessageTypeRegister:
+		return 2, 3, 0
+	default:
+		options, args = payloadPositions(messageType)
+		if messageType == messages.MessageTypeCall || messageType == messages.MessageTypePublish {
+			uri = 3
+		}
+
+		if options == 0 {
+			options, _ = optionKeys(messageType)
+		}
+
+		return options, uri, args
+	}
+}
@@ -0,0 +1,135 @@
+package wampprotocli
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// WAMP roles.
+const (
+	callerRole     = "caller"
+	calleeRole     = "callee"
+	publisherRole  = "publisher"
+	subscriberRole = "subscriber"
+	dealerRole     = "dealer"
+	brokerRole     = "broker"
+)
+
+// featureRoles returns the official feature registry, mapping every feature
+// of the WAMP advanced profile to the roles that may announce it.
+func featureRoles() map[string][]string {
+	rpc := []string{callerRole, calleeRole, dealerRole}
+	callee := []string{calleeRole, dealerRole}
+	pubSub := []string{publisherRole, subscriberRole, brokerRole}
+	subscriber := []string{subscriberRole, brokerRole}
+
+	return map[string][]string{
+		"progressive_call_results":      rpc,
+		"progressive_call_invocations":  rpc,
+		"call_timeout":                  rpc,
+		"call_canceling":                rpc,
+		"caller_identification":         rpc,
+		"call_trustlevels":              callee,
+		"pattern_based_registration":    callee,
+		"shared_registration":           callee,
+		"sharded_registration":          callee,
+		"registration_revocation":       callee,
+		"registration_meta_api":         {dealerRole},
+		"procedure_reflection":          {dealerRole},
+		"subscriber_blackwhite_listing": {publisherRole, brokerRole},
+		"publisher_exclusion":           {publisherRole, brokerRole},
+		"publisher_identification":      pubSub,
+		"publication_trustlevels":       subscriber,
+		"pattern_based_subscription":    subscriber,
+		"sharded_subscription":          subscriber,
+		"event_history":                 subscriber,
+		"subscription_revocation":       subscriber,
+		"subscription_meta_api":         {brokerRole},
+		"topic_reflection":              {brokerRole},
+		"event_retention":               {brokerRole},
+		"session_meta_api":              {dealerRole, brokerRole},
+		"payload_passthru_mode":         {callerRole, calleeRole, dealerRole, publisherRole, subscriberRole, brokerRole},
+	}
+}
+
+// validateRoles checks the roles announced by HELLO and WELCOME messages and
+// the features announced for each of them against the feature registry.
+func validateRoles(messageType int, raw []any) []Warning {
+	var allowedRoles []string
+	switch messageType {
+	case messages.MessageTypeHello:
+		allowedRoles = []string{callerRole, calleeRole, publisherRole, subscriberRole}
+	case messages.MessageTypeWelcome:
+		allowedRoles = []string{dealerRole, brokerRole}
+	default:
+		return nil
+	}
+
+	const detailsPosition = 2
+	if detailsPosition >= len(raw) {
+		return nil
+	}
+
+	details, _ := raw[detailsPosition].(map[string]any)
+	roles, ok := details["roles"].(map[string]any)
+	if !ok {
+		return []Warning{{Category: CategoryFeature,
+			Message: fmt.Sprintf("%s must announce roles", MessageName(messageType))}}
+	}
+
+	var warnings []Warning
+	warn := func(format string, args ...any) {
+		warnings = append(warnings, Warning{Category: CategoryFeature, Message: fmt.Sprintf(format, args...)})
+	}
+
+	registry := featureRoles()
+	for _, role := range sortedKeys(roles) {
+		if !slices.Contains(allowedRoles, role) {
+			warn("unknown role %q in %s, must be one of %v", role, MessageName(messageType), allowedRoles)
+			continue
+		}
+
+		roleDetails, ok := roles[role].(map[string]any)
+		if !ok {
+			warn("role %q must be a dictionary", role)
+			continue
+		}
+
+		features, ok := roleDetails["features"].(map[string]any)
+		if !ok {
+			if _, present := roleDetails["features"]; present {
+				warn("features of role %q must be a dictionary", role)
+			}
+
+			continue
+		}
+
+		for _, feature := range sortedKeys(features) {
+			definedFor, known := registry[feature]
+			switch {
+			case !known:
+				warn("unknown feature %q announced for role %q", feature, role)
+			case !slices.Contains(definedFor, role):
+				warn("feature %q is not defined for role %q, only for %v", feature, role, definedFor)
+			}
+
+			if _, ok := features[feature].(bool); !ok {
+				warn("feature %q of role %q must be a boolean", feature, role)
+			}
+		}
+	}
+
+	return warnings
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,136 @@
+package wampprotocli
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Framings of streams of serialized messages.
+const (
+	// LineFraming carries one hex or base64 encoded message per line. Blank
+	// lines and lines starting with # are skipped.
+	LineFraming = "lines"
+	// LengthFraming prefixes every raw message with its length as a 32-bit
+	// big-endian integer.
+	LengthFraming = "length"
+)
+
+// maxFramedMessageSize is the largest message a MessageReader accepts, which
+// is the largest RawSocket allows.
+const maxFramedMessageSize = 16 << 20
+
+const lengthPrefixSize = 4
+
+// Framings returns the names of the supported framings.
+func Framings() []string {
+	return []string{LineFraming, LengthFraming}
+}
+
+// MessageReader reads the serialized messages of a stream one by one,
+// holding no more than a single message in memory.
+type MessageReader struct {
+	scanner *bufio.Scanner
+	framing string
+	// limit is the size of the largest message that is read.
+	limit int
+}
+
+// NewMessageReader returns a reader of the messages of r framed with framing.
+// Messages larger than maxMessageSize bytes fail, 0 limits them to the
+// largest message RawSocket allows.
+func NewMessageReader(r io.Reader, framing string, maxMessageSize int) (*MessageReader, error) {
+	limit := maxFramedMessageSize
+	if maxMessageSize > 0 && maxMessageSize < limit {
+		limit = maxMessageSize
+	}
+
+	reader := &MessageReader{scanner: bufio.NewScanner(r), framing: framing, limit: limit}
+	// Hex encoded lines take twice the size of the message.
+	reader.scanner.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, 2*limit+lengthPrefixSize)),
+		2*limit+lengthPrefixSize)
+	switch framing {
+	case LineFraming:
+	case LengthFraming:
+		reader.scanner.Split(reader.splitLengthPrefixed)
+	default:
+		return nil, fmt.Errorf("unknown framing %q, must be one of %v", framing, Framings())
+	}
+
+	return reader, nil
+}
+
+// StreamError is an error reading a stream of messages, after which no more
+// messages can be read, as opposed to an error decoding a single line.
+type StreamError struct {
+	Err error
+}
+
+func (e *StreamError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// Next returns the next message, or io.EOF at the end of the stream. Errors
+// reading the stream are returned as *StreamError, whereas the reader
+// continues with the next line after a line that isn't hex or base64.
+func (m *MessageReader) Next() ([]byte, error) {
+	for m.scanner.Scan() {
+		if m.framing == LengthFraming {
+			return m.scanner.Bytes(), nil
+		}
+
+		line := strings.TrimSpace(m.scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		data, err := DecodeBytes(line)
+		if err != nil {
+			return nil, err
+		}
+
+		return data, CheckMessageSize(len(data), m.limit)
+	}
+
+	if err := m.scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		return nil, &StreamError{Err: fmt.Errorf("line exceeds the %d bytes of an encoded message of %d bytes",
+			2*m.limit+lengthPrefixSize, m.limit)}
+	} else if err != nil {
+		return nil, &StreamError{Err: err}
+	}
+
+	return nil, io.EOF
+}
+
+func (m *MessageReader) splitLengthPrefixed(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) < lengthPrefixSize {
+		if atEOF && len(data) > 0 {
+			return 0, nil, fmt.Errorf("truncated length prefix of %d bytes", len(data))
+		}
+
+		return 0, nil, nil
+	}
+
+	size := int(binary.BigEndian.Uint32(data))
+	if size > m.limit {
+		return 0, nil, fmt.Errorf("message length %d exceeds the limit of %d bytes", size, m.limit)
+	}
+
+	if len(data) < lengthPrefixSize+size {
+		if atEOF {
+			return 0, nil, fmt.Errorf("truncated message of %d bytes, expected %d", len(data)-lengthPrefixSize,
+				size)
+		}
+
+		return 0, nil, nil
+	}
+
+	return lengthPrefixSize + size, data[lengthPrefixSize : lengthPrefixSize+size], nil
+}
@@ -0,0 +1,43 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessageReaderLimit(t *testing.T) {
+	reader, err := NewMessageReader(strings.NewReader("01020304\n0102\n"), LineFraming, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var streamErr *StreamError
+	if _, err = reader.Next(); err == nil || errors.As(err, &streamErr) {
+		t.Errorf("expected the message of 4 bytes to fail on its own, got %v", err)
+	}
+
+	if data, err := reader.Next(); err != nil || !bytes.Equal(data, []byte{1, 2}) {
+		t.Errorf("expected the next message to be read, got %x, %v", data, err)
+	}
+
+	if _, err = reader.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+
+	// Lines longer than any message of the limit fail without being read.
+	reader, _ = NewMessageReader(strings.NewReader(strings.Repeat("00", 64)+"\n"), LineFraming, 8)
+	if _, err = reader.Next(); !errors.As(err, &streamErr) {
+		t.Errorf("expected a stream error for a long line, got %v", err)
+	}
+
+	var framed bytes.Buffer
+	_ = binary.Write(&framed, binary.BigEndian, uint32(1<<30))
+	reader, _ = NewMessageReader(&framed, LengthFraming, 8)
+	if _, err = reader.Next(); !errors.As(err, &streamErr) {
+		t.Errorf("expected a stream error for a length beyond the limit, got %v", err)
+	}
+}
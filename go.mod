@@ -1,12 +1,23 @@
 module github.com/xconnio/wampproto-cli
 
-go 1.20
+go 1.21
 
-require github.com/xconnio/wampproto-go v0.0.0-20240531231532-d8fa7f588c4e
+require (
+	github.com/alecthomas/kingpin/v2 v2.4.0
+	github.com/fxamacker/cbor/v2 v2.6.0
+	github.com/gorilla/websocket v1.5.1
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	github.com/xconnio/wampproto-go v0.0.0-20240531231532-d8fa7f588c4e
+	golang.org/x/crypto v0.23.0
+	golang.org/x/term v0.21.0
+)
 
 require (
-	github.com/fxamacker/cbor/v2 v2.6.0 // indirect
-	github.com/vmihailenco/msgpack/v5 v5.4.1 // indirect
+	github.com/alecthomas/units v0.0.0-20211218093645-b94a6e3cc137 // indirect
 	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	github.com/x448/float16 v0.8.4 // indirect
+	github.com/xhit/go-str2duration/v2 v2.1.0 // indirect
+	golang.org/x/exp v0.0.0-20240525044651-4c93da0ed11d // indirect
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
 )
@@ -0,0 +1,127 @@
+package wampprotocli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// maxBodySize limits request bodies of a handler without a MaxMessageSize.
+const maxBodySize = 64 << 20
+
+// HTTPOptions configure the handler returned by NewHTTPHandler.
+type HTTPOptions struct {
+	// MaxMessageSize limits the size of serialized messages for requests
+	// that don't set their own limit. 0 means unlimited. Request bodies are
+	// limited to 8 times its size, which leaves room for messages encoded as
+	// hex or as JSON with escapes, or to 64 MiB if it is 0.
+	MaxMessageSize int
+	// Strict turns validation warnings into errors for every request.
+	Strict bool
+	// OnWarnings, if set, receives the validation warnings of every
+	// request. It may be called concurrently.
+	OnWarnings func([]Warning)
+}
+
+// NewHTTPHandler returns a handler exposing the serialize, parse, sign and
+// verify functionality as JSON endpoints. Every request is logged to
+// logHandler, which may be nil to disable logging.
+func NewHTTPHandler(logHandler slog.Handler, options HTTPOptions) http.Handler {
+	logger := NewLogger(logHandler)
+
+	serialize := func(request *SerializeRequest) (*SerializeResponse, error) {
+		if request.MaxMessageSize == 0 {
+			request.MaxMessageSize = options.MaxMessageSize
+		}
+		request.Strict = request.Strict || options.Strict
+
+		response, err := Serialize(request)
+		if err == nil && options.OnWarnings != nil && len(response.Warnings) > 0 {
+			options.OnWarnings(response.Warnings)
+		}
+
+		return response, err
+	}
+
+	parse := func(request *ParseRequest) (*ParseResponse, error) {
+		if request.MaxMessageSize == 0 {
+			request.MaxMessageSize = options.MaxMessageSize
+		}
+		request.Strict = request.Strict || options.Strict
+
+		response, err := ParseJSON(request)
+		if err == nil && options.OnWarnings != nil && len(response.Warnings) > 0 {
+			options.OnWarnings(response.Warnings)
+		}
+
+		return response, err
+	}
+
+	bodyLimit := int64(maxBodySize)
+	if options.MaxMessageSize > 0 {
+		bodyLimit = 8 * int64(options.MaxMessageSize)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/serialize", handleJSON(logger, bodyLimit, serialize))
+	mux.HandleFunc("/parse", handleJSON(logger, bodyLimit, parse))
+	mux.HandleFunc("/auth/cryptosign/sign", handleJSON(logger, bodyLimit, SignCryptoSign))
+	mux.HandleFunc("/auth/cryptosign/verify", handleJSON(logger, bodyLimit, VerifyCryptoSign))
+
+	return mux
+}
+
+func handleJSON[Req any, Resp any](logger *slog.Logger, bodyLimit int64,
+	fn func(*Req) (*Resp, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r.Body = http.MaxBytesReader(w, r.Body, bodyLimit)
+		status, err := serveJSON(w, r, fn)
+
+		attrs := []any{"method", r.Method, "path", r.URL.Path, "status", status, "duration", time.Since(start)}
+		if err != nil {
+			logger.WarnContext(r.Context(), "request failed", append(attrs, "error", err)...)
+			return
+		}
+
+		logger.InfoContext(r.Context(), "request handled", attrs...)
+	}
+}
+
+func serveJSON[Req any, Resp any](w http.ResponseWriter, r *http.Request, fn func(*Req) (*Resp, error)) (int, error) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		return writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+
+	var request Req
+	var tooLarge *http.MaxBytesError
+	if err := DecodeRequest(r.Body, &request); errors.As(err, &tooLarge) {
+		return writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds the limit of "+
+			"%d bytes", tooLarge.Limit))
+	} else if err != nil {
+		return writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+	}
+
+	response, err := fn(&request)
+	if err != nil {
+		return writeError(w, http.StatusUnprocessableEntity, err)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+	return http.StatusOK, nil
+}
+
+func writeError(w http.ResponseWriter, status int, err error) (int, error) {
+	writeJSON(w, status, errorResponse{Error: err.Error(), Violations: Violations(err)})
+	return status, err
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
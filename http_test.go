@@ -0,0 +1,27 @@
+package wampprotocli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPBodyLimit(t *testing.T) {
+	handler := NewHTTPHandler(nil, HTTPOptions{MaxMessageSize: 16})
+
+	body := `{"serializer":"json","data":"` + strings.Repeat("00", 128) + `"}`
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/parse", strings.NewReader(body)))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %d, got %d: %s", http.StatusRequestEntityTooLarge, recorder.Code,
+			recorder.Body)
+	}
+
+	body = `{"serializer":"json","data":"5b322c315d"}`
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/parse", strings.NewReader(body)))
+	if recorder.Code == http.StatusRequestEntityTooLarge {
+		t.Errorf("expected a small body to be accepted, got %s", recorder.Body)
+	}
+}
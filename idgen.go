@@ -0,0 +1,58 @@
+package wampprotocli
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+)
+
+// IDGenerator produces WAMP IDs of one scope.
+type IDGenerator interface {
+	NextID() (int64, error)
+}
+
+// RandomIDGenerator draws IDs uniformly from [1, 2^53], as the spec requires
+// for the global scope, i.e. session and publication IDs.
+type RandomIDGenerator struct{}
+
+func (RandomIDGenerator) NextID() (int64, error) {
+	n, err := rand.Int(RandomReader(), big.NewInt(MaxID))
+	if err != nil {
+		return 0, err
+	}
+
+	return n.Int64() + 1, nil
+}
+
+// SequentialIDGenerator counts up from 1 and wraps around to 1 after 2^53, as
+// the spec requires for the session scope, i.e. request IDs.
+type SequentialIDGenerator struct {
+	mu   sync.Mutex
+	last int64
+}
+
+// NewSequentialIDGenerator returns a generator continuing after last, which
+// is 0 for a fresh sequence.
+func NewSequentialIDGenerator(last int64) *SequentialIDGenerator {
+	return &SequentialIDGenerator{last: last}
+}
+
+func (s *SequentialIDGenerator) NextID() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last >= MaxID || s.last < 0 {
+		s.last = 0
+	}
+
+	s.last++
+	return s.last, nil
+}
+
+// Last returns the most recently generated ID, or 0 if there is none.
+func (s *SequentialIDGenerator) Last() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.last
+}
@@ -0,0 +1,209 @@
+package wampprotocli
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// RequestLatency summarizes how long a router took to answer the requests of
+// one kind for one URI, e.g. the calls of a procedure.
+type RequestLatency struct {
+	// Request is the name of the request message, e.g. CALL.
+	Request string `json:"request"`
+	URI     string `json:"uri"`
+	Count   int    `json:"count"`
+	// Errors counts the requests answered with an ERROR, which are included
+	// in the latencies.
+	Errors int           `json:"errors"`
+	Min    time.Duration `json:"min_ns"`
+	Mean   time.Duration `json:"mean_ns"`
+	P50    time.Duration `json:"p50_ns"`
+	P90    time.Duration `json:"p90_ns"`
+	P99    time.Duration `json:"p99_ns"`
+	Max    time.Duration `json:"max_ns"`
+}
+
+// pendingRequest is a request whose response is still to come.
+type pendingRequest struct {
+	uri  string
+	time time.Time
+}
+
+// requestKey identifies a request within a transcript.
+type requestKey struct {
+	session     string
+	requestType int64
+	requestID   int64
+}
+
+// responseRequestType returns the type of the request a message answers, or
+// 0 if it answers none.
+func responseRequestType(messageType int) int64 {
+	switch messageType {
+	case messages.MessageTypeResult:
+		return messages.MessageTypeCall
+	case messages.MessageTypePublished:
+		return messages.MessageTypePublish
+	case messages.MessageTypeRegistered:
+		return messages.MessageTypeRegister
+	case messages.MessageTypeSubscribed:
+		return messages.MessageTypeSubscribe
+	default:
+		return 0
+	}
+}
+
+// latencySamples are the latencies of the requests of one type and URI.
+type latencySamples struct {
+	latencies []time.Duration
+	errors    int
+}
+
+// LatencyAnalysis correlates the requests and responses of a transcript one
+// record at a time, so that it doesn't have to be held in memory. Only the
+// requests whose response is still to come and the latencies are kept.
+type LatencyAnalysis struct {
+	pending map[requestKey]pendingRequest
+	results map[[2]string]*latencySamples
+}
+
+// NewLatencyAnalysis returns the analysis of a transcript without records.
+func NewLatencyAnalysis() *LatencyAnalysis {
+	return &LatencyAnalysis{pending: map[requestKey]pendingRequest{}, results: map[[2]string]*latencySamples{}}
+}
+
+// Add adds the next record of the transcript.
+func (l *LatencyAnalysis) Add(record *TranscriptRecord) {
+	raw, err := DeserializeRaw(record.Serializer, record.Data)
+	if err != nil || len(raw) < 2 {
+		return
+	}
+
+	messageType := rawMessageType(raw)
+	if record.Direction == DirectionToRouter {
+		switch messageType {
+		case messages.MessageTypeCall, messages.MessageTypeRegister, messages.MessageTypeSubscribe,
+			messages.MessageTypePublish:
+		default:
+			return
+		}
+
+		options, _ := rawMap(raw, 2)
+		if messageType == messages.MessageTypePublish && options["acknowledge"] != true {
+			return
+		}
+
+		requestID, _ := messages.AsInt64(raw[1])
+		uri, _ := rawString(raw, 3)
+		key := requestKey{record.Session, int64(messageType), requestID}
+		l.pending[key] = pendingRequest{uri: uri, time: record.Time}
+		return
+	}
+
+	details, _ := rawMap(raw, 2)
+	if messageType == messages.MessageTypeResult && details[progressOption] == true {
+		return
+	}
+
+	requestType, failed := responseRequestType(messageType), false
+	requestID, _ := messages.AsInt64(raw[1])
+	if messageType == messages.MessageTypeError && len(raw) > 2 {
+		requestType, _ = messages.AsInt64(raw[1])
+		requestID, _ = messages.AsInt64(raw[2])
+		failed = true
+	}
+
+	key := requestKey{record.Session, requestType, requestID}
+	request, ok := l.pending[key]
+	if !ok {
+		return
+	}
+
+	delete(l.pending, key)
+	resultKey := [2]string{MessageName(int(requestType)), request.uri}
+	result, ok := l.results[resultKey]
+	if !ok {
+		result = &latencySamples{}
+		l.results[resultKey] = result
+	}
+
+	result.latencies = append(result.latencies, record.Time.Sub(request.time))
+	if failed {
+		result.errors++
+	}
+}
+
+// Latencies returns the latency percentiles of the requests answered so far
+// per request type and URI, sorted.
+func (l *LatencyAnalysis) Latencies() []RequestLatency {
+	latencies := make([]RequestLatency, 0, len(l.results))
+	for key, result := range l.results {
+		latency := summarizeLatencies(slices.Clone(result.latencies))
+		latency.Request, latency.URI, latency.Errors = key[0], key[1], result.errors
+		latencies = append(latencies, latency)
+	}
+
+	sortRequestLatencies(latencies)
+	return latencies
+}
+
+// AnalyzeLatency correlates CALL with RESULT, PUBLISH with PUBLISHED,
+// REGISTER with REGISTERED and SUBSCRIBE with SUBSCRIBED, or with the ERROR
+// failing them, by session and request ID and returns the latency
+// percentiles per request type and URI, sorted. Progressive results don't
+// complete calls and only acknowledged publications are answered.
+func AnalyzeLatency(records []*TranscriptRecord) []RequestLatency {
+	analysis := NewLatencyAnalysis()
+	for _, record := range records {
+		analysis.Add(record)
+	}
+
+	return analysis.Latencies()
+}
+
+// sortRequestLatencies sorts latencies by request type and URI.
+func sortRequestLatencies(latencies []RequestLatency) {
+	slices.SortFunc(latencies, func(a, b RequestLatency) int {
+		if c := cmp.Compare(a.Request, b.Request); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.URI, b.URI)
+	})
+}
+
+// summarizeLatencies returns the statistics of latencies, which it sorts.
+func summarizeLatencies(latencies []time.Duration) RequestLatency {
+	slices.Sort(latencies)
+	percentile := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+
+	return RequestLatency{
+		Count: len(latencies),
+		Min:   latencies[0],
+		Mean:  total / time.Duration(len(latencies)),
+		P50:   percentile(0.50),
+		P90:   percentile(0.90),
+		P99:   percentile(0.99),
+		Max:   latencies[len(latencies)-1],
+	}
+}
+
+// rawMap returns the dictionary at position of the positional message raw.
+func rawMap(raw []any, position int) (map[string]any, bool) {
+	if position >= len(raw) {
+		return nil, false
+	}
+
+	value, ok := raw[position].(map[string]any)
+	return value, ok
+}
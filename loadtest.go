@@ -0,0 +1,337 @@
+package wampprotocli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// LoadTestConfig describes the traffic of a load test.
+type LoadTestConfig struct {
+	Realm      string
+	Serializer string
+	// Rate is the number of requests sent per second, spread round-robin
+	// over the procedures and topics.
+	Rate        int
+	Duration    time.Duration
+	Procedures  []string
+	Topics      []string
+	PayloadSize int
+	// Timeout is how long to wait for the responses still outstanding once
+	// the duration has passed.
+	Timeout time.Duration
+}
+
+// LatencyBucket counts the responses that took at most UpperBound and longer
+// than the bound of the previous bucket. The last bucket has no bound.
+type LatencyBucket struct {
+	UpperBound time.Duration `json:"upper_bound_ns,omitempty"`
+	Count      int           `json:"count"`
+}
+
+// LoadTestResult is the outcome of a load test.
+type LoadTestResult struct {
+	Duration time.Duration `json:"duration_ns"`
+	Sent     int           `json:"sent"`
+	// Completed counts the requests answered with a RESULT or PUBLISHED.
+	Completed int `json:"completed"`
+	Errors    int `json:"errors"`
+	// Timeouts counts the requests still unanswered at the end.
+	Timeouts int `json:"timeouts"`
+	// Throughput is the number of answered requests per second.
+	Throughput float64 `json:"throughput"`
+	// Requests are the latencies per request type and URI, sorted.
+	Requests  []RequestLatency `json:"requests"`
+	Histogram []LatencyBucket  `json:"histogram"`
+	// ErrorURIs counts the errors by their URI.
+	ErrorURIs map[string]int `json:"error_uris,omitempty"`
+}
+
+// latencyBucketBounds are the upper bounds of the latency histogram buckets.
+//
+//nolint:gochecknoglobals
+var latencyBucketBounds = []time.Duration{
+	100 * time.Microsecond, 250 * time.Microsecond, 500 * time.Microsecond,
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond, time.Second,
+}
+
+// loadTarget is a procedure to call or a topic to publish to.
+type loadTarget struct {
+	messageType int64
+	uri         string
+}
+
+// loadTest is the state of a running load test.
+type loadTest struct {
+	config    *LoadTestConfig
+	transport ReplayTransport
+	payload   []any
+	targets   []loadTarget
+	requestID int64
+	pending   map[requestKey]pendingRequest
+	latencies map[[2]string][]time.Duration
+	errors    map[[2]string]int
+	result    *LoadTestResult
+}
+
+// received is a message received from the router or the error receiving it.
+type received struct {
+	data []byte
+	err  error
+}
+
+// RunLoadTest joins config.Realm anonymously over transport and sends CALLs
+// and acknowledged PUBLISHes at config.Rate for config.Duration, measuring
+// the latency of every response. Requests answered with an ERROR count as
+// errors and are included in the latencies.
+func RunLoadTest(ctx context.Context, transport ReplayTransport, config *LoadTestConfig) (*LoadTestResult, error) {
+	if config.Rate < 1 {
+		return nil, fmt.Errorf("rate must be at least 1, was %d", config.Rate)
+	} else if len(config.Procedures)+len(config.Topics) == 0 {
+		return nil, errors.New("at least one procedure or topic is required")
+	}
+
+	l := &loadTest{
+		config:    config,
+		transport: transport,
+		pending:   map[requestKey]pendingRequest{},
+		latencies: map[[2]string][]time.Duration{},
+		errors:    map[[2]string]int{},
+		result:    &LoadTestResult{ErrorURIs: map[string]int{}},
+	}
+
+	if config.PayloadSize > 0 {
+		l.payload = []any{strings.Repeat("x", config.PayloadSize)}
+	}
+
+	for _, procedure := range config.Procedures {
+		l.targets = append(l.targets, loadTarget{messages.MessageTypeCall, procedure})
+	}
+
+	for _, topic := range config.Topics {
+		l.targets = append(l.targets, loadTarget{messages.MessageTypePublish, topic})
+	}
+
+	if err := l.join(); err != nil {
+		return nil, err
+	}
+
+	incoming := make(chan received, 1)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		for {
+			data, err := transport.Receive()
+			select {
+			case incoming <- received{data, err}:
+			case <-stop:
+				return
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := l.run(ctx, incoming); err != nil {
+		return nil, err
+	}
+
+	_ = l.send([]any{messages.MessageTypeGoodbye, map[string]any{}, "wamp.close.close_realm"})
+	return l.summarize(), nil
+}
+
+// join opens the session the load is sent in.
+func (l *loadTest) join() error {
+	err := l.send([]any{messages.MessageTypeHello, l.config.Realm, map[string]any{
+		"roles": map[string]any{"caller": map[string]any{}, "publisher": map[string]any{}},
+	}})
+	if err != nil {
+		return err
+	}
+
+	data, err := l.transport.Receive()
+	if err != nil {
+		return fmt.Errorf("failed to receive WELCOME: %w", err)
+	}
+
+	raw, err := DeserializeRaw(l.config.Serializer, data)
+	if err != nil {
+		return err
+	} else if rawMessageType(raw) != messages.MessageTypeWelcome {
+		return fmt.Errorf("router sent %s instead of WELCOME: %v", MessageName(rawMessageType(raw)), raw)
+	}
+
+	return nil
+}
+
+// run sends requests at the configured rate until the duration has passed,
+// then waits for the outstanding responses until the timeout.
+func (l *loadTest) run(ctx context.Context, incoming <-chan received) error {
+	start := time.Now()
+	// Requests are sent in batches every millisecond so that rates above a
+	// thousand per second don't depend on timer resolution.
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	end := time.NewTimer(l.config.Duration)
+	defer end.Stop()
+
+	var drain <-chan time.Time
+	sending := true
+	for sending || len(l.pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			if !sending {
+				continue
+			}
+
+			due := int(now.Sub(start).Seconds()*float64(l.config.Rate)) - l.result.Sent
+			for ; due > 0; due-- {
+				if err := l.request(); err != nil {
+					return err
+				}
+			}
+		case <-end.C:
+			sending = false
+			l.result.Duration = time.Since(start)
+			drain = time.After(l.config.Timeout)
+		case <-drain:
+			l.result.Timeouts = len(l.pending)
+			return nil
+		case message := <-incoming:
+			if message.err != nil {
+				return fmt.Errorf("failed to receive: %w", message.err)
+			}
+
+			if err := l.response(message.data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// request sends the next request.
+func (l *loadTest) request() error {
+	target := l.targets[l.result.Sent%len(l.targets)]
+	l.requestID++
+	raw := []any{target.messageType, l.requestID, map[string]any{}, target.uri}
+	if target.messageType == messages.MessageTypePublish {
+		raw[2] = map[string]any{"acknowledge": true}
+	}
+
+	if l.payload != nil {
+		raw = append(raw, l.payload)
+	}
+
+	key := requestKey{requestType: target.messageType, requestID: l.requestID}
+	l.pending[key] = pendingRequest{uri: target.uri, time: time.Now()}
+	l.result.Sent++
+	return l.send(raw)
+}
+
+// response accounts for a message of the router.
+func (l *loadTest) response(data []byte) error {
+	now := time.Now()
+	raw, err := DeserializeRaw(l.config.Serializer, data)
+	if err != nil {
+		return err
+	}
+
+	messageType := rawMessageType(raw)
+	switch messageType {
+	case messages.MessageTypeAbort, messages.MessageTypeGoodbye:
+		reason, _ := raw[len(raw)-1].(string)
+		return fmt.Errorf("router closed the session: %s", reason)
+	case messages.MessageTypeResult, messages.MessageTypePublished, messages.MessageTypeError:
+	default:
+		return nil
+	}
+
+	details, _ := rawMap(raw, 2)
+	if messageType == messages.MessageTypeResult && details[progressOption] == true {
+		return nil
+	}
+
+	requestType := responseRequestType(messageType)
+	requestID, _ := messages.AsInt64(raw[1])
+	if messageType == messages.MessageTypeError && len(raw) > 4 {
+		requestType, _ = messages.AsInt64(raw[1])
+		requestID, _ = messages.AsInt64(raw[2])
+	}
+
+	key := requestKey{requestType: requestType, requestID: requestID}
+	request, ok := l.pending[key]
+	if !ok {
+		return nil
+	}
+
+	delete(l.pending, key)
+	resultKey := [2]string{MessageName(int(requestType)), request.uri}
+	l.latencies[resultKey] = append(l.latencies[resultKey], now.Sub(request.time))
+	if messageType == messages.MessageTypeError {
+		errorURI, _ := rawString(raw, 4)
+		l.errors[resultKey]++
+		l.result.ErrorURIs[errorURI]++
+		l.result.Errors++
+	} else {
+		l.result.Completed++
+	}
+
+	return nil
+}
+
+func (l *loadTest) send(raw []any) error {
+	data, err := serializeValue(l.config.Serializer, raw)
+	if err != nil {
+		return err
+	}
+
+	return l.transport.Send(data)
+}
+
+// summarize returns the result with the latencies summarized.
+func (l *loadTest) summarize() *LoadTestResult {
+	result := l.result
+	if result.Duration > 0 {
+		result.Throughput = float64(result.Completed+result.Errors) / result.Duration.Seconds()
+	}
+
+	result.Histogram = make([]LatencyBucket, len(latencyBucketBounds)+1)
+	for i, bound := range latencyBucketBounds {
+		result.Histogram[i].UpperBound = bound
+	}
+
+	for key, latencies := range l.latencies {
+		for _, latency := range latencies {
+			bucket := len(latencyBucketBounds)
+			for i, bound := range latencyBucketBounds {
+				if latency <= bound {
+					bucket = i
+					break
+				}
+			}
+
+			result.Histogram[bucket].Count++
+		}
+
+		latency := summarizeLatencies(latencies)
+		latency.Request, latency.URI, latency.Errors = key[0], key[1], l.errors[key]
+		result.Requests = append(result.Requests, latency)
+	}
+
+	sortRequestLatencies(result.Requests)
+	return result
+}
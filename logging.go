@@ -0,0 +1,16 @@
+package wampprotocli
+
+import (
+	"io"
+	"log/slog"
+)
+
+// NewLogger returns a logger backed by handler. A nil handler discards all
+// records, which is the default when the library is embedded.
+func NewLogger(handler slog.Handler) *slog.Logger {
+	if handler == nil {
+		handler = slog.NewTextHandler(io.Discard, nil)
+	}
+
+	return slog.New(handler)
+}
@@ -0,0 +1,252 @@
+package wampprotocli
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// Match policies of subscriptions and registrations.
+const (
+	MatchExact    = "exact"
+	MatchPrefix   = "prefix"
+	MatchWildcard = "wildcard"
+)
+
+// MatchPolicies returns the names accepted by MatchURI.
+func MatchPolicies() []string {
+	return []string{MatchExact, MatchPrefix, MatchWildcard}
+}
+
+// MatchURI reports whether uri matches pattern under policy, as a broker
+// matches topics against subscriptions and a dealer procedures against
+// registrations. An empty policy is MatchExact. Prefix patterns match every
+// URI starting with them, wildcard patterns every URI with the same number of
+// components that agrees on all components that are not empty in pattern.
+func MatchURI(pattern, uri, policy string) (bool, error) {
+	if err := ValidateURI(pattern, false, policy == MatchWildcard); err != nil {
+		return false, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	if err := ValidateURI(uri, false, false); err != nil {
+		return false, err
+	}
+
+	switch policy {
+	case "", MatchExact:
+		return uri == pattern, nil
+	case MatchPrefix:
+		return strings.HasPrefix(uri, pattern), nil
+	case MatchWildcard:
+		return matchWildcard(pattern, uri), nil
+	default:
+		return false, fmt.Errorf("unknown match policy %q, must be one of %v", policy, MatchPolicies())
+	}
+}
+
+func matchWildcard(pattern, uri string) bool {
+	patternComponents := strings.Split(pattern, ".")
+	uriComponents := strings.Split(uri, ".")
+	if len(patternComponents) != len(uriComponents) {
+		return false
+	}
+
+	for i, component := range patternComponents {
+		if component != "" && component != uriComponents[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Subscription is a subscription of a broker's subscription table.
+type Subscription struct {
+	ID          int64    `json:"id,omitempty"`
+	Topic       string   `json:"topic"`
+	Match       string   `json:"match,omitempty"`
+	Subscribers []string `json:"subscribers,omitempty"`
+}
+
+// MatchSubscriptions returns the subscriptions that receive an event
+// published to topic, in the order given. A broker delivers the event once
+// for every matching subscription, so subscribers of several of them receive
+// it several times.
+func MatchSubscriptions(subscriptions []Subscription, topic string) ([]Subscription, error) {
+	if err := ValidateURI(topic, false, false); err != nil {
+		return nil, err
+	}
+
+	matching := make([]Subscription, 0)
+	for i, subscription := range subscriptions {
+		matched, err := MatchURI(subscription.Topic, topic, subscription.Match)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %d: %w", i+1, err)
+		}
+
+		if matched {
+			matching = append(matching, subscription)
+		}
+	}
+
+	return matching, nil
+}
+
+// Invocation policies of shared registrations.
+const (
+	InvokeSingle     = "single"
+	InvokeRoundRobin = "roundrobin"
+	InvokeRandom     = "random"
+	InvokeFirst      = "first"
+	InvokeLast       = "last"
+)
+
+// InvokePolicies returns the names accepted by SelectCallees.
+func InvokePolicies() []string {
+	return []string{InvokeSingle, InvokeRoundRobin, InvokeRandom, InvokeFirst, InvokeLast}
+}
+
+// Registration is a registration of a dealer's registration table. Callees
+// are listed in the order they registered.
+type Registration struct {
+	ID        int64    `json:"id,omitempty"`
+	Procedure string   `json:"procedure"`
+	Match     string   `json:"match,omitempty"`
+	Invoke    string   `json:"invoke,omitempty"`
+	Callees   []string `json:"callees"`
+}
+
+// MatchRegistration returns the registration a dealer routes a call to
+// procedure to, or nil if there is none. An exact match takes precedence over
+// prefix matches, of which the longest wins, which take precedence over
+// wildcard matches, of which the one with non-empty components furthest to
+// the left wins. Remaining ties go to the registration listed first.
+func MatchRegistration(registrations []Registration, procedure string) (*Registration, error) {
+	if err := ValidateURI(procedure, false, false); err != nil {
+		return nil, err
+	}
+
+	var best *Registration
+	for i := range registrations {
+		registration := &registrations[i]
+		matched, err := MatchURI(registration.Procedure, procedure, registration.Match)
+		if err != nil {
+			return nil, fmt.Errorf("registration %d: %w", i+1, err)
+		}
+
+		if matched && (best == nil || precedes(registration, best)) {
+			best = registration
+		}
+	}
+
+	return best, nil
+}
+
+// precedes reports whether a takes precedence over b, both matching the same
+// procedure.
+func precedes(a, b *Registration) bool {
+	rank := map[string]int{"": 0, MatchExact: 0, MatchPrefix: 1, MatchWildcard: 2}
+	if rank[a.Match] != rank[b.Match] {
+		return rank[a.Match] < rank[b.Match]
+	}
+
+	switch a.Match {
+	case MatchPrefix:
+		return len(a.Procedure) > len(b.Procedure)
+	case MatchWildcard:
+		aComponents, bComponents := strings.Split(a.Procedure, "."), strings.Split(b.Procedure, ".")
+		for i := range aComponents {
+			if (aComponents[i] == "") != (bComponents[i] == "") {
+				return bComponents[i] == ""
+			}
+		}
+	}
+
+	return false
+}
+
+// SelectCallees returns the callee invoked by each of calls successive calls
+// routed to registration under its invocation policy, an empty one being
+// InvokeSingle. Random selection is drawn from RandomReader, so that it is
+// reproducible under SetRandomSource.
+func SelectCallees(registration Registration, calls int) ([]string, error) {
+	callees := registration.Callees
+	if len(callees) == 0 {
+		return nil, fmt.Errorf("registration of %s has no callees", registration.Procedure)
+	}
+
+	var next func(call int) string
+	switch registration.Invoke {
+	case "", InvokeSingle:
+		if len(callees) > 1 {
+			return nil, fmt.Errorf("registration of %s with invocation policy single has %d callees",
+				registration.Procedure, len(callees))
+		}
+
+		next = func(int) string { return callees[0] }
+	case InvokeRoundRobin:
+		next = func(call int) string { return callees[call%len(callees)] }
+	case InvokeRandom:
+		var seed [8]byte
+		if _, err := io.ReadFull(RandomReader(), seed[:]); err != nil {
+			return nil, err
+		}
+
+		//nolint:gosec // picks of a dealer, not secrets
+		source := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(seed[:]))))
+		next = func(int) string { return callees[source.Intn(len(callees))] }
+	case InvokeFirst:
+		next = func(int) string { return callees[0] }
+	case InvokeLast:
+		next = func(int) string { return callees[len(callees)-1] }
+	default:
+		return nil, fmt.Errorf("unknown invocation policy %q, must be one of %v", registration.Invoke,
+			InvokePolicies())
+	}
+
+	selected := make([]string, calls)
+	for call := range selected {
+		selected[call] = next(call)
+	}
+
+	return selected, nil
+}
+
+// URIComponents describes the components of a URI and the match policies it
+// can be registered or subscribed with.
+type URIComponents struct {
+	URI        string   `json:"uri"`
+	Components []string `json:"components"`
+	Depth      int      `json:"depth"`
+	// Empty lists the 1-based positions of empty components, which are only
+	// allowed in wildcard patterns.
+	Empty    []int `json:"empty"`
+	Exact    bool  `json:"exact"`
+	Prefix   bool  `json:"prefix"`
+	Wildcard bool  `json:"wildcard"`
+}
+
+// SplitURI splits uri into its components and checks it against the loose URI
+// rules of every match policy.
+func SplitURI(uri string) URIComponents {
+	components := strings.Split(uri, ".")
+	empty := make([]int, 0)
+	for i, component := range components {
+		if component == "" {
+			empty = append(empty, i+1)
+		}
+	}
+
+	valid := ValidateURI(uri, false, false) == nil
+	return URIComponents{
+		URI:        uri,
+		Components: components,
+		Depth:      len(components),
+		Empty:      empty,
+		Exact:      valid,
+		Prefix:     valid,
+		Wildcard:   ValidateURI(uri, false, true) == nil,
+	}
+}
@@ -0,0 +1,121 @@
+package wampprotocli
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMatchRegistration(t *testing.T) {
+	registrations := []Registration{
+		{ID: 1, Procedure: "com.", Match: MatchWildcard},
+		{ID: 2, Procedure: "com.app", Match: MatchPrefix},
+		{ID: 3, Procedure: "com.app.add", Match: MatchPrefix},
+		{ID: 4, Procedure: "com.app.add"},
+		{ID: 5, Procedure: ".app.add", Match: MatchWildcard},
+		{ID: 6, Procedure: "com..add", Match: MatchWildcard},
+		{ID: 7, Procedure: "com..", Match: MatchWildcard},
+	}
+
+	for _, test := range []struct {
+		name      string
+		procedure string
+		// id is the ID of the matching registration, 0 for none.
+		id int64
+	}{
+		{"exact over prefix and wildcard", "com.app.add", 4},
+		{"longest prefix", "com.app.add.int", 3},
+		{"prefix over wildcard", "com.app.sub", 2},
+		{"wildcard with the leftmost component", "com.other.add", 6},
+		{"wildcard", "com.other", 1},
+		{"none", "org.app", 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			registration, err := MatchRegistration(registrations, test.procedure)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var id int64
+			if registration != nil {
+				id = registration.ID
+			}
+
+			if id != test.id {
+				t.Errorf("expected registration %d for %s, got %d", test.id, test.procedure, id)
+			}
+		})
+	}
+
+	// Ties go to the registration listed first.
+	tied := []Registration{{ID: 1, Procedure: "com..add", Match: MatchWildcard},
+		{ID: 2, Procedure: "com.app.", Match: MatchWildcard}}
+	if registration, err := MatchRegistration(tied, "com.app.add"); err != nil || registration.ID != 2 {
+		t.Errorf("expected the wildcard with the leftmost component to win, got %+v, %v", registration, err)
+	}
+
+	tied[1].Procedure = "com..add"
+	if registration, err := MatchRegistration(tied, "com.app.add"); err != nil || registration.ID != 1 {
+		t.Errorf("expected the first of tied registrations, got %+v, %v", registration, err)
+	}
+}
+
+func TestSelectCallees(t *testing.T) {
+	callees := []string{"a", "b", "c"}
+	for _, test := range []struct {
+		invoke   string
+		callees  []string
+		expected []string
+	}{
+		{"", []string{"a"}, []string{"a", "a", "a", "a"}},
+		{InvokeSingle, []string{"a"}, []string{"a", "a", "a", "a"}},
+		{InvokeRoundRobin, callees, []string{"a", "b", "c", "a"}},
+		{InvokeFirst, callees, []string{"a", "a", "a", "a"}},
+		{InvokeLast, callees, []string{"c", "c", "c", "c"}},
+	} {
+		selected, err := SelectCallees(Registration{Procedure: "p", Invoke: test.invoke, Callees: test.callees}, 4)
+		if err != nil {
+			t.Errorf("%q: %v", test.invoke, err)
+		} else if !slices.Equal(selected, test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.invoke, test.expected, selected)
+		}
+	}
+
+	for _, registration := range []Registration{
+		{Procedure: "p", Invoke: InvokeSingle, Callees: callees},
+		{Procedure: "p", Invoke: InvokeRoundRobin},
+		{Procedure: "p", Invoke: "any", Callees: callees},
+	} {
+		if _, err := SelectCallees(registration, 1); err == nil {
+			t.Errorf("%+v: expected an error", registration)
+		}
+	}
+}
+
+func TestSelectCalleesRandom(t *testing.T) {
+	registration := Registration{Procedure: "p", Invoke: InvokeRandom, Callees: []string{"a", "b", "c"}}
+	previous := SetRandomSource(NewSeededReader(7))
+	defer SetRandomSource(previous)
+
+	first, err := SelectCallees(registration, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, callee := range first {
+		if !slices.Contains(registration.Callees, callee) {
+			t.Fatalf("selected %s, which didn't register", callee)
+		}
+	}
+
+	SetRandomSource(NewSeededReader(7))
+	if again, _ := SelectCallees(registration, 32); !slices.Equal(again, first) {
+		t.Errorf("expected the same seed to select the same callees, got %v and %v", first, again)
+	}
+
+	// The picks of 32 calls are equal for different seeds with a chance of
+	// 3^-32.
+	SetRandomSource(NewSeededReader(8))
+	if other, _ := SelectCallees(registration, 32); slices.Equal(other, first) {
+		t.Errorf("expected another seed to select other callees, got %v for both", first)
+	}
+}
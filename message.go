@@ -0,0 +1,214 @@
+package wampprotocli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/xconnio/wampproto-go/messages"
+	"github.com/xconnio/wampproto-go/serializers"
+)
+
+// MessageName returns the WAMP name of the given message type, e.g. "CALL".
+func MessageName(messageType int) string {
+	switch messageType {
+	case messages.MessageTypeHello:
+		return messages.MessageNameHello
+	case messages.MessageTypeWelcome:
+		return messages.MessageNameWelcome
+	case messages.MessageTypeAbort:
+		return messages.MessageNameAbort
+	case messages.MessageTypeChallenge:
+		return messages.MessageNameChallenge
+	case messages.MessageTypeAuthenticate:
+		return messages.MessageNameAuthenticate
+	case messages.MessageTypeGoodbye:
+		return messages.MessageNameGoodbye
+	case messages.MessageTypeError:
+		return messages.MessageNameError
+	case messages.MessageTypePublish:
+		return messages.MessageNamePublish
+	case messages.MessageTypePublished:
+		return messages.MessageNamePublished
+	case messages.MessageTypeSubscribe:
+		return messages.MessageNameSubscribe
+	case messages.MessageTypeSubscribed:
+		return messages.MessageNameSubscribed
+	case messages.MessageTypeUnSubscribe:
+		return messages.MessageNameUnSubscribe
+	case messages.MessageTypeUnSubscribed:
+		return messages.MessageNameUnSubscribed
+	case messages.MessageTypeEvent:
+		return messages.MessageNameEvent
+	case messages.MessageTypeCall:
+		return messages.MessageNameCall
+	case messages.MessageTypeCancel:
+		return messages.MessageNameCancel
+	case messages.MessageTypeResult:
+		return messages.MessageNameResult
+	case messages.MessageTypeRegister:
+		return messages.MessageNameRegister
+	case messages.MessageTypeRegistered:
+		return messages.MessageNameRegistered
+	case messages.MessageTypeUnRegister:
+		return messages.MessageNameUnRegister
+	case messages.MessageTypeUnRegistered:
+		return messages.MessageNameUnRegistered
+	case messages.MessageTypeInvocation:
+		return messages.MessageNameInvocation
+	case messages.MessageTypeInterrupt:
+		return messages.MessageNameInterrupt
+	case messages.MessageTypeYield:
+		return messages.MessageNameYield
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// MessageFromRaw builds a typed message from its positional WAMP representation.
+func MessageFromRaw(raw []any) (message messages.Message, err error) {
+	if len(raw) == 0 {
+		return nil, errors.New("message must not be empty")
+	}
+
+	// The wampproto-go parsers index past the end of some short messages.
+	defer func() {
+		if r := recover(); r != nil {
+			message, err = nil, fmt.Errorf("invalid message: %v", r)
+		}
+	}()
+
+	message, err = serializers.ToMessage(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// wampproto-go drops the topic when parsing SUBSCRIBE and PUBLISH, the
+	// options when parsing PUBLISH and all details when parsing HELLO, so
+	// those are rebuilt from the validated raw message.
+	switch msg := message.(type) {
+	case *messages.Hello:
+		details, _ := raw[2].(map[string]any)
+		return messages.NewHelloWithFields(&helloFields{realm: msg.Realm(), details: details}), nil
+	case *messages.Subscribe:
+		topic, _ := raw[3].(string)
+		return messages.NewSubscribe(msg.RequestID(), msg.Options(), topic), nil
+	case *messages.Publish:
+		options, _ := raw[2].(map[string]any)
+		topic, _ := raw[3].(string)
+		return messages.NewPublish(msg.RequestID(), options, topic, msg.Args(), msg.KwArgs()), nil
+	}
+
+	return message, nil
+}
+
+// MarshalMessage returns the positional WAMP representation of message.
+// ERROR messages are marshaled here rather than by wampproto-go, whose
+// Error.Marshal omits the mandatory details dictionary, and parsed HELLO
+// messages keep all of their details.
+func MarshalMessage(message messages.Message) []any {
+	if hello, ok := message.(*messages.Hello); ok {
+		if fields, ok := hello.HelloFields.(*helloFields); ok {
+			return []any{messages.MessageTypeHello, fields.realm, fields.details}
+		}
+	}
+
+	errMessage, ok := message.(*messages.Error)
+	if !ok {
+		return message.Marshal()
+	}
+
+	details := errMessage.Details()
+	if details == nil {
+		details = map[string]any{}
+	}
+
+	result := []any{messages.MessageTypeError, errMessage.MessageType(), errMessage.RequestID(), details,
+		errMessage.URI()}
+
+	if errMessage.Args() != nil {
+		result = append(result, errMessage.Args())
+	}
+
+	if errMessage.KwArgs() != nil {
+		if errMessage.Args() == nil {
+			result = append(result, []any{})
+		}
+
+		result = append(result, errMessage.KwArgs())
+	}
+
+	return result
+}
+
+// helloFields keeps every detail of a parsed HELLO message, including those
+// wampproto-go doesn't know about, like agent.
+type helloFields struct {
+	realm   string
+	details map[string]any
+}
+
+func (h *helloFields) Realm() string {
+	return h.realm
+}
+
+func (h *helloFields) AuthID() string {
+	authID, _ := h.details["authid"].(string)
+	return authID
+}
+
+func (h *helloFields) AuthMethods() []string {
+	rawMethods, _ := h.details["authmethods"].([]any)
+	methods := make([]string, 0, len(rawMethods))
+	for _, method := range rawMethods {
+		if name, ok := method.(string); ok {
+			methods = append(methods, name)
+		}
+	}
+
+	return methods
+}
+
+func (h *helloFields) AuthExtra() map[string]any {
+	authExtra, _ := h.details["authextra"].(map[string]any)
+	return authExtra
+}
+
+func (h *helloFields) Roles() map[string]any {
+	roles, _ := h.details["roles"].(map[string]any)
+	return roles
+}
+
+// marshaled adapts a message so that serializers use MarshalMessage.
+type marshaled struct {
+	messages.Message
+}
+
+func (m marshaled) Marshal() []any {
+	return MarshalMessage(m.Message)
+}
+
+// NormalizeJSON converts json.Number values produced by a decoder with
+// UseNumber enabled into int64 or float64, so that integers keep their
+// type when re-encoded with a binary serializer.
+func NormalizeJSON(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i, item := range v {
+			v[i] = NormalizeJSON(item)
+		}
+	case map[string]any:
+		for key, item := range v {
+			v[key] = NormalizeJSON(item)
+		}
+	}
+
+	return value
+}
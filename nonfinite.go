@@ -0,0 +1,102 @@
+package wampprotocli
+
+import (
+	"fmt"
+	"math"
+)
+
+// Policies for NaN and infinite floats, which JSON cannot represent.
+const (
+	NonFiniteError  = "error"
+	NonFiniteNull   = "null"
+	NonFiniteString = "string"
+)
+
+// NonFinitePolicies returns the names accepted by ReplaceNonFinite.
+func NonFinitePolicies() []string {
+	return []string{NonFiniteError, NonFiniteNull, NonFiniteString}
+}
+
+// ReplaceNonFinite applies policy to every NaN or infinite float below value,
+// in place where possible, and returns the result along with a warning for
+// every replacement. NonFiniteError, the default for an empty policy, fails on
+// the first such float, NonFiniteNull replaces it with nil and NonFiniteString
+// with "NaN", "Infinity" or "-Infinity".
+func ReplaceNonFinite(value any, policy string) (any, []Warning, error) {
+	switch policy {
+	case "", NonFiniteError, NonFiniteNull, NonFiniteString:
+	default:
+		return nil, nil, fmt.Errorf("unknown non-finite policy %q, must be one of %v", policy, NonFinitePolicies())
+	}
+
+	var warnings []Warning
+	result, err := replaceNonFinite(value, policy, "", &warnings)
+	return result, warnings, err
+}
+
+func replaceNonFinite(value any, policy, path string, warnings *[]Warning) (any, error) {
+	var f float64
+	switch v := value.(type) {
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	case []any:
+		for i, item := range v {
+			replaced, err := replaceNonFinite(item, policy, fmt.Sprintf("%s[%d]", path, i), warnings)
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = replaced
+		}
+
+		return v, nil
+	case map[string]any:
+		for _, key := range sortedKeys(v) {
+			replaced, err := replaceNonFinite(v[key], policy, fmt.Sprintf("%s[%q]", path, key), warnings)
+			if err != nil {
+				return nil, err
+			}
+
+			v[key] = replaced
+		}
+
+		return v, nil
+	default:
+		return value, nil
+	}
+
+	if !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return value, nil
+	}
+
+	name := nonFiniteName(f)
+	switch policy {
+	case NonFiniteNull:
+		*warnings = append(*warnings, Warning{
+			Category: CategoryNonFinite,
+			Message:  fmt.Sprintf("replaced %s at %s with null", name, path),
+		})
+		return nil, nil
+	case NonFiniteString:
+		*warnings = append(*warnings, Warning{
+			Category: CategoryNonFinite,
+			Message:  fmt.Sprintf("replaced %s at %s with a string", name, path),
+		})
+		return name, nil
+	default:
+		return nil, fmt.Errorf("non-finite float %s at %s cannot be represented in JSON", name, path)
+	}
+}
+
+func nonFiniteName(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	default:
+		return "NaN"
+	}
+}
@@ -0,0 +1,146 @@
+package wampprotocli
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// offlineSigningVersion is the version of the signing request format.
+const offlineSigningVersion = 1
+
+// OfflineSigningRequest carries a cryptosign challenge to a machine that
+// holds the private key but has no network access, and the signature back.
+type OfflineSigningRequest struct {
+	Version   int    `json:"version"`
+	Method    string `json:"method"`
+	Challenge string `json:"challenge"`
+	// PublicKey is the hex encoded public key that has to sign the challenge.
+	// If the request doesn't name one, signing sets it.
+	PublicKey string `json:"public_key,omitempty"`
+	// Signature is the hex encoded signature followed by the challenge, as
+	// AUTHENTICATE carries it, once the request is signed.
+	Signature string `json:"signature,omitempty"`
+}
+
+// NewOfflineSigningRequest returns the signing request of a cryptosign
+// challenge. publicKey optionally names the hex encoded key expected to sign.
+func NewOfflineSigningRequest(challenge []byte, publicKey string) (*OfflineSigningRequest, error) {
+	if len(challenge) != challengeSize {
+		return nil, fmt.Errorf("challenge must be %d bytes, was %d", challengeSize, len(challenge))
+	}
+
+	if publicKey != "" {
+		if key, err := hex.DecodeString(publicKey); err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("public key must be %d hex encoded bytes", ed25519.PublicKeySize)
+		}
+	}
+
+	return &OfflineSigningRequest{
+		Version:   offlineSigningVersion,
+		Method:    "cryptosign",
+		Challenge: hex.EncodeToString(challenge),
+		PublicKey: publicKey,
+	}, nil
+}
+
+// ChallengeFromMessage returns the challenge of a positional cryptosign
+// CHALLENGE message.
+func ChallengeFromMessage(raw []any) ([]byte, error) {
+	const methodPosition, extraPosition = 1, 2
+	if rawMessageType(raw) != messages.MessageTypeChallenge || extraPosition >= len(raw) {
+		return nil, fmt.Errorf("%s is not a CHALLENGE message", MessageName(rawMessageType(raw)))
+	}
+
+	if method, _ := raw[methodPosition].(string); method != "cryptosign" {
+		return nil, fmt.Errorf("CHALLENGE is for authentication method %v, not cryptosign", raw[methodPosition])
+	}
+
+	extra, _ := raw[extraPosition].(map[string]any)
+	encoded, _ := extra["challenge"].(string)
+	challenge, err := hex.DecodeString(encoded)
+	if err != nil || len(challenge) == 0 {
+		return nil, errors.New("CHALLENGE extra carries no hex encoded challenge")
+	}
+
+	return challenge, nil
+}
+
+// check validates the fields every signing request has.
+func (r *OfflineSigningRequest) check() ([]byte, error) {
+	if r.Version != offlineSigningVersion {
+		return nil, fmt.Errorf("unsupported signing request version %d", r.Version)
+	} else if r.Method != "cryptosign" {
+		return nil, fmt.Errorf("unsupported authentication method %q", r.Method)
+	}
+
+	challenge, err := hex.DecodeString(r.Challenge)
+	if err != nil || len(challenge) != challengeSize {
+		return nil, fmt.Errorf("signing request carries no hex encoded %d byte challenge", challengeSize)
+	}
+
+	return challenge, nil
+}
+
+// Sign signs the challenge of the request with keyPair, which must be the
+// one the request names if it names one.
+func (r *OfflineSigningRequest) Sign(keyPair *KeyPair) error {
+	challenge, err := r.check()
+	if err != nil {
+		return err
+	}
+
+	publicKey := hex.EncodeToString(keyPair.PublicKey)
+	if r.PublicKey != "" && r.PublicKey != publicKey {
+		return fmt.Errorf("signing request is for public key %s, the private key belongs to %s", r.PublicKey,
+			publicKey)
+	}
+
+	signature, err := SignChallenge(challenge, keyPair)
+	if err != nil {
+		return err
+	}
+
+	r.PublicKey, r.Signature = publicKey, hex.EncodeToString(signature)
+	return nil
+}
+
+// Authenticate verifies the signature of a signed request and returns the
+// positional AUTHENTICATE message carrying it.
+func (r *OfflineSigningRequest) Authenticate() ([]any, error) {
+	challenge, err := r.check()
+	if err != nil {
+		return nil, err
+	} else if r.Signature == "" {
+		return nil, errors.New("signing request is not signed yet")
+	}
+
+	signature, err := hex.DecodeString(r.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("signature must be hex encoded: %w", err)
+	}
+
+	publicKey, err := hex.DecodeString(r.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("public key must be hex encoded: %w", err)
+	}
+
+	valid, err := VerifyChallengeSignature(signature, challenge, publicKey)
+	if err != nil {
+		return nil, err
+	} else if !valid {
+		return nil, errors.New("signature does not sign the challenge of the request with its public key")
+	}
+
+	return []any{messages.MessageTypeAuthenticate, r.Signature, map[string]any{}}, nil
+}
+
+// SameChallenge reports whether other was prepared for the same challenge
+// and key as r, e.g. whether a signed request answers r.
+func (r *OfflineSigningRequest) SameChallenge(other *OfflineSigningRequest) bool {
+	return r.Method == other.Method && r.Challenge == other.Challenge &&
+		(r.PublicKey == "" || r.PublicKey == other.PublicKey)
+}
@@ -0,0 +1,342 @@
+package wampprotocli
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"time"
+)
+
+// Magic numbers of pcap files, which also determine their byte order and
+// timestamp resolution.
+const (
+	pcapMagicMicros = 0xa1b2c3d4
+	pcapMagicNanos  = 0xa1b23c4d
+	pcapngMagic     = 0x0a0d0d0a
+)
+
+// Link types of pcap files DecodePcap understands.
+const (
+	linkTypeNull     = 0
+	linkTypeEthernet = 1
+	linkTypeRaw      = 101
+	linkTypeLinuxSLL = 113
+	linkTypeIPv4     = 228
+	linkTypeIPv6     = 229
+)
+
+const (
+	pcapHeaderSize       = 24
+	pcapRecordHeaderSize = 16
+	maxPcapRecordSize    = 1 << 20
+	// maxPendingBytes bounds the segments a stream holds while waiting for
+	// one that was lost or not captured.
+	maxPendingBytes = 16 << 20
+)
+
+const (
+	tcpProtocol = 6
+	tcpFIN      = 0x01
+	tcpSYN      = 0x02
+	tcpRST      = 0x04
+)
+
+// DecodePcap reads a capture in pcap format and calls fn for every WAMP
+// message exchanged over TCP with port, in the order the messages completed.
+// WebSocket and RawSocket connections are told apart by their opening
+// handshake, which also names the serializer; WebSocket connections captured
+// after the handshake are detected by their frames and the serializer is
+// guessed from the message. Each client connection is a session named after
+// the client's address. Messages larger than maxMessageSize bytes, 0 being
+// the largest RawSocket allows, are reported as a record with an error and
+// the rest of their stream is ignored, so that no more than a message per
+// stream is held in memory.
+func DecodePcap(r io.Reader, port uint16, maxMessageSize int, fn func(*TranscriptRecord) error) error {
+	header := make([]byte, pcapHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("failed to read pcap header: %w", err)
+	}
+
+	var order binary.ByteOrder
+	var nanos bool
+	switch magic := binary.LittleEndian.Uint32(header); {
+	case magic == pcapMagicMicros || magic == pcapMagicNanos:
+		order, nanos = binary.LittleEndian, magic == pcapMagicNanos
+	case binary.BigEndian.Uint32(header) == pcapMagicMicros || binary.BigEndian.Uint32(header) == pcapMagicNanos:
+		order, nanos = binary.BigEndian, binary.BigEndian.Uint32(header) == pcapMagicNanos
+	case magic == pcapngMagic:
+		return errors.New("pcapng captures are not supported, convert them with: editcap -F pcap in.pcapng out.pcap")
+	default:
+		return errors.New("not a pcap capture")
+	}
+
+	linkType := order.Uint32(header[20:24]) & 0x0fffffff
+	limit := maxFramedMessageSize
+	if maxMessageSize > 0 {
+		limit = maxMessageSize
+	}
+
+	decoder := &pcapDecoder{port: port, limit: limit, connections: map[string]*wampConnection{}, emit: fn}
+
+	record := make([]byte, pcapRecordHeaderSize)
+	for {
+		if _, err := io.ReadFull(r, record); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read pcap record: %w", err)
+		}
+
+		seconds, fraction := order.Uint32(record[0:4]), order.Uint32(record[4:8])
+		length := order.Uint32(record[8:12])
+		if length > maxPcapRecordSize {
+			return fmt.Errorf("pcap record of %d bytes exceeds the limit of %d bytes", length, maxPcapRecordSize)
+		}
+
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return fmt.Errorf("failed to read pcap record: %w", err)
+		}
+
+		if !nanos {
+			fraction *= 1000
+		}
+
+		t := time.Unix(int64(seconds), int64(fraction)).UTC()
+		if err := decoder.packet(t, linkType, frame); err != nil {
+			return err
+		}
+	}
+}
+
+// pcapDecoder reassembles the TCP connections with port of a capture.
+type pcapDecoder struct {
+	port uint16
+	// limit is the size of the largest message that is decoded.
+	limit       int
+	connections map[string]*wampConnection
+	emit        func(*TranscriptRecord) error
+}
+
+// packet decodes one captured link layer frame, ignoring anything that is not
+// a TCP segment of a connection with d.port.
+func (d *pcapDecoder) packet(t time.Time, linkType uint32, frame []byte) error {
+	ip, ok := linkPayload(linkType, frame)
+	if !ok {
+		return nil
+	}
+
+	src, dst, segment, ok := ipPayload(ip)
+	if !ok || len(segment) < 20 {
+		return nil
+	}
+
+	srcPort, dstPort := binary.BigEndian.Uint16(segment[0:2]), binary.BigEndian.Uint16(segment[2:4])
+	seq := binary.BigEndian.Uint32(segment[4:8])
+	offset, flags := int(segment[12]>>4)*4, segment[13]
+	if offset < 20 || offset > len(segment) {
+		return nil
+	}
+
+	var client netip.AddrPort
+	var direction int
+	switch d.port {
+	case dstPort:
+		client, direction = netip.AddrPortFrom(src, srcPort), toRouter
+	case srcPort:
+		client, direction = netip.AddrPortFrom(dst, dstPort), toClient
+	default:
+		return nil
+	}
+
+	session := client.String()
+	connection, ok := d.connections[session]
+	if !ok || flags&tcpSYN != 0 && direction == toRouter && connection.streams[toRouter].started {
+		connection = &wampConnection{session: session, limit: d.limit}
+		d.connections[session] = connection
+	}
+
+	grew, err := connection.streams[direction].add(seq, flags&tcpSYN != 0, segment[offset:])
+	if err != nil {
+		return d.emit(&TranscriptRecord{Time: t, Session: session, Direction: directionName(direction),
+			Serializer: connection.serializer, Error: err.Error()})
+	} else if !grew {
+		return nil
+	}
+
+	if err := connection.decode(t, direction, d.emit); err != nil {
+		return err
+	}
+
+	if flags&(tcpFIN|tcpRST) != 0 && direction == toRouter {
+		delete(d.connections, session)
+	}
+
+	return nil
+}
+
+// linkPayload returns the IP packet carried by a link layer frame.
+func linkPayload(linkType uint32, frame []byte) ([]byte, bool) {
+	var etherType uint16
+	switch linkType {
+	case linkTypeNull:
+		if len(frame) < 4 {
+			return nil, false
+		}
+
+		return frame[4:], true
+	case linkTypeRaw, linkTypeIPv4, linkTypeIPv6:
+		return frame, true
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return nil, false
+		}
+
+		etherType, frame = binary.BigEndian.Uint16(frame[12:14]), frame[14:]
+		for etherType == 0x8100 && len(frame) >= 4 {
+			etherType, frame = binary.BigEndian.Uint16(frame[2:4]), frame[4:]
+		}
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 {
+			return nil, false
+		}
+
+		etherType, frame = binary.BigEndian.Uint16(frame[14:16]), frame[16:]
+	default:
+		return nil, false
+	}
+
+	return frame, etherType == 0x0800 || etherType == 0x86dd
+}
+
+// ipPayload returns the addresses and the TCP segment of an IPv4 or IPv6
+// packet. Fragments and IPv6 extension headers are not supported.
+func ipPayload(packet []byte) (src, dst netip.Addr, segment []byte, ok bool) {
+	if len(packet) == 0 {
+		return src, dst, nil, false
+	}
+
+	switch packet[0] >> 4 {
+	case 4:
+		if len(packet) < 20 || packet[9] != tcpProtocol || binary.BigEndian.Uint16(packet[6:8])&0x3fff != 0 {
+			return src, dst, nil, false
+		}
+
+		headerLength, totalLength := int(packet[0]&0x0f)*4, int(binary.BigEndian.Uint16(packet[2:4]))
+		if headerLength < 20 || totalLength < headerLength || totalLength > len(packet) {
+			return src, dst, nil, false
+		}
+
+		src, dst = netip.AddrFrom4([4]byte(packet[12:16])), netip.AddrFrom4([4]byte(packet[16:20]))
+		return src, dst, packet[headerLength:totalLength], true
+	case 6:
+		if len(packet) < 40 || packet[6] != tcpProtocol {
+			return src, dst, nil, false
+		}
+
+		totalLength := 40 + int(binary.BigEndian.Uint16(packet[4:6]))
+		if totalLength > len(packet) {
+			return src, dst, nil, false
+		}
+
+		src, dst = netip.AddrFrom16([16]byte(packet[8:24])), netip.AddrFrom16([16]byte(packet[24:40]))
+		return src, dst, packet[40:totalLength], true
+	default:
+		return src, dst, nil, false
+	}
+}
+
+// tcpStream reassembles one direction of a TCP connection.
+type tcpStream struct {
+	started bool
+	next    uint32
+	// pending holds segments that arrived ahead of next, pendingSize their
+	// total length.
+	pending     map[uint32][]byte
+	pendingSize int
+	// dropped is set once pending exceeded maxPendingBytes or a message
+	// exceeded the size limit, after which the stream is ignored.
+	dropped bool
+	// buffer holds the reassembled bytes that are not decoded yet.
+	buffer []byte
+}
+
+// add adds the payload of a segment starting at seq and reports whether the
+// reassembled stream grew. Retransmitted data is dropped and segments
+// overlapping the reassembled stream are trimmed. It fails once more than
+// maxPendingBytes arrived ahead of a missing segment, dropping the stream.
+func (s *tcpStream) add(seq uint32, syn bool, payload []byte) (bool, error) {
+	if s.dropped {
+		return false, nil
+	}
+
+	if syn {
+		s.started, s.next = true, seq+1
+		return false, nil
+	}
+
+	if len(payload) == 0 {
+		return false, nil
+	}
+
+	if !s.started {
+		s.started, s.next = true, seq
+	}
+
+	if diff := int32(seq - s.next); diff > 0 { //nolint:gosec // sequence numbers wrap around
+		if s.pending == nil {
+			s.pending = map[uint32][]byte{}
+		}
+
+		if previous, ok := s.pending[seq]; ok && len(previous) >= len(payload) {
+			return false, nil
+		}
+
+		s.pendingSize += len(payload) - len(s.pending[seq])
+		s.pending[seq] = append([]byte(nil), payload...)
+		if s.pendingSize > maxPendingBytes {
+			s.drop()
+			return false, fmt.Errorf("more than %d bytes arrived ahead of a missing TCP segment, dropping the "+
+				"stream", maxPendingBytes)
+		}
+
+		return false, nil
+	} else if -int(diff) >= len(payload) {
+		return false, nil
+	} else {
+		payload = payload[-diff:]
+	}
+
+	s.buffer = append(s.buffer, payload...)
+	s.next += uint32(len(payload)) //nolint:gosec // sequence numbers wrap around
+	s.drain()
+	return true, nil
+}
+
+// drop discards the stream and ignores the segments that follow.
+func (s *tcpStream) drop() {
+	s.dropped, s.pending, s.pendingSize, s.buffer = true, nil, 0, nil
+}
+
+// drain moves the pending segments that start at or before next to the
+// reassembled stream, trimming the bytes it already holds.
+func (s *tcpStream) drain() {
+	for progress := true; progress; {
+		progress = false
+		for seq, data := range s.pending {
+			diff := int32(seq - s.next) //nolint:gosec // sequence numbers wrap around
+			if diff > 0 {
+				continue
+			}
+
+			delete(s.pending, seq)
+			s.pendingSize -= len(data)
+			if -int(diff) < len(data) {
+				s.buffer = append(s.buffer, data[-diff:]...)
+				s.next += uint32(len(data) + int(diff)) //nolint:gosec // sequence numbers wrap around
+				progress = true
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestTCPStreamOverlap(t *testing.T) {
+	var s tcpStream
+	if _, err := s.add(99, true, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Segments arriving ahead of a lost one, overlapping each other and the
+	// segment filling the gap.
+	for _, segment := range []struct {
+		seq  uint32
+		data string
+	}{{105, "fghij"}, {103, "defg"}, {101, "bcd"}, {100, "abc"}, {104, "efg"}} {
+		if _, err := s.add(segment.seq, false, []byte(segment.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if string(s.buffer) != "abcdefghij" || s.next != 110 {
+		t.Errorf("expected abcdefghij up to 110, got %q up to %d", s.buffer, s.next)
+	}
+
+	if len(s.pending) != 0 || s.pendingSize != 0 {
+		t.Errorf("expected no pending segments, got %d of %d bytes", len(s.pending), s.pendingSize)
+	}
+}
+
+func TestTCPStreamPendingLimit(t *testing.T) {
+	var s tcpStream
+	if _, err := s.add(0, true, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	segment := make([]byte, 1<<20)
+	var err error
+	for seq := uint32(2); err == nil; seq += uint32(len(segment)) {
+		if s.pendingSize > maxPendingBytes {
+			t.Fatalf("%d pending bytes exceed the limit", s.pendingSize)
+		}
+
+		_, err = s.add(seq, false, segment)
+	}
+
+	if !s.dropped || s.pending != nil {
+		t.Errorf("expected the stream to be dropped")
+	}
+
+	if grew, err := s.add(1, false, []byte("a")); grew || err != nil {
+		t.Errorf("expected a dropped stream to ignore segments, got %v, %v", grew, err)
+	}
+}
+
+// rawSocketCapture returns a pcap capture of one TCP segment from a client to
+// port 8080 carrying a RawSocket handshake and message.
+func rawSocketCapture(message []byte) []byte {
+	stream := []byte{0x7f, 0xf1, 0x00, 0x00, 0x00, byte(len(message) >> 16), byte(len(message) >> 8),
+		byte(len(message))}
+	stream = append(stream, message...)
+
+	packet := make([]byte, 40, 40+len(stream))
+	packet[0], packet[8], packet[9] = 0x45, 64, tcpProtocol
+	binary.BigEndian.PutUint16(packet[2:4], uint16(40+len(stream)))
+	copy(packet[12:16], []byte{10, 0, 0, 1})
+	copy(packet[16:20], []byte{10, 0, 0, 2})
+	binary.BigEndian.PutUint16(packet[20:22], 50000)
+	binary.BigEndian.PutUint16(packet[22:24], 8080)
+	binary.BigEndian.PutUint32(packet[24:28], 1)
+	packet[32], packet[33] = 0x50, 0x18
+	packet = append(packet, stream...)
+
+	capture := make([]byte, pcapHeaderSize+pcapRecordHeaderSize)
+	binary.LittleEndian.PutUint32(capture[0:4], pcapMagicMicros)
+	binary.LittleEndian.PutUint32(capture[20:24], linkTypeRaw)
+	binary.LittleEndian.PutUint32(capture[32:36], uint32(len(packet)))
+	binary.LittleEndian.PutUint32(capture[36:40], uint32(len(packet)))
+	return append(capture, packet...)
+}
+
+func TestDecodePcapLimit(t *testing.T) {
+	capture := rawSocketCapture([]byte(`[48,1,{},"com.example.add"]`))
+	for _, test := range []struct {
+		limit int
+		fails bool
+	}{{0, false}, {64, false}, {16, true}} {
+		var records []*TranscriptRecord
+		err := DecodePcap(bytes.NewReader(capture), 8080, test.limit, func(record *TranscriptRecord) error {
+			records = append(records, record)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("limit %d: %v", test.limit, err)
+		}
+
+		if len(records) != 1 || (records[0].Error != "") != test.fails {
+			t.Errorf("limit %d: expected one record failing: %v, got %+v", test.limit, test.fails, records)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/xconnio/wampproto-go/messages"
+	"github.com/xconnio/wampproto-go/serializers"
+)
+
+// Serializer instances are stateless, so one of each is shared by every
+// caller. Their encode buffers are pooled so that high-rate modes such as
+// batch and http-serve don't allocate a growing buffer per message.
+var (
+	sharedJSONSerializer    = &pooledJSONSerializer{}    //nolint:gochecknoglobals
+	sharedCBORSerializer    = &pooledCBORSerializer{}    //nolint:gochecknoglobals
+	sharedMsgPackSerializer = &pooledMsgPackSerializer{} //nolint:gochecknoglobals
+
+	sortedCBORSerializer    = &pooledCBORSerializer{encMode: sortedCBOREncMode()} //nolint:gochecknoglobals
+	sortedMsgPackSerializer = &pooledMsgPackSerializer{sortKeys: true}            //nolint:gochecknoglobals
+
+	bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }} //nolint:gochecknoglobals
+
+	// cborDecMode decodes maps with string keys, like serializers.CBORSerializer.
+	// Invalid UTF-8 is decoded rather than rejected so that validation can
+	// report it, or tolerate it in lenient mode.
+	cborDecMode, _ = cbor.DecOptions{ //nolint:gochecknoglobals
+		DefaultMapType: reflect.TypeOf(map[string]any(nil)),
+		UTF8:           cbor.UTF8DecodeInvalid,
+	}.DecMode()
+)
+
+// encodePooled runs encode against a pooled buffer and returns a copy of
+// what was written.
+func encodePooled(encode func(*bytes.Buffer) error) ([]byte, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		bufferPool.Put(buf)
+	}()
+
+	if err := encode(buf); err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(buf.Bytes()), nil
+}
+
+// pooledJSONSerializer produces the same bytes as serializers.JSONSerializer.
+type pooledJSONSerializer struct {
+	serializers.JSONSerializer
+}
+
+func (p *pooledJSONSerializer) Serialize(message messages.Message) ([]byte, error) {
+	return encodePooled(func(buf *bytes.Buffer) error {
+		if err := json.NewEncoder(buf).Encode(message.Marshal()); err != nil {
+			return err
+		}
+
+		// Encode terminates every value with a newline, Marshal does not.
+		buf.Truncate(buf.Len() - 1)
+		return nil
+	})
+}
+
+// pooledCBORSerializer produces the same bytes as serializers.CBORSerializer,
+// or encodes with encMode if set.
+type pooledCBORSerializer struct {
+	serializers.CBORSerializer
+	encMode cbor.EncMode
+}
+
+func (p *pooledCBORSerializer) Serialize(message messages.Message) ([]byte, error) {
+	return encodePooled(func(buf *bytes.Buffer) error {
+		if p.encMode != nil {
+			return p.encMode.NewEncoder(buf).Encode(message.Marshal())
+		}
+
+		return cbor.NewEncoder(buf).Encode(message.Marshal())
+	})
+}
+
+// sortedCBOREncMode sorts map keys as required by the core deterministic
+// encoding of RFC 8949.
+func sortedCBOREncMode() cbor.EncMode {
+	encMode, _ := cbor.EncOptions{Sort: cbor.SortCoreDeterministic}.EncMode()
+	return encMode
+}
+
+// pooledMsgPackSerializer produces the same bytes as serializers.MsgPackSerializer,
+// with map keys sorted if sortKeys is set.
+type pooledMsgPackSerializer struct {
+	serializers.MsgPackSerializer
+	sortKeys bool
+}
+
+func (p *pooledMsgPackSerializer) Serialize(message messages.Message) ([]byte, error) {
+	return encodePooled(func(buf *bytes.Buffer) error {
+		encoder := msgpack.NewEncoder(buf)
+		encoder.SetSortMapKeys(p.sortKeys)
+		return encoder.Encode(message.Marshal())
+	})
+}
@@ -0,0 +1,35 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xconnio/wampproto-go/messages"
+	"github.com/xconnio/wampproto-go/serializers"
+)
+
+// TestPooledSerializers checks that the pooled serializers produce the same
+// bytes as those of wampproto-go.
+func TestPooledSerializers(t *testing.T) {
+	message := messages.NewCall(1, map[string]any{"timeout": 1000}, "com.example.add", []any{2, "three", 4.5},
+		map[string]any{"name": "alice"})
+	for name, pair := range map[string][2]serializers.Serializer{
+		JSONSerializer:    {sharedJSONSerializer, &serializers.JSONSerializer{}},
+		CBORSerializer:    {sharedCBORSerializer, &serializers.CBORSerializer{}},
+		MsgPackSerializer: {sharedMsgPackSerializer, &serializers.MsgPackSerializer{}},
+	} {
+		pooled, err := pair[0].Serialize(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected, err := pair[1].Serialize(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(pooled, expected) {
+			t.Errorf("%s: expected %x, got %x", name, expected, pooled)
+		}
+	}
+}
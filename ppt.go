@@ -0,0 +1,307 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// PPTNativeSerializer is the payload passthru serializer of payloads that
+// are carried as regular arguments, serialized with the transport.
+const PPTNativeSerializer = "native"
+
+// PPTSerializerNames returns the payload passthru serializers PackPPT supports.
+func PPTSerializerNames() []string {
+	return append([]string{PPTNativeSerializer}, SerializerNames()...)
+}
+
+// jsonBinaryPrefix marks strings carrying base64 encoded binary data in JSON,
+// which has no binary type.
+const jsonBinaryPrefix = "\x00"
+
+// PPT holds the payload passthru options of a message.
+type PPT struct {
+	Scheme     string `json:"ppt_scheme,omitempty"`
+	Serializer string `json:"ppt_serializer,omitempty"`
+	Cipher     string `json:"ppt_cipher,omitempty"`
+	KeyID      string `json:"ppt_keyid,omitempty"`
+	// Keys encrypt the payload if Cipher is CryptoboxCipher.
+	Keys *CryptoboxKeys `json:"-"`
+}
+
+// PPTPayload is the payload carried by a message in payload passthru mode.
+type PPTPayload struct {
+	Args   []any          `json:"args,omitempty"`
+	Kwargs map[string]any `json:"kwargs,omitempty"`
+}
+
+// validate checks p against the rules of the payload passthru mode.
+func (p PPT) validate() error {
+	if p.Scheme == "" {
+		if p.Serializer != "" || p.Cipher != "" || p.KeyID != "" {
+			return errors.New("payload passthru options require a ppt_scheme")
+		}
+
+		return nil
+	}
+
+	if p.Scheme != "wamp" && p.Scheme != "mqtt" && !strings.HasPrefix(p.Scheme, "x_") {
+		return fmt.Errorf("invalid ppt_scheme %q, must be wamp, mqtt or start with x_", p.Scheme)
+	}
+
+	if p.Serializer != "" && p.Serializer != PPTNativeSerializer {
+		if _, err := SerializerByName(p.Serializer); err != nil {
+			return fmt.Errorf("unsupported ppt_serializer %q, must be one of %v", p.Serializer,
+				PPTSerializerNames())
+		}
+	}
+
+	return nil
+}
+
+// PackPPT adds the payload passthru options p to options and returns the
+// arguments and keyword arguments of the message carrying args and kwargs.
+// Unless the ppt_serializer is native, the payload is serialized with it as
+// {"args": [...], "kwargs": {...}} and carried as the single binary argument,
+// encoded for transportSerializer.
+func PackPPT(transportSerializer string, p PPT, options map[string]any, args []any,
+	kwargs map[string]any) ([]any, map[string]any, error) {
+	if err := p.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if p.Scheme == "" {
+		return args, kwargs, nil
+	}
+
+	for key, value := range map[string]string{
+		pptSchemeOption:     p.Scheme,
+		pptSerializerOption: p.Serializer,
+		pptCipherOption:     p.Cipher,
+		pptKeyIDOption:      p.KeyID,
+	} {
+		if value != "" {
+			options[key] = value
+		}
+	}
+
+	if p.Serializer == "" || p.Serializer == PPTNativeSerializer {
+		return args, kwargs, nil
+	}
+
+	data, err := SerializePayload(p.Serializer, &PPTPayload{Args: args, Kwargs: kwargs})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch p.Cipher {
+	case "":
+	case CryptoboxCipher:
+		if p.Keys == nil {
+			return nil, nil, fmt.Errorf("ppt_cipher %s requires a private key and the peer's public key",
+				CryptoboxCipher)
+		}
+
+		if data, err = EncryptPayload(data, *p.Keys); err != nil {
+			return nil, nil, err
+		}
+	case SealedBoxCipher:
+		if p.Keys == nil {
+			return nil, nil, fmt.Errorf("ppt_cipher %s requires the recipient's public key", SealedBoxCipher)
+		}
+
+		if data, err = SealPayload(data, p.Keys.PeerPublicKey); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported ppt_cipher %q, must be %s or %s", p.Cipher, CryptoboxCipher,
+			SealedBoxCipher)
+	}
+
+	return []any{encodeBinary(transportSerializer, data)}, nil, nil
+}
+
+// UnpackPPT returns the payload carried by the positional message raw in
+// payload passthru mode, or nil if raw doesn't carry a serialized payload.
+// Payloads encrypted with CryptoboxCipher or SealedBoxCipher are decrypted
+// with keys, or left alone if keys is nil.
+func UnpackPPT(transportSerializer string, raw []any, keys *CryptoboxKeys) (*PPTPayload, error) {
+	optionsPosition, argsPosition := payloadPositions(rawMessageType(raw))
+	if argsPosition == 0 || optionsPosition >= len(raw) {
+		return nil, nil
+	}
+
+	options, _ := raw[optionsPosition].(map[string]any)
+	scheme, _ := options[pptSchemeOption].(string)
+	serializer, _ := options[pptSerializerOption].(string)
+	cipher, _ := options[pptCipherOption].(string)
+	if scheme == "" || serializer == "" || serializer == PPTNativeSerializer {
+		return nil, nil
+	}
+
+	if cipher != "" && (cipher != CryptoboxCipher && cipher != SealedBoxCipher || keys == nil) {
+		return nil, nil
+	}
+
+	var args []any
+	if argsPosition < len(raw) {
+		args, _ = raw[argsPosition].([]any)
+	}
+
+	if len(args) != 1 || len(raw) > argsPosition+1 {
+		return nil, errors.New("a payload passthru message must carry its payload as the single argument")
+	}
+
+	data, ok := decodeBinary(transportSerializer, args[0])
+	if !ok {
+		return nil, errors.New("the payload passthru argument must be binary")
+	}
+
+	var err error
+	switch cipher {
+	case CryptoboxCipher:
+		data, err = DecryptPayload(data, *keys)
+	case SealedBoxCipher:
+		data, err = OpenPayload(data, keys.PrivateKey)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DeserializePayload(serializer, data)
+}
+
+// SerializePayload serializes payload as {"args": [...], "kwargs": {...}},
+// omitting empty arguments and keyword arguments.
+func SerializePayload(serializer string, payload *PPTPayload) ([]byte, error) {
+	value := make(map[string]any, 2)
+	if len(payload.Args) > 0 {
+		value["args"] = payload.Args
+	}
+
+	if len(payload.Kwargs) > 0 {
+		value["kwargs"] = payload.Kwargs
+	}
+
+	return serializeValue(serializer, value)
+}
+
+// DeserializePayload reverses SerializePayload.
+func DeserializePayload(serializer string, data []byte) (*PPTPayload, error) {
+	value, err := deserializeValue(serializer, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", serializer, err)
+	}
+
+	payload, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("the %s payload must be a map", serializer)
+	}
+
+	result := &PPTPayload{}
+	result.Args, _ = payload["args"].([]any)
+	result.Kwargs, _ = payload["kwargs"].(map[string]any)
+	return result, nil
+}
+
+// rawMessageType returns the type of the positional message raw, or 0 if it
+// has none.
+func rawMessageType(raw []any) int {
+	if len(raw) == 0 {
+		return 0
+	}
+
+	messageType, _ := messages.AsInt64(raw[0])
+	return int(messageType)
+}
+
+// payloadPositions returns the positions of the options or details
+// dictionary and of the arguments of message types supporting payload
+// passthru mode, or 0 for the arguments of other message types.
+func payloadPositions(messageType int) (int, int) {
+	switch messageType {
+	case messages.MessageTypeCall, messages.MessageTypePublish:
+		return 2, 4
+	case messages.MessageTypeInvocation, messages.MessageTypeEvent:
+		return 3, 4
+	case messages.MessageTypeResult, messages.MessageTypeYield:
+		return 2, 3
+	default:
+		return 0, 0
+	}
+}
+
+// encodeBinary encodes data as a binary value of serializer. JSON carries it
+// as a base64 string prefixed with a NUL character, as the spec requires.
+func encodeBinary(serializer string, data []byte) any {
+	if serializer == JSONSerializer {
+		return jsonBinaryPrefix + base64.StdEncoding.EncodeToString(data)
+	}
+
+	return data
+}
+
+// decodeBinary reverses encodeBinary.
+func decodeBinary(serializer string, value any) ([]byte, bool) {
+	switch v := value.(type) {
+	case []byte:
+		return v, serializer != JSONSerializer
+	case string:
+		if serializer != JSONSerializer || !strings.HasPrefix(v, jsonBinaryPrefix) {
+			return nil, false
+		}
+
+		data, err := base64.StdEncoding.DecodeString(v[len(jsonBinaryPrefix):])
+		return data, err == nil
+	default:
+		return nil, false
+	}
+}
+
+// serializeValue encodes value with the named serializer.
+func serializeValue(serializer string, value any) ([]byte, error) {
+	switch serializer {
+	case JSONSerializer:
+		return json.Marshal(value)
+	case CBORSerializer:
+		return cbor.Marshal(value)
+	case MsgPackSerializer:
+		return msgpack.Marshal(value)
+	default:
+		return nil, fmt.Errorf("unknown serializer %q", serializer)
+	}
+}
+
+// deserializeValue decodes data with the named serializer. JSON integers keep
+// their precision as they do with DeserializeRaw.
+func deserializeValue(serializer string, data []byte) (any, error) {
+	var value any
+	var err error
+	switch serializer {
+	case JSONSerializer:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err = decoder.Decode(&value); err == nil {
+			value = NormalizeJSON(value)
+		}
+	case CBORSerializer:
+		err = cborDecMode.Unmarshal(data, &value)
+	case MsgPackSerializer:
+		if err = checkMsgPackLengths(data); err == nil {
+			err = msgpack.Unmarshal(data, &value)
+		}
+	default:
+		return nil, fmt.Errorf("unknown serializer %q", serializer)
+	}
+
+	return value, err
+}
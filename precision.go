@@ -0,0 +1,124 @@
+package wampprotocli
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// MaxSafeInteger is the largest integer that JSON consumers using IEEE-754
+// doubles, such as JavaScript, can represent exactly.
+const MaxSafeInteger = 1 << 53
+
+// Policies for integers beyond MaxSafeInteger when producing JSON.
+const (
+	BigIntExact  = "exact"
+	BigIntError  = "error"
+	BigIntClamp  = "clamp"
+	BigIntString = "string"
+)
+
+// BigIntPolicies returns the names accepted by ApplyBigIntPolicy.
+func BigIntPolicies() []string {
+	return []string{BigIntExact, BigIntError, BigIntClamp, BigIntString}
+}
+
+// ApplyBigIntPolicy applies policy to every integer below value whose
+// magnitude exceeds MaxSafeInteger, in place where possible, and returns the
+// result along with a warning for every replacement. BigIntExact, the default
+// for an empty policy, keeps all digits, BigIntError fails on the first such
+// integer, BigIntClamp replaces it with ±MaxSafeInteger and BigIntString with
+// its decimal representation.
+func ApplyBigIntPolicy(value any, policy string) (any, []Warning, error) {
+	switch policy {
+	case "", BigIntExact:
+		return value, nil, nil
+	case BigIntError, BigIntClamp, BigIntString:
+	default:
+		return nil, nil, fmt.Errorf("unknown big integer policy %q, must be one of %v", policy, BigIntPolicies())
+	}
+
+	var warnings []Warning
+	result, err := applyBigIntPolicy(value, policy, "", &warnings)
+	return result, warnings, err
+}
+
+func applyBigIntPolicy(value any, policy, path string, warnings *[]Warning) (any, error) {
+	var negative, unsafe bool
+	switch v := value.(type) {
+	case int64:
+		negative, unsafe = v < 0, v > MaxSafeInteger || v < -MaxSafeInteger
+	case uint64:
+		unsafe = v > MaxSafeInteger
+	case []any:
+		for i, item := range v {
+			replaced, err := applyBigIntPolicy(item, policy, fmt.Sprintf("%s[%d]", path, i), warnings)
+			if err != nil {
+				return nil, err
+			}
+
+			v[i] = replaced
+		}
+
+		return v, nil
+	case map[string]any:
+		for _, key := range sortedKeys(v) {
+			replaced, err := applyBigIntPolicy(v[key], policy, fmt.Sprintf("%s[%q]", path, key), warnings)
+			if err != nil {
+				return nil, err
+			}
+
+			v[key] = replaced
+		}
+
+		return v, nil
+	}
+
+	if !unsafe {
+		return value, nil
+	}
+
+	switch policy {
+	case BigIntClamp:
+		*warnings = append(*warnings, Warning{
+			Category: CategoryPrecision,
+			Message:  fmt.Sprintf("clamped integer %v at %s to ±2^53", value, path),
+		})
+
+		if negative {
+			return int64(-MaxSafeInteger), nil
+		}
+		return int64(MaxSafeInteger), nil
+	case BigIntString:
+		*warnings = append(*warnings, Warning{
+			Category: CategoryPrecision,
+			Message:  fmt.Sprintf("replaced integer %v at %s with a string", value, path),
+		})
+		return fmt.Sprint(value), nil
+	default:
+		return nil, fmt.Errorf("integer %v at %s exceeds 2^53 and loses precision in JSON", value, path)
+	}
+}
+
+// jsonNumberExact reports whether number survives NormalizeJSON without
+// losing precision, i.e. it is an int64 or the shortest decimal form of the
+// float64 it is decoded to denotes the same value.
+func jsonNumberExact(number json.Number) bool {
+	if _, err := number.Int64(); err == nil {
+		return true
+	}
+
+	f, err := number.Float64()
+	if err != nil {
+		return false
+	}
+
+	literal, ok := new(big.Rat).SetString(number.String())
+	if !ok {
+		return false
+	}
+
+	decoded, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return ok && literal.Cmp(decoded) == 0
+}
@@ -0,0 +1,148 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+)
+
+// Router configuration formats of exported principals.
+const (
+	CrossbarFormat = "crossbar"
+	NexusFormat    = "nexus"
+)
+
+// Authentication methods of principals.
+const (
+	CryptosignAuth = "cryptosign"
+	TicketAuth     = "ticket"
+	WAMPCRAAuth    = "wampcra"
+)
+
+// PrincipalFormats returns the router configuration formats principals are
+// exported in.
+func PrincipalFormats() []string {
+	return []string{CrossbarFormat, NexusFormat}
+}
+
+// PrincipalMethods returns the authentication methods of principals.
+func PrincipalMethods() []string {
+	return []string{CryptosignAuth, TicketAuth, WAMPCRAAuth}
+}
+
+// Principal is a client identity as a router authenticates it.
+type Principal struct {
+	AuthID string
+	Realm  string
+	Role   string
+	Method string
+	// PublicKey is the hex encoded public key of cryptosign.
+	PublicKey string
+	// Secret is the ticket of ticket and the secret of wampcra.
+	Secret string
+}
+
+// ExportPrincipal returns the router configuration authenticating the
+// principal: the auth section of a Crossbar.io transport, or a Go key store
+// for the authenticators of a nexus realm.
+func ExportPrincipal(principal Principal, format string) ([]byte, error) {
+	if principal.AuthID == "" || principal.Realm == "" || principal.Role == "" {
+		return nil, errors.New("authid, realm and role are required")
+	}
+
+	switch principal.Method {
+	case CryptosignAuth:
+		if key, err := hex.DecodeString(principal.PublicKey); err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%s principals need a %d byte hex encoded public key", principal.Method,
+				ed25519.PublicKeySize)
+		}
+	case TicketAuth, WAMPCRAAuth:
+		if principal.Secret == "" {
+			return nil, fmt.Errorf("%s principals need a secret", principal.Method)
+		}
+	default:
+		return nil, fmt.Errorf("unknown authentication method %q, must be one of %v", principal.Method,
+			PrincipalMethods())
+	}
+
+	switch format {
+	case CrossbarFormat:
+		return crossbarPrincipal(principal)
+	case NexusFormat:
+		var buffer bytes.Buffer
+		if err := nexusKeyStoreTemplate.Execute(&buffer, principal); err != nil {
+			return nil, err
+		}
+
+		return buffer.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unknown principal format %q, must be one of %v", format, PrincipalFormats())
+	}
+}
+
+// crossbarPrincipal returns the auth section of a Crossbar.io transport
+// with a static principal.
+func crossbarPrincipal(principal Principal) ([]byte, error) {
+	entry := map[string]any{"realm": principal.Realm, "role": principal.Role}
+	kind := "principals"
+	switch principal.Method {
+	case CryptosignAuth:
+		entry["authorized_keys"] = []string{principal.PublicKey}
+	case TicketAuth:
+		entry["ticket"] = principal.Secret
+	case WAMPCRAAuth:
+		entry["secret"] = principal.Secret
+		kind = "users"
+	}
+
+	return json.MarshalIndent(map[string]any{
+		principal.Method: map[string]any{
+			"type": "static",
+			kind:   map[string]any{principal.AuthID: entry},
+		},
+	}, "", "  ")
+}
+
+//nolint:gochecknoglobals
+var nexusKeyStoreTemplate = template.Must(template.New("nexus").Parse(`// principalKeyStore implements the
+// auth.KeyStore of github.com/gammazero/nexus/v3/router/auth for the {{.Method}} principal
+// {{printf "%q" .AuthID}} of realm {{printf "%q" .Realm}}.
+type principalKeyStore struct{}
+
+func (principalKeyStore) AuthKey(authid, authmethod string) ([]byte, error) {
+	if authid != {{printf "%q" .AuthID}} || authmethod != {{printf "%q" .Method}} {
+		return nil, errors.New("no such principal")
+	}
+{{if eq .Method "cryptosign"}}
+	return hex.DecodeString({{printf "%q" .PublicKey}})
+{{- else}}
+	return []byte({{printf "%q" .Secret}}), nil
+{{- end}}
+}
+
+func (principalKeyStore) PasswordInfo(string) (string, int, int) { return "", 0, 0 }
+
+func (principalKeyStore) Provider() string { return "static" }
+
+func (principalKeyStore) AuthRole(authid string) (string, error) {
+	if authid != {{printf "%q" .AuthID}} {
+		return "", errors.New("no such principal")
+	}
+
+	return {{printf "%q" .Role}}, nil
+}
+
+{{if eq .Method "cryptosign" -}}
+// Pass principalKeyStore{} to the cryptosign authenticator of realm {{printf "%q" .Realm}}.
+{{else -}}
+// Add the authenticator to the RealmConfig of realm {{printf "%q" .Realm}}:
+//
+//	Authenticators: []auth.Authenticator{
+//		auth.New{{if eq .Method "ticket"}}Ticket{{else}}CR{{end}}Authenticator(principalKeyStore{}, time.Second),
+//	},
+{{end -}}
+`))
@@ -0,0 +1,81 @@
+package wampprotocli
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+// randomSource is the source of the keys, nonces, challenges, IDs and realms
+// the package generates: the operating system CSPRNG unless replaced with
+// SetRandomSource.
+var randomSource io.Reader = rand.Reader //nolint:gochecknoglobals
+
+// RandomReader returns the source of all randomness of the package.
+func RandomReader() io.Reader {
+	return randomSource
+}
+
+// SetRandomSource replaces the source of all randomness of the package and
+// returns the previous one. It must not be called while randomness is drawn.
+func SetRandomSource(source io.Reader) io.Reader {
+	previous := randomSource
+	randomSource = source
+	return previous
+}
+
+// clock returns the current time of the timestamps the package generates:
+// time.Now unless replaced with SetClock.
+var clock = time.Now //nolint:gochecknoglobals
+
+// SetClock replaces the clock of the timestamps the package generates, e.g.
+// to make fixtures reproducible, and returns the previous one. It must not be
+// called while timestamps are generated.
+func SetClock(now func() time.Time) func() time.Time {
+	previous := clock
+	clock = now
+	return previous
+}
+
+// seededReader is a deterministic stream of bytes, the SHA-256 hashes of the
+// seed followed by a block counter. It is reproducible across platforms and
+// Go versions, but predictable, so it must only be used for fixtures.
+type seededReader struct {
+	mu      sync.Mutex
+	seed    [8]byte
+	counter uint64
+	buffer  []byte
+}
+
+// NewSeededReader returns a deterministic source of randomness for seed.
+func NewSeededReader(seed int64) io.Reader {
+	r := &seededReader{}
+	binary.BigEndian.PutUint64(r.seed[:], uint64(seed))
+	return r
+}
+
+func (r *seededReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n := 0; n < len(p); {
+		if len(r.buffer) == 0 {
+			var block [16]byte
+			copy(block[:8], r.seed[:])
+			binary.BigEndian.PutUint64(block[8:], r.counter)
+			r.counter++
+
+			sum := sha256.Sum256(block[:])
+			r.buffer = sum[:]
+		}
+
+		copied := copy(p[n:], r.buffer)
+		r.buffer = r.buffer[copied:]
+		n += copied
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,179 @@
+package wampprotocli
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// ReplayTransport exchanges serialized messages with a live router.
+type ReplayTransport interface {
+	Send(data []byte) error
+	Receive() ([]byte, error)
+}
+
+// ReplayResult compares a message the router sent in a recorded session with
+// the one it sends when the session is replayed.
+type ReplayResult struct {
+	// Index is the 1-based position of the recorded message in the session.
+	Index    int    `json:"index"`
+	Recorded []any  `json:"recorded"`
+	Received []any  `json:"received"`
+	Match    bool   `json:"match"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Kinds of the IDs a router chooses.
+const (
+	sessionIDKind      = "session"
+	subscriptionIDKind = "subscription"
+	publicationIDKind  = "publication"
+	registrationIDKind = "registration"
+	invocationIDKind   = "invocation"
+)
+
+// routerID is an ID a router chose, qualified by its kind as IDs of
+// different kinds may collide.
+type routerID struct {
+	kind string
+	id   int64
+}
+
+// routerIDPositions returns the positions and kinds of the IDs the router
+// chooses in messages it sends, which differ between sessions.
+func routerIDPositions(messageType int) map[int]string {
+	switch messageType {
+	case messages.MessageTypeWelcome:
+		return map[int]string{1: sessionIDKind}
+	case messages.MessageTypeInterrupt:
+		return map[int]string{1: invocationIDKind}
+	case messages.MessageTypeSubscribed:
+		return map[int]string{2: subscriptionIDKind}
+	case messages.MessageTypePublished:
+		return map[int]string{2: publicationIDKind}
+	case messages.MessageTypeRegistered:
+		return map[int]string{2: registrationIDKind}
+	case messages.MessageTypeEvent:
+		return map[int]string{1: subscriptionIDKind, 2: publicationIDKind}
+	case messages.MessageTypeInvocation:
+		return map[int]string{1: invocationIDKind, 2: registrationIDKind}
+	default:
+		return nil
+	}
+}
+
+// clientIDPositions returns the positions and kinds of router chosen IDs the
+// client refers to in messages it sends.
+func clientIDPositions(raw []any) map[int]string {
+	switch rawMessageType(raw) {
+	case messages.MessageTypeUnSubscribe:
+		return map[int]string{2: subscriptionIDKind}
+	case messages.MessageTypeUnRegister:
+		return map[int]string{2: registrationIDKind}
+	case messages.MessageTypeYield:
+		return map[int]string{1: invocationIDKind}
+	case messages.MessageTypeError:
+		if len(raw) > 1 {
+			if requestType, _ := messages.AsInt64(raw[1]); requestType == messages.MessageTypeInvocation {
+				return map[int]string{2: invocationIDKind}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ReplaySession resends the messages the client sent in the recorded session
+// records over transport and compares every message the router sends back
+// with the recorded one, in order, calling fn with each comparison. IDs the
+// router chooses, like subscription, registration and invocation IDs, are
+// learned from its responses and replace the recorded ones in the messages
+// the client sends.
+func ReplaySession(transport ReplayTransport, records []*TranscriptRecord, fn func(*ReplayResult) error) error {
+	ids := map[routerID]int64{}
+	for i, record := range records {
+		recorded, err := DeserializeRaw(record.Serializer, record.Data)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", i+1, err)
+		}
+
+		if record.Direction == DirectionToRouter {
+			if err = replaySend(transport, record, recorded, ids); err != nil {
+				return fmt.Errorf("record %d: %w", i+1, err)
+			}
+
+			continue
+		}
+
+		result := &ReplayResult{Index: i + 1, Recorded: recorded}
+		data, err := transport.Receive()
+		if err != nil {
+			result.Error = err.Error()
+			return fn(result)
+		}
+
+		if result.Received, err = DeserializeRaw(record.Serializer, data); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Match = compareReplayed(recorded, result.Received, ids)
+		}
+
+		if err = fn(result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replaySend sends the recorded client message, replacing the router chosen
+// IDs it refers to.
+func replaySend(transport ReplayTransport, record *TranscriptRecord, raw []any, ids map[routerID]int64) error {
+	data := record.Data
+	remapped := false
+	for position, kind := range clientIDPositions(raw) {
+		if position >= len(raw) {
+			continue
+		}
+
+		if id, ok := messages.AsInt64(raw[position]); ok {
+			if live, ok := ids[routerID{kind: kind, id: id}]; ok && live != id {
+				raw[position], remapped = live, true
+			}
+		}
+	}
+
+	if remapped {
+		var err error
+		if data, err = serializeValue(record.Serializer, raw); err != nil {
+			return err
+		}
+	}
+
+	return transport.Send(data)
+}
+
+// compareReplayed reports whether received matches recorded apart from the
+// IDs the router chooses, whose live values are added to ids.
+func compareReplayed(recorded, received []any, ids map[routerID]int64) bool {
+	if len(recorded) != len(received) || rawMessageType(recorded) != rawMessageType(received) {
+		return false
+	}
+
+	positions := routerIDPositions(rawMessageType(recorded))
+	for i := range recorded {
+		recordedID, recordedOK := messages.AsInt64(recorded[i])
+		receivedID, receivedOK := messages.AsInt64(received[i])
+		if kind, ok := positions[i]; ok && recordedOK && receivedOK {
+			ids[routerID{kind: kind, id: recordedID}] = receivedID
+			continue
+		}
+
+		if !reflect.DeepEqual(recorded[i], received[i]) {
+			return false
+		}
+	}
+
+	return true
+}
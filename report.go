@@ -0,0 +1,178 @@
+package wampprotocli
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// Phases of a session in a report.
+const (
+	phaseHandshake      = "Handshake"
+	phaseAuthentication = "Authentication"
+	phaseSession        = "Session"
+	phaseClosing        = "Closing"
+)
+
+// reportSession is a session as a report shows it.
+type reportSession struct {
+	Name     string
+	Start    string
+	Duration time.Duration
+	Count    int
+	Rows     []reportRow
+}
+
+// reportRow is a message or the start of a phase in a report.
+type reportRow struct {
+	Phase    string
+	Offset   string
+	ToRouter bool
+	Summary  string
+	Detail   string
+}
+
+// WriteReport writes an HTML report of records showing each session as a
+// sequence diagram between client and router, divided into the handshake,
+// the authentication, the established session and its closing. Every message
+// can be expanded into its decoded form.
+func WriteReport(w io.Writer, title string, records []*TranscriptRecord) error {
+	sessions := make([]reportSession, 0)
+	for _, sessionRecords := range SplitTranscript(records) {
+		sessions = append(sessions, newReportSession(sessionRecords))
+	}
+
+	return reportTemplate.Execute(w, map[string]any{"Title": title, "Sessions": sessions})
+}
+
+func newReportSession(records []*TranscriptRecord) reportSession {
+	start, end := records[0].Time, records[len(records)-1].Time
+	session := reportSession{
+		Name:     records[0].Session,
+		Start:    start.Format(time.RFC3339Nano),
+		Duration: end.Sub(start),
+		Count:    len(records),
+	}
+
+	phase := ""
+	for _, record := range records {
+		exported := ExportRecord(record)
+		if next := nextPhase(phase, exported.Type); next != phase {
+			phase = next
+			session.Rows = append(session.Rows, reportRow{Phase: phase})
+		}
+
+		row := reportRow{
+			Offset:   fmt.Sprintf("+%s", record.Time.Sub(start)),
+			ToRouter: record.Direction == DirectionToRouter,
+			Summary:  reportSummary(exported),
+			Detail:   exported.DecodeError,
+		}
+
+		if raw, err := DeserializeRaw(record.Serializer, record.Data); err == nil {
+			detail, _ := json.MarshalIndent(raw, "", "  ")
+			row.Detail = string(detail)
+		}
+
+		session.Rows = append(session.Rows, row)
+	}
+
+	return session
+}
+
+// nextPhase returns the phase of a session after a message of messageType.
+func nextPhase(phase string, messageType int) string {
+	switch messageType {
+	case messages.MessageTypeHello:
+		return phaseHandshake
+	case messages.MessageTypeChallenge, messages.MessageTypeAuthenticate:
+		return phaseAuthentication
+	case messages.MessageTypeWelcome:
+		return phaseSession
+	case messages.MessageTypeAbort, messages.MessageTypeGoodbye:
+		return phaseClosing
+	}
+
+	if phase == "" {
+		return phaseSession
+	}
+
+	return phase
+}
+
+// reportSummary describes a message in a line, e.g. CALL #1 com.example.add.
+func reportSummary(exported *ExportedMessage) string {
+	if exported.DecodeError != "" {
+		return fmt.Sprintf("undecodable %d byte message", exported.Size)
+	}
+
+	parts := []string{exported.Name}
+	if exported.RequestID != nil {
+		parts = append(parts, fmt.Sprintf("#%d", *exported.RequestID))
+	}
+
+	for _, id := range []struct {
+		name  string
+		value *int64
+	}{
+		{"session", exported.SessionID},
+		{"subscription", exported.SubscriptionID},
+		{"registration", exported.RegistrationID},
+		{"publication", exported.PublicationID},
+	} {
+		if id.value != nil {
+			parts = append(parts, fmt.Sprintf("%s %d", id.name, *id.value))
+		}
+	}
+
+	if exported.URI != "" {
+		parts = append(parts, exported.URI)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+//nolint:gochecknoglobals
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { padding: 0.25em 0.5em; vertical-align: top; }
+th { text-align: left; border-bottom: 2px solid #888; }
+td.offset { color: #888; font-family: monospace; white-space: nowrap; }
+td.arrow { width: 50%; }
+td.phase { font-weight: bold; background: #eee; }
+.to-router { border-right: 3px solid #2a6; text-align: left; }
+.to-client { border-left: 3px solid #26a; text-align: right; }
+summary { cursor: pointer; font-family: monospace; }
+pre { text-align: left; background: #f6f6f6; padding: 0.5em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Sessions}}
+<h2>Session {{.Name}}</h2>
+<p>{{.Count}} messages starting {{.Start}}, lasting {{.Duration}}.</p>
+<table>
+<tr><th></th><th>Client &rarr; Router</th><th>Router &rarr; Client</th></tr>
+{{range .Rows}}{{if .Phase}}<tr><td class="phase" colspan="3">{{.Phase}}</td></tr>
+{{else}}<tr><td class="offset">{{.Offset}}</td>
+{{if .ToRouter}}<td class="arrow to-router"><details><summary>{{.Summary}} &rarr;</summary>
+<pre>{{.Detail}}</pre></details></td><td></td>
+{{else}}<td></td><td class="arrow to-client"><details><summary>&larr; {{.Summary}}</summary>
+<pre>{{.Detail}}</pre></details></td>
+{{end}}</tr>
+{{end}}{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,533 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// WireNumber describes how a number was encoded on the wire.
+type WireNumber struct {
+	Path     string `json:"path"`
+	WireType string `json:"wire_type"`
+	Value    string `json:"value"`
+}
+
+// payloadScan collects what decoding serialized data would hide.
+type payloadScan struct {
+	duplicates []Warning
+	precision  []Warning
+	numbers    []WireNumber
+	// withNumbers collects numbers, which most scans don't need.
+	withNumbers bool
+	// size is the length of the scanned data, which bounds the number of
+	// elements of any container, as every element takes at least a byte.
+	size int
+}
+
+// FindDuplicateKeys reports every map key that occurs more than once in the
+// same map of serialized data, along with both values. Decoders silently keep
+// only one of them, and which one differs between implementations.
+func FindDuplicateKeys(serializerName string, data []byte) ([]Warning, error) {
+	scan, err := scanPayload(serializerName, data, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return scan.duplicates, nil
+}
+
+// WireNumbers reports the exact wire type and value of every number in
+// serialized data, e.g. a CBOR uint64 or a JSON float literal.
+func WireNumbers(serializerName string, data []byte) ([]WireNumber, error) {
+	scan, err := scanPayload(serializerName, data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return scan.numbers, nil
+}
+
+func scanPayload(serializerName string, data []byte, withNumbers bool) (*payloadScan, error) {
+	scan := &payloadScan{withNumbers: withNumbers, size: len(data)}
+	path := newScanPath()
+	var err error
+	switch serializerName {
+	case JSONSerializer:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		_, err = scan.walkJSON(decoder, path)
+	case CBORSerializer:
+		_, err = scan.walkCBOR(data, 0, path)
+	case MsgPackSerializer:
+		_, err = scan.walkMsgPack(msgpack.NewDecoder(bytes.NewReader(data)), path)
+	default:
+		return nil, fmt.Errorf("unknown serializer %q", serializerName)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	return scan, nil
+}
+
+// scanPath is the path of a scanned value, e.g. [4]["name"]. It is only
+// formatted for the values that are reported, as most of them aren't.
+type scanPath []pathElement
+
+// newScanPath returns the path of the root, with room for the depth of
+// typical messages.
+func newScanPath() scanPath {
+	return make(scanPath, 0, 8)
+}
+
+// pathElement is an index into an array or a key of a map.
+type pathElement struct {
+	index int
+	key   string
+	isKey bool
+}
+
+// index returns the path of element i of the array at p. Walks are depth
+// first, so siblings may share the backing array of p.
+func (p scanPath) index(i int) scanPath {
+	return append(p, pathElement{index: i})
+}
+
+// key returns the path of the value of key in the map at p.
+func (p scanPath) key(key string) scanPath {
+	return append(p, pathElement{key: key, isKey: true})
+}
+
+func (p scanPath) String() string {
+	var b strings.Builder
+	for _, element := range p {
+		b.WriteByte('[')
+		if element.isKey {
+			b.WriteString(strconv.Quote(element.key))
+		} else {
+			b.WriteString(strconv.Itoa(element.index))
+		}
+		b.WriteByte(']')
+	}
+
+	return b.String()
+}
+
+func (s *payloadScan) duplicateKey(path scanPath, key string, first, second any) {
+	s.duplicates = append(s.duplicates, Warning{
+		Category: CategoryDuplicate,
+		Message:  fmt.Sprintf("duplicate key %q in map at %s with values %v and %v", key, path, first, second),
+	})
+}
+
+func (s *payloadScan) number(path scanPath, wireType string, value any) {
+	s.numbers = append(s.numbers, WireNumber{Path: path.String(), WireType: wireType, Value: fmt.Sprint(value)})
+}
+
+// walkJSON decodes the next JSON value from decoder.
+func (s *payloadScan) walkJSON(decoder *json.Decoder, path scanPath) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if number, ok := token.(json.Number); ok {
+		if s.withNumbers {
+			wireType := "integer"
+			if strings.ContainsAny(number.String(), ".eE") {
+				wireType = "float"
+			}
+
+			s.number(path, wireType, number)
+		}
+
+		if !jsonNumberExact(number) {
+			s.precision = append(s.precision, Warning{
+				Category: CategoryPrecision,
+				Message:  fmt.Sprintf("number %s at %s cannot be decoded without losing precision", number, path),
+			})
+		}
+
+		return number, nil
+	}
+
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '[':
+		var array []any
+		for i := 0; decoder.More(); i++ {
+			value, err := s.walkJSON(decoder, path.index(i))
+			if err != nil {
+				return nil, err
+			}
+
+			array = append(array, value)
+		}
+
+		_, err = decoder.Token()
+		return array, err
+	case '{':
+		object := map[string]any{}
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			key, _ := token.(string)
+			value, err := s.walkJSON(decoder, path.key(key))
+			if err != nil {
+				return nil, err
+			}
+
+			if first, ok := object[key]; ok {
+				s.duplicateKey(path, key, first, value)
+			}
+			object[key] = value
+		}
+
+		_, err = decoder.Token()
+		return object, err
+	default:
+		return nil, fmt.Errorf("unexpected %q", delim)
+	}
+}
+
+// walkMsgPack decodes the next MessagePack value from decoder.
+func (s *payloadScan) walkMsgPack(decoder *msgpack.Decoder, path scanPath) (any, error) {
+	code, err := decoder.PeekCode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case msgpcode.IsFixedArray(code) || code == msgpcode.Array16 || code == msgpcode.Array32:
+		length, err := decoder.DecodeArrayLen()
+		if err != nil {
+			return nil, err
+		}
+
+		// The length comes from the wire, so it mustn't size the allocation
+		// unless the data can hold that many elements.
+		array := make([]any, 0, min(max(length, 0), s.size))
+		for i := 0; i < length; i++ {
+			value, err := s.walkMsgPack(decoder, path.index(i))
+			if err != nil {
+				return nil, err
+			}
+
+			array = append(array, value)
+		}
+
+		return array, nil
+	case msgpcode.IsFixedMap(code) || code == msgpcode.Map16 || code == msgpcode.Map32:
+		length, err := decoder.DecodeMapLen()
+		if err != nil {
+			return nil, err
+		}
+
+		object := make(map[string]any, min(max(length, 0), s.size))
+		for i := 0; i < length; i++ {
+			rawKey, err := decoder.DecodeInterface()
+			if err != nil {
+				return nil, err
+			}
+
+			key, ok := rawKey.(string)
+			if !ok {
+				key = fmt.Sprint(rawKey)
+			}
+
+			value, err := s.walkMsgPack(decoder, path.key(key))
+			if err != nil {
+				return nil, err
+			}
+
+			if first, ok := object[key]; ok {
+				s.duplicateKey(path, key, first, value)
+			}
+			object[key] = value
+		}
+
+		return object, nil
+	default:
+		value, err := decoder.DecodeInterface()
+		if err != nil {
+			return nil, err
+		}
+
+		if wireType := msgPackNumberType(code); s.withNumbers && wireType != "" {
+			s.number(path, wireType, value)
+		}
+
+		return value, nil
+	}
+}
+
+// checkMsgPackLengths fails if an array or map of the MessagePack value data
+// declares more elements than the rest of data can hold. The msgpack decoder
+// allocates containers by their declared length, so a few bytes declaring
+// 2^31 elements would otherwise exhaust the memory.
+func checkMsgPackLengths(data []byte) error {
+	// A bytes.Reader isn't buffered by the decoder, so its length is what is
+	// left to decode.
+	reader := bytes.NewReader(data)
+	return checkMsgPackValue(msgpack.NewDecoder(reader), reader)
+}
+
+func checkMsgPackValue(decoder *msgpack.Decoder, reader *bytes.Reader) error {
+	code, err := decoder.PeekCode()
+	if err != nil {
+		return err
+	}
+
+	var elements int
+	switch {
+	case msgpcode.IsFixedArray(code) || code == msgpcode.Array16 || code == msgpcode.Array32:
+		length, err := decoder.DecodeArrayLen()
+		if err != nil {
+			return err
+		}
+
+		elements = length
+	case msgpcode.IsFixedMap(code) || code == msgpcode.Map16 || code == msgpcode.Map32:
+		length, err := decoder.DecodeMapLen()
+		if err != nil {
+			return err
+		}
+
+		elements = 2 * length
+	default:
+		return decoder.Skip()
+	}
+
+	if elements > reader.Len() {
+		return fmt.Errorf("msgpack: container of %d elements exceeds the %d remaining bytes", elements,
+			reader.Len())
+	}
+
+	for i := 0; i < elements; i++ {
+		if err = checkMsgPackValue(decoder, reader); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func msgPackNumberType(code byte) string {
+	switch {
+	case msgpcode.IsFixedNum(code):
+		return "fixint"
+	case code == msgpcode.Uint8:
+		return "uint8"
+	case code == msgpcode.Uint16:
+		return "uint16"
+	case code == msgpcode.Uint32:
+		return "uint32"
+	case code == msgpcode.Uint64:
+		return "uint64"
+	case code == msgpcode.Int8:
+		return "int8"
+	case code == msgpcode.Int16:
+		return "int16"
+	case code == msgpcode.Int32:
+		return "int32"
+	case code == msgpcode.Int64:
+		return "int64"
+	case code == msgpcode.Float:
+		return "float32"
+	case code == msgpcode.Double:
+		return "float64"
+	default:
+		return ""
+	}
+}
+
+// CBOR major types.
+const (
+	cborUnsigned = iota
+	cborNegative
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+const cborBreak = 0xff
+
+// walkCBOR walks the CBOR data item at offset and returns the offset
+// following it.
+func (s *payloadScan) walkCBOR(data []byte, offset int, path scanPath) (int, error) {
+	major, argument, next, indefinite, err := cborHead(data, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	items := func(walk func(i int, next int) (int, error)) (int, error) {
+		for i := 0; indefinite || uint64(i) < argument; i++ {
+			if indefinite {
+				if next >= len(data) {
+					return 0, io.ErrUnexpectedEOF
+				}
+
+				if data[next] == cborBreak {
+					return next + 1, nil
+				}
+			}
+
+			if next, err = walk(i, next); err != nil {
+				return 0, err
+			}
+		}
+
+		return next, nil
+	}
+
+	switch major {
+	case cborBytes, cborText:
+		if indefinite {
+			return items(func(_ int, next int) (int, error) { return s.walkCBOR(data, next, path) })
+		}
+
+		if argument > uint64(len(data)-next) {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		return next + int(argument), nil
+	case cborArray:
+		return items(func(i int, next int) (int, error) {
+			return s.walkCBOR(data, next, path.index(i))
+		})
+	case cborMap:
+		values := make(map[string][]byte)
+		return items(func(_ int, next int) (int, error) {
+			keyEnd, err := s.walkCBOR(data, next, path)
+			if err != nil {
+				return 0, err
+			}
+
+			key, err := cborKey(data[next:keyEnd])
+			if err != nil {
+				return 0, err
+			}
+
+			valueEnd, err := s.walkCBOR(data, keyEnd, path.key(key))
+			if err != nil {
+				return 0, err
+			}
+
+			value := data[keyEnd:valueEnd]
+			if first, ok := values[key]; ok {
+				var firstValue, secondValue any
+				_ = cborDecMode.Unmarshal(first, &firstValue)
+				_ = cborDecMode.Unmarshal(value, &secondValue)
+				s.duplicateKey(path, key, firstValue, secondValue)
+			}
+			values[key] = value
+
+			return valueEnd, nil
+		})
+	case cborTag:
+		return s.walkCBOR(data, next, path)
+	default:
+		if !s.withNumbers {
+			return next, nil
+		}
+
+		if wireType := cborNumberType(major, data[offset]&0x1f); wireType != "" {
+			var value any
+			_ = cborDecMode.Unmarshal(data[offset:next], &value)
+			s.number(path, wireType, value)
+		}
+
+		return next, nil
+	}
+}
+
+// cborKey returns the map key of the CBOR data item data as a string. Keys
+// are text strings but for few messages, and valid ones are read without
+// decoding.
+func cborKey(data []byte) (string, error) {
+	major, _, next, indefinite, err := cborHead(data, 0)
+	if err != nil {
+		return "", err
+	}
+
+	if major == cborText && !indefinite && utf8.Valid(data[next:]) {
+		return string(data[next:]), nil
+	}
+
+	var key any
+	if err = cborDecMode.Unmarshal(data, &key); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprint(key), nil
+}
+
+func cborNumberType(major, info byte) string {
+	sizes := []string{"", "8", "16", "32", "64"}
+	size := 0
+	if info >= 24 && info <= 27 {
+		size = int(info) - 23
+	}
+
+	switch {
+	case major == cborUnsigned:
+		return "uint" + sizes[size]
+	case major == cborNegative:
+		return "negint" + sizes[size]
+	case major == cborSimple && size >= 2:
+		return "float" + sizes[size]
+	default:
+		return ""
+	}
+}
+
+// cborHead decodes the head of the CBOR data item at offset, returning its
+// major type, argument and the offset of its content.
+func cborHead(data []byte, offset int) (major byte, argument uint64, next int, indefinite bool, err error) {
+	if offset >= len(data) {
+		return 0, 0, 0, false, io.ErrUnexpectedEOF
+	}
+
+	major, info := data[offset]>>5, data[offset]&0x1f
+	next = offset + 1
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), next, false, nil
+	case info <= 27:
+		size = 1 << (info - 24)
+	case info == 31 && major >= cborBytes && major <= cborMap:
+		return major, 0, next, true, nil
+	default:
+		return 0, 0, 0, false, fmt.Errorf("invalid CBOR additional information %d at offset %d", info, offset)
+	}
+
+	if len(data)-next < size {
+		return 0, 0, 0, false, io.ErrUnexpectedEOF
+	}
+
+	var buf [8]byte
+	copy(buf[8-size:], data[next:next+size])
+	return major, binary.BigEndian.Uint64(buf[:]), next + size, false, nil
+}
@@ -0,0 +1,189 @@
+package wampprotocli
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestFindDuplicateKeys(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		serializer string
+		// input is hex encoded unless serializer is JSON.
+		input string
+		// paths are the maps holding a duplicate key, in order.
+		paths []string
+	}{
+		{"json none", JSONSerializer, `[48,1,{"a":1,"b":1},"p"]`, nil},
+		{"json options", JSONSerializer, `[48,1,{"a":1,"a":2},"p"]`, []string{"[2]"}},
+		{"json nested", JSONSerializer, `[48,1,{"x":{"a":1,"a":2}},"p"]`, []string{`[2]["x"]`}},
+		{"json arguments", JSONSerializer, `[48,1,{},"p",[{"k":[{"a":1,"a":2}]}],{"a":1,"a":{}}]`,
+			[]string{`[4][0]["k"][0]`, "[5]"}},
+		// [48, 1, {"a": 1, "a": 2}, "p"]
+		{"cbor options", CBORSerializer, "84183001a2616101616102617070", []string{"[2]"}},
+		// [48, 1, {"x": {"a": 1, "a": 2}}, "p"]
+		{"cbor nested", CBORSerializer, "84183001a16178a2616101616102617070", []string{`[2]["x"]`}},
+		// [48, 1, {_ "a": 1, "a": 2}, "p"] with an indefinite-length map
+		{"cbor indefinite map", CBORSerializer, "84183001bf616101616102ff617070", []string{"[2]"}},
+		// [_ 48, 1, {}, "p", [_ {"a": 1, (_ "a"): 2}]] with indefinite-length
+		// arrays and a key of an indefinite-length string
+		{"cbor indefinite key", CBORSerializer, "9f183001a06170 9fa26161017f6161ff02ff ff", []string{"[4][0]"}},
+		// [48, 1, {"a": 1, "a": 2}, "p"]
+		{"msgpack options", MsgPackSerializer, "943001 82a16101a16102 a170", []string{"[2]"}},
+		// [48, 1, {}, "p", [[{"a": 1, "a": 2}]], {"k": {"a": 1, "a": 2}}]
+		{"msgpack nested", MsgPackSerializer, "963001 80 a170 9191 82a16101a16102 81a16b 82a16101a16102",
+			[]string{"[4][0][0]", `[5]["k"]`}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data := []byte(test.input)
+			if test.serializer != JSONSerializer {
+				var err error
+				if data, err = hex.DecodeString(strings.ReplaceAll(test.input, " ", "")); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			duplicates, err := FindDuplicateKeys(test.serializer, data)
+			if err != nil {
+				t.Fatalf("FindDuplicateKeys: %v", err)
+			}
+
+			if len(duplicates) != len(test.paths) {
+				t.Fatalf("expected %d duplicates, got %v", len(test.paths), duplicates)
+			}
+
+			for i, duplicate := range duplicates {
+				if !strings.Contains(duplicate.Message, "in map at "+test.paths[i]+" ") {
+					t.Errorf("expected a duplicate in the map at %s, got %q", test.paths[i], duplicate.Message)
+				}
+			}
+		})
+	}
+}
+
+// TestScanTruncated scans data ending in the middle of a value, which must
+// fail instead of reading past the data.
+func TestScanTruncated(t *testing.T) {
+	for _, test := range []struct {
+		serializer string
+		input      string
+	}{
+		{CBORSerializer, "8301"},          // array of 3 elements with 1
+		{CBORSerializer, "9f01"},          // indefinite-length array without a break
+		{CBORSerializer, "bf6161"},        // indefinite-length map without a value
+		{CBORSerializer, "6361"},          // text string of 3 bytes with 1
+		{CBORSerializer, "7f6161"},        // indefinite-length string without a break
+		{CBORSerializer, "1901"},          // uint16 with 1 byte
+		{CBORSerializer, "1b000000"},      // uint64 with 3 bytes
+		{CBORSerializer, "1c"},            // reserved additional information
+		{MsgPackSerializer, "9201"},       // array of 2 elements with 1
+		{MsgPackSerializer, "dc00"},       // array16 with a length of 1 byte
+		{MsgPackSerializer, "dd000000"},   // array32 with a length of 3 bytes
+		{MsgPackSerializer, "de0002a161"}, // map16 of 2 entries without a value
+		{MsgPackSerializer, "a561"},       // string of 5 bytes with 1
+		{MsgPackSerializer, "cd01"},       // uint16 with 1 byte
+		{MsgPackSerializer, "cb3ff8"},     // float64 with 2 bytes
+	} {
+		data, err := hex.DecodeString(test.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = FindDuplicateKeys(test.serializer, data); err == nil {
+			t.Errorf("FindDuplicateKeys(%s, %s): expected an error", test.serializer, test.input)
+		}
+
+		if _, err = WireNumbers(test.serializer, data); err == nil {
+			t.Errorf("WireNumbers(%s, %s): expected an error", test.serializer, test.input)
+		}
+	}
+
+	if _, err := WireNumbers(JSONSerializer, []byte("[1,")); err == nil {
+		t.Error("WireNumbers(json, [1,): expected an error")
+	}
+}
+
+func TestWireNumbers(t *testing.T) {
+	for _, test := range []struct {
+		serializer string
+		// input is hex encoded unless serializer is JSON.
+		input    string
+		wireType string
+		value    string
+	}{
+		{JSONSerializer, "[1]", "integer", "1"},
+		{JSONSerializer, "[-1]", "integer", "-1"},
+		{JSONSerializer, "[1.5]", "float", "1.5"},
+		{JSONSerializer, "[1e3]", "float", "1e3"},
+		{CBORSerializer, "8117", "uint", "23"},
+		{CBORSerializer, "811818", "uint8", "24"},
+		{CBORSerializer, "81190100", "uint16", "256"},
+		{CBORSerializer, "811a00010000", "uint32", "65536"},
+		{CBORSerializer, "811b0000000100000000", "uint64", "4294967296"},
+		{CBORSerializer, "8120", "negint", "-1"},
+		{CBORSerializer, "813818", "negint8", "-25"},
+		{CBORSerializer, "81390100", "negint16", "-257"},
+		{CBORSerializer, "813a00010000", "negint32", "-65537"},
+		{CBORSerializer, "813b0000000100000000", "negint64", "-4294967297"},
+		{CBORSerializer, "81f93e00", "float16", "1.5"},
+		{CBORSerializer, "81fa3fc00000", "float32", "1.5"},
+		{CBORSerializer, "81fb3ff8000000000000", "float64", "1.5"},
+		{MsgPackSerializer, "9101", "fixint", "1"},
+		{MsgPackSerializer, "91ff", "fixint", "-1"},
+		{MsgPackSerializer, "91ccff", "uint8", "255"},
+		{MsgPackSerializer, "91cd0100", "uint16", "256"},
+		{MsgPackSerializer, "91ce00010000", "uint32", "65536"},
+		{MsgPackSerializer, "91cf0000000100000000", "uint64", "4294967296"},
+		{MsgPackSerializer, "91d080", "int8", "-128"},
+		{MsgPackSerializer, "91d1ff00", "int16", "-256"},
+		{MsgPackSerializer, "91d2ffff0000", "int32", "-65536"},
+		{MsgPackSerializer, "91d3ffffffff00000000", "int64", "-4294967296"},
+		{MsgPackSerializer, "91ca3fc00000", "float32", "1.5"},
+		{MsgPackSerializer, "91cb3ff8000000000000", "float64", "1.5"},
+	} {
+		data := []byte(test.input)
+		if test.serializer != JSONSerializer {
+			var err error
+			if data, err = hex.DecodeString(test.input); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		numbers, err := WireNumbers(test.serializer, data)
+		if err != nil {
+			t.Errorf("WireNumbers(%s, %s): %v", test.serializer, test.input, err)
+			continue
+		}
+
+		expected := WireNumber{Path: "[0]", WireType: test.wireType, Value: test.value}
+		if len(numbers) != 1 || numbers[0] != expected {
+			t.Errorf("WireNumbers(%s, %s): expected %+v, got %+v", test.serializer, test.input, expected, numbers)
+		}
+	}
+}
+
+func TestWireNumbersPaths(t *testing.T) {
+	value := []any{int64(1), map[string]any{"a": []any{int64(2), int64(3)}}, int64(4)}
+	expected := []string{`[0]`, `[1]["a"][0]`, `[1]["a"][1]`, `[2]`}
+	for _, serializer := range SerializerNames() {
+		data, err := serializeValue(serializer, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		numbers, err := WireNumbers(serializer, data)
+		if err != nil {
+			t.Fatalf("WireNumbers(%s): %v", serializer, err)
+		}
+
+		var paths []string
+		for _, number := range numbers {
+			paths = append(paths, number.Path)
+		}
+
+		if strings.Join(paths, " ") != strings.Join(expected, " ") {
+			t.Errorf("WireNumbers(%s): expected paths %v, got %v", serializer, expected, paths)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package wampprotocli
+
+import (
+	"fmt"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// ScenarioStep is one message of a scenario, sent by the peer with the role
+// From to the peer with the role To. Peer names the peer the router exchanges
+// the message with if several peers have the same role.
+type ScenarioStep struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Peer    string `json:"peer,omitempty"`
+	Message []any  `json:"message"`
+}
+
+// AppendPayload appends the positional arguments and keyword arguments of a
+// payload-carrying message to raw. Arguments are only present if there are
+// keyword arguments or either is non-empty.
+func AppendPayload(raw []any, args []any, kwargs map[string]any) []any {
+	if len(kwargs) > 0 {
+		if args == nil {
+			args = []any{}
+		}
+
+		return append(raw, args, kwargs)
+	}
+
+	if len(args) > 0 {
+		return append(raw, args)
+	}
+
+	return raw
+}
+
+// ProgressiveResults returns the RESULT messages a dealer sends for a call
+// with progressive call results: count results with progress=true, the i-th
+// carrying the single argument i, followed by the final result without it.
+func ProgressiveResults(requestID int64, count int) ([]ScenarioStep, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("count must not be negative, was %d", count)
+	}
+
+	result := func(details map[string]any, args []any) ScenarioStep {
+		raw := []any{messages.MessageTypeResult, requestID, details}
+		return ScenarioStep{From: dealerRole, To: callerRole, Message: AppendPayload(raw, args, nil)}
+	}
+
+	steps := make([]ScenarioStep, 0, count+1)
+	for i := 1; i <= count; i++ {
+		steps = append(steps, result(map[string]any{progressOption: true}, []any{int64(i)}))
+	}
+
+	return append(steps, result(map[string]any{}, nil)), nil
+}
+
+// CallIDs identifies a call as seen by its caller and its callee.
+type CallIDs struct {
+	// RequestID is the request ID of the CALL, chosen by the caller.
+	RequestID int64
+	// InvocationID is the request ID of the INVOCATION, chosen by the dealer.
+	InvocationID   int64
+	RegistrationID int64
+	Procedure      string
+}
+
+// ProgressiveInvocations returns the CALL and INVOCATION messages of a call
+// with progressive call invocations: count calls with progress=true, the i-th
+// carrying the single argument i, each forwarded to the callee as an
+// INVOCATION, followed by the final call and invocation without it.
+func ProgressiveInvocations(ids CallIDs, count int) ([]ScenarioStep, error) {
+	if count < 0 {
+		return nil, fmt.Errorf("count must not be negative, was %d", count)
+	}
+
+	call := func(options map[string]any, args []any) ScenarioStep {
+		raw := []any{messages.MessageTypeCall, ids.RequestID, options, ids.Procedure}
+		return ScenarioStep{From: callerRole, To: dealerRole, Message: AppendPayload(raw, args, nil)}
+	}
+
+	invocation := func(details map[string]any, args []any) ScenarioStep {
+		raw := []any{messages.MessageTypeInvocation, ids.InvocationID, ids.RegistrationID, details}
+		return ScenarioStep{From: dealerRole, To: calleeRole, Message: AppendPayload(raw, args, nil)}
+	}
+
+	steps := make([]ScenarioStep, 0, 2*(count+1))
+	for i := 1; i <= count; i++ {
+		args := []any{int64(i)}
+		steps = append(steps, call(map[string]any{progressOption: true}, args),
+			invocation(map[string]any{progressOption: true}, args))
+	}
+
+	return append(steps, call(map[string]any{}, nil), invocation(map[string]any{}, nil)), nil
+}
+
+// Cancellation modes of CANCEL and INTERRUPT messages.
+const (
+	CancelSkip       = "skip"
+	CancelKill       = "kill"
+	CancelKillNoWait = "killnowait"
+)
+
+// CancelModes returns the names accepted by CancelCall.
+func CancelModes() []string {
+	return []string{CancelSkip, CancelKill, CancelKillNoWait}
+}
+
+// canceledError is the error URI of canceled calls.
+const canceledError = "wamp.error.canceled"
+
+// CancelCall returns the messages of a call that is canceled by its caller
+// while the callee is executing it. With mode skip the dealer responds with an
+// ERROR right away and leaves the callee alone, with killnowait it
+// additionally sends an INTERRUPT and with kill it sends an INTERRUPT and
+// waits for the callee to fail the invocation before responding.
+func CancelCall(ids CallIDs, mode string) ([]ScenarioStep, error) {
+	switch mode {
+	case CancelSkip, CancelKill, CancelKillNoWait:
+	default:
+		return nil, fmt.Errorf("unknown cancellation mode %q, must be one of %v", mode, CancelModes())
+	}
+
+	steps := []ScenarioStep{
+		{
+			From:    callerRole,
+			To:      dealerRole,
+			Message: []any{messages.MessageTypeCall, ids.RequestID, map[string]any{}, ids.Procedure},
+		},
+		{
+			From:    dealerRole,
+			To:      calleeRole,
+			Message: []any{messages.MessageTypeInvocation, ids.InvocationID, ids.RegistrationID, map[string]any{}},
+		},
+		{
+			From:    callerRole,
+			To:      dealerRole,
+			Message: []any{messages.MessageTypeCancel, ids.RequestID, map[string]any{modeOption: mode}},
+		},
+	}
+
+	if mode != CancelSkip {
+		steps = append(steps, ScenarioStep{
+			From:    dealerRole,
+			To:      calleeRole,
+			Message: []any{messages.MessageTypeInterrupt, ids.InvocationID, map[string]any{modeOption: mode}},
+		})
+	}
+
+	if mode == CancelKill {
+		steps = append(steps, ScenarioStep{
+			From: calleeRole,
+			To:   dealerRole,
+			Message: []any{messages.MessageTypeError, messages.MessageTypeInvocation, ids.InvocationID,
+				map[string]any{}, canceledError},
+		})
+	}
+
+	return append(steps, ScenarioStep{
+		From: dealerRole,
+		To:   callerRole,
+		Message: []any{messages.MessageTypeError, messages.MessageTypeCall, ids.RequestID, map[string]any{},
+			canceledError},
+	}), nil
+}
+
+// timeoutError is the error URI of calls whose timeout expired.
+const timeoutError = "wamp.error.timeout"
+
+// CallTimeout returns the messages of a call with a timeout of timeout
+// milliseconds whose callee takes duration milliseconds to respond, as a
+// dealer enforcing the timeout produces them. If the callee is too slow, the
+// dealer interrupts it with mode killnowait and fails the call with
+// wamp.error.timeout, otherwise the YIELD is forwarded as the RESULT. A
+// timeout of 0 disables it.
+func CallTimeout(ids CallIDs, timeout, duration int64) ([]ScenarioStep, error) {
+	if timeout < 0 || duration < 0 {
+		return nil, fmt.Errorf("timeout and duration must not be negative, were %d and %d", timeout, duration)
+	}
+
+	callOptions := map[string]any{}
+	if timeout > 0 {
+		callOptions[timeoutOption] = timeout
+	}
+
+	steps := []ScenarioStep{
+		{
+			From:    callerRole,
+			To:      dealerRole,
+			Message: []any{messages.MessageTypeCall, ids.RequestID, callOptions, ids.Procedure},
+		},
+		{
+			From:    dealerRole,
+			To:      calleeRole,
+			Message: []any{messages.MessageTypeInvocation, ids.InvocationID, ids.RegistrationID, map[string]any{}},
+		},
+	}
+
+	if timeout == 0 || duration <= timeout {
+		return append(steps,
+			ScenarioStep{
+				From:    calleeRole,
+				To:      dealerRole,
+				Message: []any{messages.MessageTypeYield, ids.InvocationID, map[string]any{}},
+			},
+			ScenarioStep{
+				From:    dealerRole,
+				To:      callerRole,
+				Message: []any{messages.MessageTypeResult, ids.RequestID, map[string]any{}},
+			}), nil
+	}
+
+	return append(steps,
+		ScenarioStep{
+			From: dealerRole,
+			To:   calleeRole,
+			Message: []any{messages.MessageTypeInterrupt, ids.InvocationID,
+				map[string]any{modeOption: CancelKillNoWait, reasonOption: timeoutError}},
+		},
+		ScenarioStep{
+			From: dealerRole,
+			To:   callerRole,
+			Message: []any{messages.MessageTypeError, messages.MessageTypeCall, ids.RequestID, map[string]any{},
+				timeoutError},
+		}), nil
+}
+
+// TopicIDs identifies an event as seen by its publisher and a subscriber.
+type TopicIDs struct {
+	// PublishRequestID is the request ID of the PUBLISH, chosen by the publisher.
+	PublishRequestID int64
+	// SubscribeRequestID is the request ID of the SUBSCRIBE, chosen by the
+	// subscriber.
+	SubscribeRequestID int64
+	SubscriptionID     int64
+	PublicationID      int64
+	Topic              string
+}
+
+// RetainedEvent returns the messages of an event retained by the broker and
+// delivered to a subscriber that subscribes after it was published: the
+// PUBLISH with retain=true, the SUBSCRIBE with get_retained=true, the
+// SUBSCRIBED and the EVENT with retained=true.
+func RetainedEvent(ids TopicIDs) []ScenarioStep {
+	return []ScenarioStep{
+		{
+			From: publisherRole,
+			To:   brokerRole,
+			Message: []any{messages.MessageTypePublish, ids.PublishRequestID, map[string]any{retainOption: true},
+				ids.Topic},
+		},
+		{
+			From: subscriberRole,
+			To:   brokerRole,
+			Message: []any{messages.MessageTypeSubscribe, ids.SubscribeRequestID,
+				map[string]any{getRetainedOption: true}, ids.Topic},
+		},
+		{
+			From:    brokerRole,
+			To:      subscriberRole,
+			Message: []any{messages.MessageTypeSubscribed, ids.SubscribeRequestID, ids.SubscriptionID},
+		},
+		{
+			From: brokerRole,
+			To:   subscriberRole,
+			Message: []any{messages.MessageTypeEvent, ids.SubscriptionID, ids.PublicationID,
+				map[string]any{retainedOption: true}},
+		},
+	}
+}
+
+// SharedRegistration returns the messages of calls successive calls to a
+// procedure every callee of registration registered with its invocation
+// policy: the REGISTER and REGISTERED of each callee, followed by the CALL,
+// the INVOCATION of the callee SelectCallees picks, its YIELD and the RESULT
+// of each call. The calls' request IDs count up from 1, as do the IDs of the
+// invocations. A registration without ID gets 1.
+func SharedRegistration(registration Registration, calls int) ([]ScenarioStep, error) {
+	if calls < 0 {
+		return nil, fmt.Errorf("calls must not be negative, was %d", calls)
+	}
+
+	callees, err := SelectCallees(registration, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	registrationID := registration.ID
+	if registrationID == 0 {
+		registrationID = 1
+	}
+
+	options := map[string]any{}
+	if registration.Match != "" && registration.Match != MatchExact {
+		options[matchOption] = registration.Match
+	}
+
+	if registration.Invoke != "" && registration.Invoke != InvokeSingle {
+		options[invokeOption] = registration.Invoke
+	}
+
+	steps := make([]ScenarioStep, 0, 2*len(registration.Callees)+4*calls)
+	for _, callee := range registration.Callees {
+		steps = append(steps,
+			ScenarioStep{
+				From:    calleeRole,
+				To:      dealerRole,
+				Peer:    callee,
+				Message: []any{messages.MessageTypeRegister, int64(1), options, registration.Procedure},
+			},
+			ScenarioStep{
+				From:    dealerRole,
+				To:      calleeRole,
+				Peer:    callee,
+				Message: []any{messages.MessageTypeRegistered, int64(1), registrationID},
+			})
+	}
+
+	for i, callee := range callees {
+		id := int64(i + 1)
+		steps = append(steps,
+			ScenarioStep{
+				From:    callerRole,
+				To:      dealerRole,
+				Message: []any{messages.MessageTypeCall, id, map[string]any{}, registration.Procedure},
+			},
+			ScenarioStep{
+				From:    dealerRole,
+				To:      calleeRole,
+				Peer:    callee,
+				Message: []any{messages.MessageTypeInvocation, id, registrationID, map[string]any{}},
+			},
+			ScenarioStep{
+				From:    calleeRole,
+				To:      dealerRole,
+				Peer:    callee,
+				Message: []any{messages.MessageTypeYield, id, map[string]any{}},
+			},
+			ScenarioStep{
+				From:    dealerRole,
+				To:      callerRole,
+				Message: []any{messages.MessageTypeResult, id, map[string]any{}},
+			})
+	}
+
+	return steps, nil
+}
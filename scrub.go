@@ -0,0 +1,135 @@
+package wampprotocli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// Fields ScrubRecord can redact.
+const (
+	RedactArgs      = "args"
+	RedactKwargs    = "kwargs"
+	RedactAuthID    = "authid"
+	RedactAuthRole  = "authrole"
+	RedactAuthExtra = "authextra"
+	RedactSignature = "signature"
+)
+
+// RedactFields returns the names accepted by ScrubRecord.
+func RedactFields() []string {
+	return []string{RedactArgs, RedactKwargs, RedactAuthID, RedactAuthRole, RedactAuthExtra, RedactSignature}
+}
+
+// ScrubRecord replaces the values of fields in the message of record with
+// placeholders of the same type, keeping its structure and IDs: strings
+// become as many "x" characters, binary values as many zero bytes, numbers 0
+// and booleans false, and lists and dictionaries are scrubbed item by item.
+// authid and authrole cover every option or detail naming one, e.g.
+// caller_authid and eligible_authrole, and signature covers the signature of
+// AUTHENTICATE and the extra of CHALLENGE.
+func ScrubRecord(record *TranscriptRecord, fields []string) error {
+	for _, field := range fields {
+		if !slices.Contains(RedactFields(), field) {
+			return fmt.Errorf("unknown field %q, must be one of %v", field, RedactFields())
+		}
+	}
+
+	raw, err := DeserializeRaw(record.Serializer, record.Data)
+	if err != nil {
+		return err
+	}
+
+	scrub := func(position int) {
+		if position > 0 && position < len(raw) {
+			raw[position] = placeholder(raw[position], record.Serializer)
+		}
+	}
+
+	messageType := rawMessageType(raw)
+	optionsPosition, _, argsPosition := exportPositions(messageType)
+	if slices.Contains(fields, RedactArgs) {
+		scrub(argsPosition)
+	}
+
+	if slices.Contains(fields, RedactKwargs) && argsPosition > 0 {
+		scrub(argsPosition + 1)
+	}
+
+	if slices.Contains(fields, RedactSignature) {
+		switch messageType {
+		case messages.MessageTypeAuthenticate:
+			scrub(1)
+		case messages.MessageTypeChallenge:
+			scrub(2)
+		}
+	}
+
+	if optionsPosition > 0 && optionsPosition < len(raw) {
+		if options, ok := raw[optionsPosition].(map[string]any); ok {
+			for key, value := range options {
+				if redactedOption(key, fields) {
+					options[key] = placeholder(value, record.Serializer)
+				}
+			}
+		}
+	}
+
+	data, err := serializeValue(record.Serializer, raw)
+	if err != nil {
+		return err
+	}
+
+	record.Data, record.Message = data, raw
+	return nil
+}
+
+// redactedOption reports whether the option or detail key is one of fields.
+func redactedOption(key string, fields []string) bool {
+	for _, field := range []string{RedactAuthID, RedactAuthRole, RedactAuthExtra} {
+		if slices.Contains(fields, field) && (key == field || strings.HasSuffix(key, "_"+field)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// placeholder returns value with every scalar below it replaced by a
+// placeholder of the same type, binary values being represented as with
+// serializer.
+func placeholder(value any, serializer string) any {
+	if data, ok := decodeBinary(serializer, value); ok {
+		return encodeBinary(serializer, make([]byte, len(data)))
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.Repeat("x", utf8.RuneCountInString(v))
+	case bool:
+		return false
+	case []any:
+		scrubbed := make([]any, len(v))
+		for i, item := range v {
+			scrubbed[i] = placeholder(item, serializer)
+		}
+
+		return scrubbed
+	case map[string]any:
+		scrubbed := make(map[string]any, len(v))
+		for key, item := range v {
+			scrubbed[key] = placeholder(item, serializer)
+		}
+
+		return scrubbed
+	case float32, float64:
+		return 0.0
+	case nil:
+		return nil
+	default:
+		return int64(0)
+	}
+}
@@ -0,0 +1,50 @@
+package wampprotocli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// RedactedSecret replaces secrets in logs, command lines and transcripts.
+const RedactedSecret = "<redacted>"
+
+// IsSecretName reports whether the flag, field or log attribute name holds a
+// secret, such as a private key, ticket, password or HMAC key. Any key but a
+// public one counts as a secret, e.g. a key derived from a wampcra secret.
+func IsSecretName(name string) bool {
+	name = strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+	if name == "key" || (strings.HasSuffix(name, "_key") && !strings.HasSuffix(name, "public_key")) {
+		return true
+	}
+
+	for _, secret := range []string{"private_key", "secret", "ticket", "password"} {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedactSecrets is a slog ReplaceAttr function replacing the values of
+// attributes whose key names a secret.
+func RedactSecrets(_ []string, attr slog.Attr) slog.Attr {
+	if IsSecretName(attr.Key) {
+		attr.Value = slog.StringValue(RedactedSecret)
+	}
+
+	return attr
+}
+
+// DecodeSecretHex decodes the hex encoded secret called name. Unlike those of
+// hex.DecodeString, its errors don't quote the offending characters.
+func DecodeSecretHex(value, name string) ([]byte, error) {
+	data, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be hex encoded", name)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,55 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestIsSecretName(t *testing.T) {
+	for name, secret := range map[string]bool{
+		"key":               true,
+		"private-key":       true,
+		"private_key":       true,
+		"PRIVATE_KEY":       true,
+		"derived-key":       true,
+		"derived_key":       true,
+		"hmac_key":          true,
+		"secret":            true,
+		"client_secret":     true,
+		"ticket":            true,
+		"password":          true,
+		"public-key":        false,
+		"public_key":        false,
+		"router_public_key": false,
+		"keylen":            false,
+		"sort-keys":         false,
+		"ppt-keyid":         false,
+		"serializer":        false,
+		"authid":            false,
+	} {
+		if got := IsSecretName(name); got != secret {
+			t.Errorf("IsSecretName(%q) = %v, expected %v", name, got, secret)
+		}
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	var output bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{ReplaceAttr: RedactSecrets}))
+	logger.Info("signing", "private_key", "aa11", "derived-key", "bb22", "secret", "cc33", "ticket", "dd44",
+		"public_key", "ee55", "authid", "alice")
+
+	for _, secret := range []string{"aa11", "bb22", "cc33", "dd44"} {
+		if strings.Contains(output.String(), secret) {
+			t.Errorf("secret %s was logged: %s", secret, output.String())
+		}
+	}
+
+	for _, expected := range []string{"private_key=" + RedactedSecret, "public_key=ee55", "authid=alice"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("expected %s in %s", expected, output.String())
+		}
+	}
+}
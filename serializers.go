@@ -0,0 +1,128 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/xconnio/wampproto-go/messages"
+	"github.com/xconnio/wampproto-go/serializers"
+)
+
+const (
+	JSONSerializer    = "json"
+	CBORSerializer    = "cbor"
+	MsgPackSerializer = "msgpack"
+)
+
+// SerializerNames returns the names of all supported serializers.
+func SerializerNames() []string {
+	return []string{JSONSerializer, CBORSerializer, MsgPackSerializer}
+}
+
+// SerializerByName returns the serializer registered under the given name.
+// The returned serializer is shared and safe for concurrent use.
+func SerializerByName(name string) (serializers.Serializer, error) {
+	switch name {
+	case JSONSerializer:
+		return sharedJSONSerializer, nil
+	case CBORSerializer:
+		return sharedCBORSerializer, nil
+	case MsgPackSerializer:
+		return sharedMsgPackSerializer, nil
+	default:
+		return nil, fmt.Errorf("unknown serializer %q", name)
+	}
+}
+
+// SortedSerializerByName is like SerializerByName, but the returned serializer
+// orders map keys deterministically so that its output is reproducible. JSON
+// output is always sorted, CBOR and MessagePack otherwise follow Go's random
+// map iteration order.
+func SortedSerializerByName(name string) (serializers.Serializer, error) {
+	switch name {
+	case CBORSerializer:
+		return sortedCBORSerializer, nil
+	case MsgPackSerializer:
+		return sortedMsgPackSerializer, nil
+	default:
+		return SerializerByName(name)
+	}
+}
+
+// SerializeMessage encodes message with the named serializer.
+func SerializeMessage(serializerName string, message messages.Message) ([]byte, error) {
+	serializer, err := SerializerByName(serializerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return serializeWith(serializer, message)
+}
+
+// SerializeMessageSorted encodes message with the named serializer, ordering
+// map keys deterministically.
+func SerializeMessageSorted(serializerName string, message messages.Message) ([]byte, error) {
+	serializer, err := SortedSerializerByName(serializerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return serializeWith(serializer, message)
+}
+
+func serializeWith(serializer serializers.Serializer, message messages.Message) ([]byte, error) {
+	data, err := serializer.Serialize(marshaled{message})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	return data, nil
+}
+
+// DeserializeMessage decodes data with the named serializer.
+func DeserializeMessage(serializerName string, data []byte) (messages.Message, error) {
+	raw, err := DeserializeRaw(serializerName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := MessageFromRaw(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	return message, nil
+}
+
+// DeserializeRaw decodes data with the named serializer into its positional
+// WAMP representation without interpreting it as a message. JSON integers are
+// decoded as int64 rather than float64 so that they keep their precision.
+func DeserializeRaw(serializerName string, data []byte) ([]any, error) {
+	var raw []any
+	var err error
+	switch serializerName {
+	case JSONSerializer:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err = decoder.Decode(&raw); err == nil {
+			raw, _ = NormalizeJSON(raw).([]any)
+		}
+	case CBORSerializer:
+		err = cborDecMode.Unmarshal(data, &raw)
+	case MsgPackSerializer:
+		if err = checkMsgPackLengths(data); err == nil {
+			err = msgpack.Unmarshal(data, &raw)
+		}
+	default:
+		return nil, fmt.Errorf("unknown serializer %q", serializerName)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,53 @@
+package wampprotocli
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// TestMsgPackDeclaredLengths feeds containers declaring far more elements
+// than the data holds, which must fail instead of allocating by the declared
+// length.
+func TestMsgPackDeclaredLengths(t *testing.T) {
+	for _, input := range []string{
+		"dd7fffffff",     // array32 of 2^31-1 elements
+		"dfffffffff",     // map32 of 2^32-1 entries
+		"93dd7fffffff01", // nested array32
+		"9301dd7fffffff", // nested array32 after the message type
+		"dc0003",         // array16 of 3 elements without any
+	} {
+		data, err := hex.DecodeString(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = DeserializeRaw(MsgPackSerializer, data); err == nil {
+			t.Errorf("DeserializeRaw(%s): expected an error", input)
+		}
+
+		if _, err = FindDuplicateKeys(MsgPackSerializer, data); err == nil {
+			t.Errorf("FindDuplicateKeys(%s): expected an error", input)
+		}
+
+		if _, err = Parse(&ParseRequest{Serializer: MsgPackSerializer, Data: input}); err == nil {
+			t.Errorf("Parse(%s): expected an error", input)
+		}
+	}
+}
+
+func TestMsgPackDeclaredLengthsValid(t *testing.T) {
+	// [48, 1, {}, "a.b"]
+	data := []byte{0x94, 0x30, 0x01, 0x80, 0xa3, 'a', '.', 'b'}
+	if err := checkMsgPackLengths(data); err != nil {
+		t.Errorf("checkMsgPackLengths: %v", err)
+	}
+
+	raw, err := DeserializeRaw(MsgPackSerializer, data)
+	if err != nil {
+		t.Fatalf("DeserializeRaw: %v", err)
+	}
+
+	if len(raw) != 4 {
+		t.Errorf("expected 4 fields, got %v", raw)
+	}
+}
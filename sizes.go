@@ -0,0 +1,38 @@
+package wampprotocli
+
+import (
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// MessageSize is the size of a message serialized with one serializer.
+type MessageSize struct {
+	Serializer string `json:"serializer"`
+	Size       int    `json:"size"`
+	// Overhead is how much larger the message is than with the most compact
+	// serializer, e.g. 0.25 for 25% larger.
+	Overhead float64 `json:"overhead"`
+}
+
+// MessageSizes serializes message with every serializer and returns the
+// sizes in the order of SerializerNames.
+func MessageSizes(message messages.Message) ([]MessageSize, error) {
+	sizes := make([]MessageSize, 0, len(SerializerNames()))
+	smallest := 0
+	for _, name := range SerializerNames() {
+		data, err := SerializeMessage(name, message)
+		if err != nil {
+			return nil, err
+		}
+
+		sizes = append(sizes, MessageSize{Serializer: name, Size: len(data)})
+		if smallest == 0 || len(data) < smallest {
+			smallest = len(data)
+		}
+	}
+
+	for i := range sizes {
+		sizes[i].Overhead = float64(sizes[i].Size-smallest) / float64(smallest)
+	}
+
+	return sizes, nil
+}
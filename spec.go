@@ -0,0 +1,100 @@
+package wampprotocli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// Sections of the WAMP spec that messages and validation rules refer to.
+const (
+	SpecMessageType          = "WAMP Basic Profile, Message Codes and Direction"
+	SpecIDs                  = "WAMP Basic Profile, Identifiers, IDs"
+	SpecURIs                 = "WAMP Basic Profile, Identifiers, URIs"
+	SpecSerializations       = "WAMP Basic Profile, Serializations"
+	SpecSessionEstablishment = "WAMP Basic Profile, Session Establishment"
+	SpecSessionClosing       = "WAMP Basic Profile, Session Closing"
+	SpecPublishing           = "WAMP Basic Profile, Publishing and Events"
+	SpecSubscribing          = "WAMP Basic Profile, Subscribing and Unsubscribing"
+	SpecCalling              = "WAMP Basic Profile, Calling and Invocations"
+	SpecRegistering          = "WAMP Basic Profile, Registering and Unregistering"
+	SpecErrors               = "WAMP Basic Profile, Message Definitions, ERROR"
+	SpecFeatureAnnouncement  = "WAMP Advanced Profile, Feature Announcement"
+	SpecAuthentication       = "WAMP Advanced Profile, Authentication"
+	SpecCanceling            = "WAMP Advanced Profile, Call Canceling"
+	SpecBlackWhiteListing    = "WAMP Advanced Profile, Subscriber Black- and Whitelisting"
+	SpecPatternSubscription  = "WAMP Advanced Profile, Pattern-based Subscription"
+	SpecPatternRegistration  = "WAMP Advanced Profile, Pattern-based Registration"
+	SpecSharedRegistration   = "WAMP Advanced Profile, Shared Registration"
+	SpecPayloadPassthru      = "WAMP Advanced Profile, Payload Passthru Mode"
+)
+
+// Violation is a validation error with the category and the spec section of
+// the rule it breaks.
+type Violation struct {
+	Category string `json:"category"`
+	Spec     string `json:"spec"`
+	Message  string `json:"message"`
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s (see %s)", v.Message, v.Spec)
+}
+
+// Violations returns the violations err consists of, which may be joined
+// with other errors.
+func Violations(err error) []Violation {
+	var violations []Violation
+	var walk func(err error)
+	walk = func(err error) {
+		var violation *Violation
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range joined.Unwrap() {
+				walk(err)
+			}
+		} else if errors.As(err, &violation) {
+			violations = append(violations, *violation)
+		}
+	}
+
+	if err != nil {
+		walk(err)
+	}
+
+	return violations
+}
+
+// violations turns the errors of a validation rule into violations.
+func violations(category, spec string, errs []error) []error {
+	for i, err := range errs {
+		errs[i] = &Violation{Category: category, Spec: spec, Message: err.Error()}
+	}
+
+	return errs
+}
+
+// categorySpec returns the spec section of the warnings of a category that
+// don't depend on the message type.
+func categorySpec(category string) string {
+	switch category {
+	case CategoryURI, CategoryReserved:
+		return SpecURIs
+	case CategoryUTF8, CategoryNonFinite, CategoryDuplicate, CategoryPrecision:
+		return SpecSerializations
+	case CategoryFeature:
+		return SpecFeatureAnnouncement
+	default:
+		return ""
+	}
+}
+
+// matchSpec returns the spec section of pattern-based subscriptions or
+// registrations.
+func matchSpec(messageType int) string {
+	if messageType == messages.MessageTypeRegister {
+		return SpecPatternRegistration
+	}
+
+	return SpecPatternSubscription
+}
@@ -0,0 +1,187 @@
+package wampprotocli
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// TranscriptStats characterizes the traffic of a transcript.
+type TranscriptStats struct {
+	Records int `json:"records"`
+	// Messages counts the messages of each type.
+	Messages   map[string]int `json:"messages"`
+	Procedures []string       `json:"procedures"`
+	Topics     []string       `json:"topics"`
+	Sizes      SizeStats      `json:"sizes"`
+	// Requests counts the messages a router can fail with an ERROR, of which
+	// Errors were failed.
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	// Undecodable counts the records whose data could not be decoded.
+	Undecodable int            `json:"undecodable"`
+	Sessions    []SessionStats `json:"sessions"`
+}
+
+// SizeStats summarizes the sizes of serialized messages in bytes.
+type SizeStats struct {
+	Min  int `json:"min"`
+	Mean int `json:"mean"`
+	P50  int `json:"p50"`
+	P90  int `json:"p90"`
+	P99  int `json:"p99"`
+	Max  int `json:"max"`
+}
+
+// SessionStats describes one session of a transcript.
+type SessionStats struct {
+	Session  string        `json:"session"`
+	Messages int           `json:"messages"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// TranscriptSummary computes the statistics of a transcript one record at a
+// time, so that it doesn't have to be held in memory.
+type TranscriptSummary struct {
+	stats      *TranscriptStats
+	procedures map[string]any
+	topics     map[string]any
+	sessions   map[string]int
+	sizes      []int
+}
+
+// NewTranscriptSummary returns the summary of a transcript without records.
+func NewTranscriptSummary() *TranscriptSummary {
+	return &TranscriptSummary{
+		stats:      &TranscriptStats{Messages: map[string]int{}, Sessions: []SessionStats{}},
+		procedures: map[string]any{},
+		topics:     map[string]any{},
+		sessions:   map[string]int{},
+	}
+}
+
+// Add adds the next record of the transcript.
+func (t *TranscriptSummary) Add(record *TranscriptRecord) {
+	stats := t.stats
+	stats.Records++
+	t.sizes = append(t.sizes, len(record.Data))
+
+	index, ok := t.sessions[record.Session]
+	if !ok {
+		index = len(stats.Sessions)
+		t.sessions[record.Session] = index
+		stats.Sessions = append(stats.Sessions, SessionStats{Session: record.Session, Start: record.Time})
+	}
+
+	session := &stats.Sessions[index]
+	session.Messages++
+	if record.Time.Before(session.Start) {
+		session.Start = record.Time
+	}
+
+	if record.Time.After(session.End) {
+		session.End = record.Time
+	}
+
+	raw, err := DeserializeRaw(record.Serializer, record.Data)
+	if err != nil {
+		stats.Undecodable++
+		return
+	}
+
+	messageType := rawMessageType(raw)
+	stats.Messages[MessageName(messageType)]++
+
+	uri, ok := rawString(raw, 3)
+	switch {
+	case !ok:
+	case messageType == messages.MessageTypeCall || messageType == messages.MessageTypeRegister:
+		t.procedures[uri] = nil
+	case messageType == messages.MessageTypePublish || messageType == messages.MessageTypeSubscribe:
+		t.topics[uri] = nil
+	}
+
+	switch messageType {
+	case messages.MessageTypeCall, messages.MessageTypeRegister, messages.MessageTypeUnRegister,
+		messages.MessageTypePublish, messages.MessageTypeSubscribe, messages.MessageTypeUnSubscribe:
+		stats.Requests++
+	case messages.MessageTypeError:
+		// Callees failing invocations are counted as the ERROR the caller receives.
+		if len(raw) > 1 {
+			if requestType, _ := messages.AsInt64(raw[1]); requestType != messages.MessageTypeInvocation {
+				stats.Errors++
+			}
+		}
+	}
+}
+
+// Stats returns the statistics of the records added so far. Sessions are
+// listed in the order they first appear.
+func (t *TranscriptSummary) Stats() *TranscriptStats {
+	stats := *t.stats
+	stats.Messages = maps.Clone(t.stats.Messages)
+	stats.Sessions = slices.Clone(t.stats.Sessions)
+	stats.Procedures, stats.Topics = sortedKeys(t.procedures), sortedKeys(t.topics)
+	if stats.Requests > 0 {
+		stats.ErrorRate = float64(stats.Errors) / float64(stats.Requests)
+	}
+
+	for i := range stats.Sessions {
+		stats.Sessions[i].Duration = stats.Sessions[i].End.Sub(stats.Sessions[i].Start)
+	}
+
+	stats.Sizes = summarizeSizes(slices.Clone(t.sizes))
+	return &stats
+}
+
+// SummarizeTranscript computes the statistics of records. Sessions are listed
+// in the order they first appear.
+func SummarizeTranscript(records []*TranscriptRecord) *TranscriptStats {
+	summary := NewTranscriptSummary()
+	for _, record := range records {
+		summary.Add(record)
+	}
+
+	return summary.Stats()
+}
+
+// summarizeSizes returns the statistics of sizes, which it sorts.
+func summarizeSizes(sizes []int) SizeStats {
+	if len(sizes) == 0 {
+		return SizeStats{}
+	}
+
+	slices.Sort(sizes)
+	percentile := func(p float64) int {
+		return sizes[int(p*float64(len(sizes)-1))]
+	}
+
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+
+	return SizeStats{
+		Min:  sizes[0],
+		Mean: total / len(sizes),
+		P50:  percentile(0.50),
+		P90:  percentile(0.90),
+		P99:  percentile(0.99),
+		Max:  sizes[len(sizes)-1],
+	}
+}
+
+// rawString returns the string at position of the positional message raw.
+func rawString(raw []any, position int) (string, bool) {
+	if position >= len(raw) {
+		return "", false
+	}
+
+	value, ok := raw[position].(string)
+	return value, ok
+}
@@ -0,0 +1,316 @@
+package wampprotocli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Indexes of the streams of a wampConnection.
+const (
+	toRouter = iota
+	toClient
+)
+
+// Transports of a wampConnection.
+const (
+	transportUnknown = iota
+	transportWebSocket
+	transportRawSocket
+	// transportInvalid marks connections that are neither, which are ignored.
+	transportInvalid
+)
+
+// WebSocket opcodes.
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+)
+
+// rawSocketMagic starts the RawSocket opening handshake.
+const rawSocketMagic = 0x7f
+
+// rawSocketSerializer returns the serializer of a serializer ID of the
+// RawSocket handshake.
+func rawSocketSerializer(id byte) string {
+	switch id {
+	case 1:
+		return JSONSerializer
+	case 2:
+		return MsgPackSerializer
+	case 3:
+		return CBORSerializer
+	default:
+		return ""
+	}
+}
+
+// webSocketSerializer returns the serializer of a WAMP WebSocket subprotocol
+// such as wamp.2.json.
+func webSocketSerializer(subprotocol string) string {
+	name, ok := strings.CutPrefix(strings.TrimSpace(subprotocol), "wamp.2.")
+	if !ok {
+		return ""
+	}
+
+	if _, err := SerializerByName(name); err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// maxHTTPHeaderSize bounds the WebSocket opening handshake of a stream.
+const maxHTTPHeaderSize = 64 << 10
+
+// wampConnection decodes the WAMP messages of a reassembled TCP connection.
+type wampConnection struct {
+	session string
+	// limit is the size of the largest message that is decoded.
+	limit      int
+	transport  int
+	serializer string
+	streams    [2]tcpStream
+	handshake  [2]bool
+	// fragments holds the payload of fragmented WebSocket messages.
+	fragments [2][]byte
+	opcode    [2]byte
+}
+
+// directionName returns the transcript direction of the stream index direction.
+func directionName(direction int) string {
+	if direction == toClient {
+		return DirectionToClient
+	}
+
+	return DirectionToRouter
+}
+
+// decode emits every message of the stream in direction that is complete.
+func (c *wampConnection) decode(t time.Time, direction int, emit func(*TranscriptRecord) error) error {
+	stream := &c.streams[direction]
+	if c.transport == transportUnknown {
+		c.detectTransport(direction, stream.buffer)
+	}
+
+	for {
+		var payload []byte
+		var text bool
+		var consumed int
+		var err error
+		switch c.transport {
+		case transportWebSocket:
+			payload, text, consumed, err = c.nextWebSocketMessage(direction, stream.buffer)
+		case transportRawSocket:
+			payload, consumed, err = c.nextRawSocketMessage(direction, stream.buffer)
+		default:
+			if c.transport == transportInvalid {
+				stream.buffer = nil
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			stream.drop()
+			return emit(&TranscriptRecord{Time: t, Session: c.session, Direction: directionName(direction),
+				Serializer: c.serializer, Error: err.Error() + ", ignoring the rest of the stream"})
+		}
+
+		if consumed == 0 {
+			return nil
+		}
+
+		stream.buffer = stream.buffer[consumed:]
+		if payload == nil {
+			continue
+		}
+
+		if c.serializer == "" {
+			c.serializer = guessSerializer(payload, text)
+		}
+
+		if err := emit(NewTranscriptRecord(t, c.session, directionName(direction), c.serializer,
+			payload)); err != nil {
+			return err
+		}
+	}
+}
+
+// detectTransport tells the transport from the first bytes the client sends.
+// WebSocket connections captured after their handshake are recognized by a
+// masked data frame.
+func (c *wampConnection) detectTransport(direction int, data []byte) {
+	if direction != toRouter || len(data) < 2 {
+		return
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("GET ")):
+		c.transport = transportWebSocket
+	case data[0] == rawSocketMagic:
+		c.transport = transportRawSocket
+	case data[0]&0x70 == 0 && (data[0]&0x0f == wsText || data[0]&0x0f == wsBinary) && data[1]&0x80 != 0:
+		c.transport = transportWebSocket
+		c.handshake = [2]bool{true, true}
+	default:
+		c.transport = transportInvalid
+	}
+}
+
+// nextWebSocketMessage consumes the HTTP handshake or the next frame of data.
+// It returns the payload once a data message is complete, and 0 if data
+// doesn't hold a complete handshake or frame yet. Handshakes and messages
+// exceeding their limits fail.
+func (c *wampConnection) nextWebSocketMessage(direction int, data []byte) ([]byte, bool, int, error) {
+	if !c.handshake[direction] {
+		end := bytes.Index(data, []byte("\r\n\r\n"))
+		if end < 0 && len(data) > maxHTTPHeaderSize {
+			return nil, false, 0, fmt.Errorf("WebSocket handshake exceeds %d bytes", maxHTTPHeaderSize)
+		} else if end < 0 {
+			return nil, false, 0, nil
+		}
+
+		if direction == toClient {
+			for _, line := range strings.Split(string(data[:end]), "\r\n") {
+				name, value, ok := strings.Cut(line, ":")
+				if ok && strings.EqualFold(strings.TrimSpace(name), "Sec-WebSocket-Protocol") {
+					c.serializer = webSocketSerializer(value)
+				}
+			}
+		}
+
+		c.handshake[direction] = true
+		return nil, false, end + 4, nil
+	}
+
+	if len(data) < 2 {
+		return nil, false, 0, nil
+	}
+
+	fin, opcode := data[0]&0x80 != 0, data[0]&0x0f
+	masked, length, offset := data[1]&0x80 != 0, uint64(data[1]&0x7f), 2
+	switch length {
+	case 126:
+		if len(data) < 4 {
+			return nil, false, 0, nil
+		}
+
+		length, offset = uint64(binary.BigEndian.Uint16(data[2:4])), 4
+	case 127:
+		if len(data) < 10 {
+			return nil, false, 0, nil
+		}
+
+		length, offset = binary.BigEndian.Uint64(data[2:10]), 10
+	}
+
+	var mask []byte
+	if masked {
+		if len(data) < offset+4 {
+			return nil, false, 0, nil
+		}
+
+		mask, offset = data[offset:offset+4], offset+4
+	}
+
+	// Continuation frames add to the fragments of the message.
+	size := length
+	if opcode == wsContinuation && length <= uint64(c.limit) {
+		size += uint64(len(c.fragments[direction]))
+	}
+
+	if size > uint64(c.limit) {
+		return nil, false, 0, fmt.Errorf("WebSocket message of at least %d bytes exceeds the limit of %d bytes",
+			size, c.limit)
+	}
+
+	if uint64(len(data)-offset) < length {
+		return nil, false, 0, nil
+	}
+
+	end := offset + int(length) //nolint:gosec // bounded by len(data)
+	payload := append([]byte(nil), data[offset:end]...)
+	for i := range mask {
+		for j := i; j < len(payload); j += len(mask) {
+			payload[j] ^= mask[i]
+		}
+	}
+
+	switch opcode {
+	case wsText, wsBinary:
+		c.opcode[direction], c.fragments[direction] = opcode, payload
+	case wsContinuation:
+		c.fragments[direction] = append(c.fragments[direction], payload...)
+	default:
+		// Control frames don't carry WAMP messages.
+		return nil, false, end, nil
+	}
+
+	if !fin {
+		return nil, false, end, nil
+	}
+
+	message := c.fragments[direction]
+	c.fragments[direction] = nil
+	if message == nil {
+		message = []byte{}
+	}
+
+	return message, c.opcode[direction] == wsText, end, nil
+}
+
+// nextRawSocketMessage consumes the handshake or the next frame of data. It
+// returns the payload of regular messages, and 0 if data doesn't hold a
+// complete handshake or frame yet. Messages exceeding the limit fail.
+func (c *wampConnection) nextRawSocketMessage(direction int, data []byte) ([]byte, int, error) {
+	const headerSize = 4
+	if len(data) < headerSize {
+		return nil, 0, nil
+	}
+
+	if !c.handshake[direction] {
+		c.handshake[direction] = true
+		if serializer := rawSocketSerializer(data[1] & 0x0f); serializer != "" {
+			c.serializer = serializer
+		}
+
+		return nil, headerSize, nil
+	}
+
+	length := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if length > c.limit {
+		return nil, 0, fmt.Errorf("RawSocket message of %d bytes exceeds the limit of %d bytes", length, c.limit)
+	}
+
+	if len(data) < headerSize+length {
+		return nil, 0, nil
+	}
+
+	end := headerSize + length
+	if data[0]&0x07 != 0 {
+		// Pings and pongs don't carry WAMP messages.
+		return nil, end, nil
+	}
+
+	return append([]byte(nil), data[headerSize:end]...), end, nil
+}
+
+// guessSerializer returns the serializer of a message whose connection didn't
+// name one: JSON for text, otherwise whichever binary serializer decodes it.
+func guessSerializer(data []byte, text bool) string {
+	if text || bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return JSONSerializer
+	}
+
+	for _, serializer := range []string{CBORSerializer, MsgPackSerializer} {
+		if raw, err := DeserializeRaw(serializer, data); err == nil && rawMessageType(raw) != 0 {
+			return serializer
+		}
+	}
+
+	return CBORSerializer
+}
@@ -0,0 +1,278 @@
+package wampprotocli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// Directions of transcript records.
+const (
+	DirectionToRouter = "to-router"
+	DirectionToClient = "to-client"
+)
+
+// TranscriptRecord is one WAMP message of a transcript, a recording of the
+// traffic of one or more sessions. Transcripts are stored as NDJSON, one
+// record per line, conventionally in files ending in .wampt.
+type TranscriptRecord struct {
+	Time time.Time `json:"time"`
+	// Session identifies the connection the message was exchanged on, e.g. the
+	// address and port of the client.
+	Session    string `json:"session"`
+	Direction  string `json:"direction"`
+	Serializer string `json:"serializer"`
+	// Data is the serialized message as it was sent.
+	Data    []byte `json:"data"`
+	Name    string `json:"name,omitempty"`
+	Message []any  `json:"message,omitempty"`
+	// Error explains why Data could not be decoded.
+	Error string `json:"error,omitempty"`
+}
+
+// NewTranscriptRecord returns the record of the serialized message data,
+// decoding it with serializer.
+func NewTranscriptRecord(t time.Time, session, direction, serializer string, data []byte) *TranscriptRecord {
+	record := &TranscriptRecord{
+		Time:       t,
+		Session:    session,
+		Direction:  direction,
+		Serializer: serializer,
+		Data:       data,
+	}
+
+	raw, err := DeserializeRaw(serializer, data)
+	if err != nil {
+		record.Error = err.Error()
+		return record
+	}
+
+	record.Message = raw
+	record.Name = MessageName(rawMessageType(raw))
+	return record
+}
+
+// TranscriptReader reads the records of an NDJSON transcript one by one,
+// holding no more than a single record in memory.
+type TranscriptReader struct {
+	scanner *bufio.Scanner
+	line    int
+	// limit is the size of the largest message a record may hold.
+	limit int
+}
+
+// NewTranscriptReader returns a reader of the records of the transcript r.
+// Records of messages larger than maxMessageSize bytes fail, 0 limits them to
+// the largest message RawSocket allows.
+func NewTranscriptReader(r io.Reader, maxMessageSize int) *TranscriptReader {
+	limit := maxFramedMessageSize
+	if maxMessageSize > 0 {
+		limit = maxMessageSize
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(bufio.MaxScanTokenSize, transcriptLineLimit(limit))),
+		transcriptLineLimit(limit))
+	return &TranscriptReader{scanner: scanner, limit: limit}
+}
+
+// transcriptLineLimit returns the length of the longest line of a record of
+// a message of limit bytes, which it holds as base64 and decoded.
+func transcriptLineLimit(limit int) int {
+	const overhead = 64 << 10
+	return 4*limit + overhead
+}
+
+// Next returns the next record, or io.EOF at the end of the transcript.
+func (t *TranscriptReader) Next() (*TranscriptRecord, error) {
+	for t.scanner.Scan() {
+		t.line++
+		if len(t.scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record TranscriptRecord
+		if err := json.Unmarshal(t.scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("transcript line %d: %w", t.line, err)
+		}
+
+		if err := CheckMessageSize(len(record.Data), t.limit); err != nil {
+			return nil, fmt.Errorf("transcript line %d: %w", t.line, err)
+		}
+
+		return &record, nil
+	}
+
+	if err := t.scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		return nil, fmt.Errorf("transcript line %d exceeds the %d bytes of a record of a message of %d bytes",
+			t.line+1, transcriptLineLimit(t.limit), t.limit)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// Line returns the line of the record Next returned last.
+func (t *TranscriptReader) Line() int {
+	return t.line
+}
+
+// ReadTranscript reads the records of an NDJSON transcript, calling fn for
+// each of them in order. maxMessageSize is as for NewTranscriptReader.
+func ReadTranscript(r io.Reader, maxMessageSize int, fn func(*TranscriptRecord) error) error {
+	reader := NewTranscriptReader(r, maxMessageSize)
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err = fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// ConvertRecord re-serializes the message of record with serializer. Binary
+// values are converted between native binary values and the base64 strings
+// JSON carries them as.
+func ConvertRecord(record *TranscriptRecord, serializer string) error {
+	if _, err := SerializerByName(serializer); err != nil {
+		return err
+	}
+
+	raw, err := DeserializeRaw(record.Serializer, record.Data)
+	if err != nil {
+		return err
+	}
+
+	converted, _ := convertBinary(raw, record.Serializer, serializer).([]any)
+	data, err := serializeValue(serializer, converted)
+	if err != nil {
+		return err
+	}
+
+	record.Serializer, record.Data, record.Message = serializer, data, converted
+	record.Name = MessageName(rawMessageType(converted))
+	return nil
+}
+
+// convertBinary converts the binary values below value from their
+// representation with serializer from to the one with serializer to.
+func convertBinary(value any, from, to string) any {
+	if data, ok := decodeBinary(from, value); ok {
+		return encodeBinary(to, data)
+	}
+
+	switch v := value.(type) {
+	case []any:
+		converted := make([]any, len(v))
+		for i, item := range v {
+			converted[i] = convertBinary(item, from, to)
+		}
+
+		return converted
+	case map[string]any:
+		converted := make(map[string]any, len(v))
+		for key, item := range v {
+			converted[key] = convertBinary(item, from, to)
+		}
+
+		return converted
+	default:
+		return value
+	}
+}
+
+// SplitTranscript groups records by session, keeping their order. Sessions
+// are returned in the order they first appear.
+func SplitTranscript(records []*TranscriptRecord) [][]*TranscriptRecord {
+	var sessions [][]*TranscriptRecord
+	indexes := map[string]int{}
+	for _, record := range records {
+		index, ok := indexes[record.Session]
+		if !ok {
+			index = len(sessions)
+			indexes[record.Session] = index
+			sessions = append(sessions, nil)
+		}
+
+		sessions[index] = append(sessions[index], record)
+	}
+
+	return sessions
+}
+
+// MergeTranscriptReaders merges the records of transcripts ordered by time
+// like MergeTranscripts, calling fn for each of them in order. It holds only
+// the next record of each transcript in memory, so the records of every
+// transcript must be ordered by time already, as DecodePcap writes them.
+func MergeTranscriptReaders(readers []*TranscriptReader, fn func(*TranscriptRecord) error) error {
+	next := make([]*TranscriptRecord, len(readers))
+	advance := func(i int) error {
+		record, err := readers[i].Next()
+		if errors.Is(err, io.EOF) {
+			next[i] = nil
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("transcript %d: %w", i+1, err)
+		}
+
+		if next[i] != nil && record.Time.Before(next[i].Time) {
+			return fmt.Errorf("transcript %d: line %d is older than the record before it, the records of "+
+				"merged transcripts must be ordered by time", i+1, readers[i].Line())
+		}
+
+		next[i] = record
+		return nil
+	}
+
+	for i := range readers {
+		if err := advance(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		earliest := -1
+		for i, record := range next {
+			if record != nil && (earliest < 0 || record.Time.Before(next[earliest].Time)) {
+				earliest = i
+			}
+		}
+
+		if earliest < 0 {
+			return nil
+		}
+
+		if err := fn(next[earliest]); err != nil {
+			return err
+		}
+
+		if err := advance(earliest); err != nil {
+			return err
+		}
+	}
+}
+
+// MergeTranscripts merges the records of transcripts ordered by time. Records
+// with the same time keep the order of their transcripts and within them.
+func MergeTranscripts(transcripts ...[]*TranscriptRecord) []*TranscriptRecord {
+	var merged []*TranscriptRecord
+	for _, records := range transcripts {
+		merged = append(merged, records...)
+	}
+
+	slices.SortStableFunc(merged, func(a, b *TranscriptRecord) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return merged
+}
@@ -0,0 +1,74 @@
+package wampprotocli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// transcript returns an NDJSON transcript of a record per time, given in
+// seconds, with a message of size bytes.
+func transcript(t *testing.T, session string, size int, times ...int) string {
+	t.Helper()
+
+	var lines []string
+	for _, seconds := range times {
+		line, err := json.Marshal(TranscriptRecord{Time: time.Unix(int64(seconds), 0).UTC(), Session: session,
+			Direction: DirectionToRouter, Serializer: JSONSerializer, Data: make([]byte, size)})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines = append(lines, string(line))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestTranscriptReaderLimit(t *testing.T) {
+	err := ReadTranscript(strings.NewReader(transcript(t, "a", 8, 1, 2)), 8, func(*TranscriptRecord) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected messages of the limit to be read, got %v", err)
+	}
+
+	if err = ReadTranscript(strings.NewReader(transcript(t, "a", 9, 1)), 8, func(*TranscriptRecord) error {
+		return nil
+	}); err == nil {
+		t.Error("expected a message beyond the limit to fail")
+	}
+
+	// Lines too long for any record of the limit fail without being read.
+	long := `{"data":"` + strings.Repeat("A", transcriptLineLimit(8)) + `"}` + "\n"
+	if err = ReadTranscript(strings.NewReader(long), 8, func(*TranscriptRecord) error { return nil }); err == nil {
+		t.Error("expected a line beyond the limit to fail")
+	}
+}
+
+func TestMergeTranscriptReaders(t *testing.T) {
+	readers := []*TranscriptReader{
+		NewTranscriptReader(strings.NewReader(transcript(t, "a", 1, 1, 3, 3, 5)), 0),
+		NewTranscriptReader(strings.NewReader(transcript(t, "b", 1, 0, 3, 6)), 0),
+	}
+
+	var merged []string
+	err := MergeTranscriptReaders(readers, func(record *TranscriptRecord) error {
+		merged = append(merged, record.Session+record.Time.Format("05"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Records with the same time keep the order of their transcripts.
+	if expected := "b00 a01 a03 a03 b03 a05 b06"; strings.Join(merged, " ") != expected {
+		t.Errorf("expected %s, got %s", expected, strings.Join(merged, " "))
+	}
+
+	readers = []*TranscriptReader{NewTranscriptReader(strings.NewReader(transcript(t, "a", 1, 2, 1)), 0)}
+	if err = MergeTranscriptReaders(readers, func(*TranscriptRecord) error { return nil }); err == nil {
+		t.Error("expected records out of order to fail")
+	}
+}
@@ -0,0 +1,105 @@
+package wampprotocli
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// reservedURIPrefix marks URIs reserved for the WAMP protocol itself.
+const reservedURIPrefix = "wamp."
+
+var (
+	looseURIPattern          = regexp.MustCompile(`^([^\s.#]+\.)*([^\s.#]+)$`)           //nolint:gochecknoglobals
+	strictURIPattern         = regexp.MustCompile(`^([0-9a-z_]+\.)*([0-9a-z_]+)$`)       //nolint:gochecknoglobals
+	looseWildcardURIPattern  = regexp.MustCompile(`^(([^\s.#]+\.)|\.)*([^\s.#]+)?$`)     //nolint:gochecknoglobals
+	strictWildcardURIPattern = regexp.MustCompile(`^(([0-9a-z_]+\.)|\.)*([0-9a-z_]+)?$`) //nolint:gochecknoglobals
+)
+
+// ValidateURI checks uri against the WAMP URI rules. Loose URIs may contain
+// any characters except whitespace and "#", strict URIs are restricted to
+// lowercase letters, digits and "_". In both cases components must not be
+// empty unless wildcard is set, as for wildcard matching patterns.
+func ValidateURI(uri string, strict, wildcard bool) error {
+	if uri == "" {
+		return errors.New("URI must not be empty")
+	}
+
+	var pattern *regexp.Regexp
+	switch {
+	case strict && wildcard:
+		pattern = strictWildcardURIPattern
+	case strict:
+		pattern = strictURIPattern
+	case wildcard:
+		pattern = looseWildcardURIPattern
+	default:
+		pattern = looseURIPattern
+	}
+
+	if pattern.MatchString(uri) {
+		return nil
+	}
+
+	for i, component := range strings.Split(uri, ".") {
+		if component == "" && !wildcard {
+			return fmt.Errorf("URI %q has an empty component at position %d", uri, i+1)
+		}
+
+		for _, r := range component {
+			if !uriRuneAllowed(r, strict) {
+				return fmt.Errorf("URI %q has disallowed character %q in component %q", uri, r, component)
+			}
+		}
+	}
+
+	return fmt.Errorf("URI %q is invalid", uri)
+}
+
+func uriRuneAllowed(r rune, strict bool) bool {
+	if strict {
+		return r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_'
+	}
+
+	return r != '#' && !unicode.IsSpace(r)
+}
+
+// IsReservedURI reports whether uri is reserved for the WAMP protocol, which
+// clients must not register procedures or publish events under.
+func IsReservedURI(uri string) bool {
+	return strings.HasPrefix(uri, reservedURIPrefix)
+}
+
+// ValidateRealm checks that name is a valid realm, i.e. a URI that is not
+// reserved for the WAMP protocol.
+func ValidateRealm(name string, strict bool) error {
+	if err := ValidateURI(name, strict, false); err != nil {
+		return err
+	}
+
+	if IsReservedURI(name) {
+		return fmt.Errorf("realm %q is reserved for the WAMP protocol", name)
+	}
+
+	return nil
+}
+
+// RandomRealm returns a realm below prefix with a random last component, which
+// is valid under the strict URI rules if prefix is.
+func RandomRealm(prefix string) (string, error) {
+	suffix := make([]byte, 8)
+	if _, err := io.ReadFull(RandomReader(), suffix); err != nil {
+		return "", err
+	}
+
+	realm := prefix + "." + hex.EncodeToString(suffix)
+	if err := ValidateRealm(realm, false); err != nil {
+		return "", err
+	}
+
+	return realm, nil
+}
@@ -0,0 +1,501 @@
+package wampprotocli
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xconnio/wampproto-go/messages"
+)
+
+// MaxID is the largest valid WAMP ID. IDs are drawn from [1, 2^53] so that
+// they can be represented exactly by an IEEE-754 double.
+const MaxID = 1 << 53
+
+// Warning and violation categories.
+const (
+	CategoryID        = "id"
+	CategoryURI       = "uri"
+	CategoryOption    = "option"
+	CategoryUTF8      = "utf8"
+	CategoryFeature   = "feature"
+	CategoryNonFinite = "nonfinite"
+	CategoryDuplicate = "duplicate"
+	CategoryPrecision = "precision"
+	CategoryReserved  = "reserved"
+	CategoryStructure = "structure"
+)
+
+const (
+	requestIDField      = "request_id"
+	sessionIDField      = "session_id"
+	publicationIDField  = "publication_id"
+	subscriptionIDField = "subscription_id"
+	registrationIDField = "registration_id"
+)
+
+type idField struct {
+	position int
+	name     string
+}
+
+// idFields returns the positions and names of the ID fields of a message type.
+func idFields(messageType int) []idField {
+	switch messageType {
+	case messages.MessageTypeWelcome:
+		return []idField{{1, sessionIDField}}
+	case messages.MessageTypeError:
+		return []idField{{2, requestIDField}}
+	case messages.MessageTypePublish, messages.MessageTypeSubscribe, messages.MessageTypeUnSubscribed,
+		messages.MessageTypeCall, messages.MessageTypeCancel, messages.MessageTypeResult,
+		messages.MessageTypeRegister, messages.MessageTypeUnRegistered, messages.MessageTypeInterrupt,
+		messages.MessageTypeYield:
+		return []idField{{1, requestIDField}}
+	case messages.MessageTypePublished:
+		return []idField{{1, requestIDField}, {2, publicationIDField}}
+	case messages.MessageTypeSubscribed, messages.MessageTypeUnSubscribe:
+		return []idField{{1, requestIDField}, {2, subscriptionIDField}}
+	case messages.MessageTypeEvent:
+		return []idField{{1, subscriptionIDField}, {2, publicationIDField}}
+	case messages.MessageTypeRegistered, messages.MessageTypeUnRegister, messages.MessageTypeInvocation:
+		return []idField{{1, requestIDField}, {2, registrationIDField}}
+	default:
+		return nil
+	}
+}
+
+// Warning is a deviation from the WAMP spec that peers are expected to
+// tolerate, such as an unknown option key.
+type Warning struct {
+	Category string `json:"category"`
+	Message  string `json:"message"`
+	// Spec is the section of the WAMP spec the warning refers to.
+	Spec string `json:"spec,omitempty"`
+}
+
+func (w Warning) String() string {
+	return w.Category + ": " + w.Message
+}
+
+// ValidationOptions control how strictly messages are validated.
+type ValidationOptions struct {
+	// Strict reports warnings as errors.
+	Strict bool
+	// Lenient reports invalid UTF-8 as a warning instead of an error.
+	Lenient bool
+	// AllowReserved accepts procedures and topics reserved for the WAMP
+	// protocol in REGISTER and PUBLISH messages, which routers reject.
+	AllowReserved bool
+}
+
+// ValidateMessage checks message against the WAMP spec. Violations are
+// returned joined into a single error, deviations that peers are expected to
+// tolerate are returned as warnings. Every violation is a *Violation naming
+// the spec section of the rule, see Violations.
+func ValidateMessage(message messages.Message, options ValidationOptions) ([]Warning, error) {
+	return validateRules(message.Type(), MarshalMessage(message), options)
+}
+
+// ValidateRaw is ValidateMessage for the positional message raw as it was
+// decoded, before it is converted to a message. The conversion truncates IDs
+// encoded as floats and drops fields of the wrong type, so that only raw
+// shows them.
+func ValidateRaw(raw []any, options ValidationOptions) ([]Warning, error) {
+	if structure := validateStructure(raw); len(structure) > 0 {
+		errs := make([]error, 0, len(structure))
+		for i := range structure {
+			errs = append(errs, &structure[i])
+		}
+
+		return nil, errors.Join(errs...)
+	}
+
+	return validateRules(rawMessageType(raw), raw, options)
+}
+
+// validateRules checks the positional message raw of type messageType
+// against the rules of the spec beyond its structure.
+func validateRules(messageType int, raw []any, options ValidationOptions) ([]Warning, error) {
+	var errs []error
+	errs = append(errs, violations(CategoryID, SpecIDs, validateIDs(messageType, raw))...)
+	errs = append(errs, violations(CategoryID, SpecBlackWhiteListing, validateIDLists(messageType, raw))...)
+	errs = append(errs, violations(CategoryURI, SpecURIs,
+		validateURIs(messageType, raw, options.AllowReserved))...)
+
+	matchWarnings, matchErrs := validateMatch(messageType, raw)
+	errs = append(errs, violations(CategoryOption, matchSpec(messageType), matchErrs)...)
+	errs = append(errs, violations(CategoryOption, SpecSharedRegistration, validateInvoke(messageType, raw))...)
+
+	var warnings []Warning
+	warnings = append(warnings, matchWarnings...)
+	warnings = append(warnings, validateOptionKeys(messageType, raw)...)
+	warnings = append(warnings, validateRoles(messageType, raw)...)
+
+	utf8Warnings := validateUTF8(raw, newScanPath())
+	if options.Lenient {
+		warnings = append(warnings, utf8Warnings...)
+	} else {
+		errs = append(errs, warningErrors(utf8Warnings)...)
+	}
+
+	return finishValidation(warnings, errs, options)
+}
+
+// ValidatePayload checks serialized data before it is decoded, catching
+// problems that decoding would hide: invalid UTF-8 in JSON, which is silently
+// replaced by U+FFFD, duplicate map keys, of which only one value is kept, and
+// JSON numbers that cannot be decoded exactly.
+func ValidatePayload(serializerName string, data []byte, options ValidationOptions) ([]Warning, error) {
+	var warnings, errs []Warning
+	if serializerName == JSONSerializer && !utf8.Valid(data) {
+		warning := Warning{Category: CategoryUTF8, Message: "JSON payload is not valid UTF-8"}
+		if options.Lenient {
+			warnings = append(warnings, warning)
+		} else {
+			errs = append(errs, warning)
+		}
+	}
+
+	// Malformed data is left to the decoder to report.
+	if scan, err := scanPayload(serializerName, data, false); err == nil {
+		warnings = append(warnings, scan.duplicates...)
+		warnings = append(warnings, scan.precision...)
+	}
+
+	return finishValidation(warnings, warningErrors(errs), options)
+}
+
+// CheckMessageSize fails if a serialized message of size bytes exceeds limit,
+// as a router enforcing its maximum message size would. A limit of 0 disables
+// the check.
+func CheckMessageSize(size, limit int) error {
+	if limit > 0 && size > limit {
+		return fmt.Errorf("message size of %d bytes exceeds the limit of %d bytes", size, limit)
+	}
+
+	return nil
+}
+
+// finishValidation applies options.Strict, which turns warnings into errors,
+// after filling in the spec sections of the warnings.
+func finishValidation(warnings []Warning, errs []error, options ValidationOptions) ([]Warning, error) {
+	for i := range warnings {
+		if warnings[i].Spec == "" {
+			warnings[i].Spec = categorySpec(warnings[i].Category)
+		}
+	}
+
+	if options.Strict {
+		errs = append(errs, warningErrors(warnings)...)
+		warnings = nil
+	}
+
+	return warnings, errors.Join(errs...)
+}
+
+func warningErrors(warnings []Warning) []error {
+	errs := make([]error, 0, len(warnings))
+	for _, warning := range warnings {
+		spec := warning.Spec
+		if spec == "" {
+			spec = categorySpec(warning.Category)
+		}
+
+		errs = append(errs, &Violation{Category: warning.Category, Spec: spec, Message: warning.Message})
+	}
+
+	return errs
+}
+
+func validateIDs(messageType int, raw []any) []error {
+	var errs []error
+	for _, field := range idFields(messageType) {
+		if field.position >= len(raw) {
+			continue
+		}
+
+		id, ok := messages.AsInt64(raw[field.position])
+		if !ok {
+			continue
+		}
+
+		if id < 1 || id > MaxID {
+			errs = append(errs, fmt.Errorf("%s %d is out of range, must be between 1 and 2^53", field.name, id))
+		}
+	}
+
+	return errs
+}
+
+// validateIDLists checks the session ID lists of PUBLISH options that black-
+// or whitelist subscribers.
+func validateIDLists(messageType int, raw []any) []error {
+	const optionsPosition = 2
+	if messageType != messages.MessageTypePublish || optionsPosition >= len(raw) {
+		return nil
+	}
+
+	options, _ := raw[optionsPosition].(map[string]any)
+
+	var errs []error
+	for _, key := range []string{"exclude", "eligible"} {
+		value, ok := options[key]
+		if !ok {
+			continue
+		}
+
+		list, ok := value.([]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("PUBLISH option %s must be a list of session IDs", key))
+			continue
+		}
+
+		for _, item := range list {
+			if id, ok := messages.AsInt64(item); !ok || id < 1 || id > MaxID {
+				errs = append(errs, fmt.Errorf("PUBLISH option %s contains %v, which is not a valid session ID",
+					key, item))
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateURIs applies loose URI validation to the procedure or topic of
+// CALL, REGISTER, SUBSCRIBE and PUBLISH messages. Patterns of REGISTER and
+// SUBSCRIBE messages requested with match=wildcard may contain empty
+// components. Reserved URIs are rejected in REGISTER and PUBLISH messages
+// unless allowReserved is set, whereas calling meta procedures and subscribing
+// to meta events is legitimate.
+func validateURIs(messageType int, raw []any, allowReserved bool) []error {
+	const optionsPosition, uriPosition = 2, 3
+
+	switch messageType {
+	case messages.MessageTypeCall, messages.MessageTypeRegister, messages.MessageTypeSubscribe,
+		messages.MessageTypePublish:
+	default:
+		return nil
+	}
+
+	if uriPosition >= len(raw) {
+		return nil
+	}
+
+	uri, ok := raw[uriPosition].(string)
+	if !ok {
+		return nil
+	}
+
+	options, _ := raw[optionsPosition].(map[string]any)
+	pattern := messageType == messages.MessageTypeRegister || messageType == messages.MessageTypeSubscribe
+	wildcard := pattern && options[matchOption] == MatchWildcard
+	if err := ValidateURI(uri, false, wildcard); err != nil {
+		if pattern && !wildcard && ValidateURI(uri, false, true) == nil {
+			return []error{fmt.Errorf("%w, which only match=%s allows", err, MatchWildcard)}
+		}
+
+		return []error{err}
+	}
+
+	reservable := messageType == messages.MessageTypeRegister || messageType == messages.MessageTypePublish
+	if reservable && !allowReserved && IsReservedURI(uri) {
+		return []error{fmt.Errorf("URI %q is reserved for the WAMP protocol and cannot be used with %s",
+			uri, MessageName(messageType))}
+	}
+
+	return nil
+}
+
+// validateMatch checks the match option of REGISTER and SUBSCRIBE messages,
+// which routers reject unless it is a known policy, and warns about wildcard
+// patterns without empty components, which only match themselves.
+func validateMatch(messageType int, raw []any) ([]Warning, []error) {
+	const optionsPosition, uriPosition = 2, 3
+	if messageType != messages.MessageTypeRegister && messageType != messages.MessageTypeSubscribe ||
+		uriPosition >= len(raw) {
+		return nil, nil
+	}
+
+	options, _ := raw[optionsPosition].(map[string]any)
+	value, ok := options[matchOption]
+	if !ok {
+		return nil, nil
+	}
+
+	policy, ok := value.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("%s option %s must be a string, was %v", MessageName(messageType),
+			matchOption, value)}
+	}
+
+	if !slices.Contains(MatchPolicies(), policy) {
+		return nil, []error{fmt.Errorf("unknown %s option %s=%q, must be one of %v", MessageName(messageType),
+			matchOption, policy, MatchPolicies())}
+	}
+
+	uri, _ := raw[uriPosition].(string)
+	if policy == MatchWildcard && !slices.Contains(strings.Split(uri, "."), "") {
+		return []Warning{{
+			Category: CategoryOption,
+			Message: fmt.Sprintf("%s pattern %q has no empty components, with match=%s it only matches itself",
+				MessageName(messageType), uri, MatchWildcard),
+			Spec: matchSpec(messageType),
+		}}, nil
+	}
+
+	return nil, nil
+}
+
+// validateInvoke checks the invoke option of REGISTER messages, which
+// dealers reject unless it is a known invocation policy.
+func validateInvoke(messageType int, raw []any) []error {
+	const optionsPosition = 2
+	if messageType != messages.MessageTypeRegister || optionsPosition >= len(raw) {
+		return nil
+	}
+
+	options, _ := raw[optionsPosition].(map[string]any)
+	value, ok := options[invokeOption]
+	if !ok {
+		return nil
+	}
+
+	if policy, ok := value.(string); !ok || !slices.Contains(InvokePolicies(), policy) {
+		return []error{fmt.Errorf("unknown REGISTER option %s=%v, must be one of %v", invokeOption, value,
+			InvokePolicies())}
+	}
+
+	return nil
+}
+
+// Option keys defined by the spec, shared by several message types.
+const (
+	forwardForOption    = "forward_for"
+	pptSchemeOption     = "ppt_scheme"
+	pptSerializerOption = "ppt_serializer"
+	pptCipherOption     = "ppt_cipher"
+	pptKeyIDOption      = "ppt_keyid"
+	progressOption      = "progress"
+	matchOption         = "match"
+	invokeOption        = "invoke"
+	modeOption          = "mode"
+	messageOption       = "message"
+	timeoutOption       = "timeout"
+	reasonOption        = "reason"
+	retainOption        = "retain"
+	getRetainedOption   = "get_retained"
+	retainedOption      = "retained"
+)
+
+// optionKeys returns the position of the options or details dictionary of a
+// message type and the keys the spec defines for it. Message types whose
+// dictionary is free-form, like the extra of CHALLENGE, return no keys.
+func optionKeys(messageType int) (int, []string) {
+	ppt := []string{pptSchemeOption, pptSerializerOption, pptCipherOption, pptKeyIDOption}
+
+	switch messageType {
+	case messages.MessageTypeHello:
+		return 2, []string{"roles", "agent", "authmethods", "authid", "authrole", "authextra", "resumable",
+			"resume-session", "resume-token"}
+	case messages.MessageTypeWelcome:
+		return 2, []string{"roles", "agent", "authid", "authrole", "authmethod", "authprovider", "authextra",
+			"realm", "resumed", "resumable", "resume-token"}
+	case messages.MessageTypeAbort, messages.MessageTypeGoodbye:
+		return 1, []string{messageOption}
+	case messages.MessageTypePublish:
+		return 2, append([]string{"acknowledge", "exclude_me", "exclude", "exclude_authid", "exclude_authrole",
+			"eligible", "eligible_authid", "eligible_authrole", "disclose_me", retainOption, forwardForOption}, ppt...)
+	case messages.MessageTypeSubscribe:
+		return 2, []string{matchOption, getRetainedOption, forwardForOption}
+	case messages.MessageTypeEvent:
+		return 3, append([]string{"publisher", "publisher_authid", "publisher_authrole", "topic", retainedOption,
+			forwardForOption}, ppt...)
+	case messages.MessageTypeCall:
+		return 2, append([]string{"receive_progress", progressOption, timeoutOption, "disclose_me",
+			forwardForOption}, ppt...)
+	case messages.MessageTypeCancel:
+		return 2, []string{modeOption, forwardForOption}
+	case messages.MessageTypeResult, messages.MessageTypeYield:
+		return 2, append([]string{progressOption, forwardForOption}, ppt...)
+	case messages.MessageTypeRegister:
+		return 2, []string{matchOption, invokeOption, "concurrency", "disclose_caller", "force_reregister",
+			forwardForOption}
+	case messages.MessageTypeInvocation:
+		return 3, append([]string{"caller", "caller_authid", "caller_authrole", "procedure", "receive_progress",
+			progressOption, timeoutOption, forwardForOption}, ppt...)
+	case messages.MessageTypeInterrupt:
+		return 2, []string{modeOption, reasonOption, forwardForOption}
+	default:
+		return 0, nil
+	}
+}
+
+// OptionKeys returns the option or detail keys the spec defines for a message
+// type, or nil if its dictionary is free-form.
+func OptionKeys(messageType int) []string {
+	_, keys := optionKeys(messageType)
+	return keys
+}
+
+// validateOptionKeys warns about option or detail keys that the spec does
+// not define for the message type. Keys starting with "_" are reserved for
+// implementation specific extensions and are always accepted.
+func validateOptionKeys(messageType int, raw []any) []Warning {
+	position, allowed := optionKeys(messageType)
+	if allowed == nil || position >= len(raw) {
+		return nil
+	}
+
+	options, _ := raw[position].(map[string]any)
+	_, spec := messageSchema(messageType)
+
+	var warnings []Warning
+	for _, key := range sortedKeys(options) {
+		if strings.HasPrefix(key, "_") || slices.Contains(allowed, key) {
+			continue
+		}
+
+		warnings = append(warnings, Warning{
+			Category: CategoryOption,
+			Message:  fmt.Sprintf("unknown key %q in %s options", key, MessageName(messageType)),
+			Spec:     spec,
+		})
+	}
+
+	return warnings
+}
+
+// validateUTF8 reports every string, including map keys, below value that is
+// not valid UTF-8. path locates value within the message, e.g. [5]["name"].
+func validateUTF8(value any, path scanPath) []Warning {
+	var warnings []Warning
+	switch v := value.(type) {
+	case string:
+		if !utf8.ValidString(v) {
+			warnings = append(warnings, Warning{
+				Category: CategoryUTF8,
+				Message:  fmt.Sprintf("invalid UTF-8 in string %q at %s", v, path),
+			})
+		}
+	case []any:
+		for i, item := range v {
+			warnings = append(warnings, validateUTF8(item, path.index(i))...)
+		}
+	case map[string]any:
+		for _, key := range sortedKeys(v) {
+			itemPath := path.key(key)
+			if !utf8.ValidString(key) {
+				warnings = append(warnings, Warning{
+					Category: CategoryUTF8,
+					Message:  fmt.Sprintf("invalid UTF-8 in key %q at %s", key, itemPath),
+				})
+			}
+
+			warnings = append(warnings, validateUTF8(v[key], itemPath)...)
+		}
+	}
+
+	return warnings
+}